@@ -0,0 +1,138 @@
+// Command hnswbench measures the recall and latency of the HNSW vector store
+// against exact brute-force search on synthetic clustered data, to help tune
+// M, efConstruction and efSearch.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/robstave/gorag/internal/adapters/repositories/vectorstore"
+	"github.com/robstave/gorag/internal/domain/types"
+)
+
+func main() {
+	n := flag.Int("n", 10000, "number of vectors to index")
+	dim := flag.Int("dim", 128, "vector dimension")
+	clusters := flag.Int("clusters", 50, "number of clusters the data is drawn from")
+	queries := flag.Int("queries", 200, "number of queries")
+	k := flag.Int("k", 10, "results per query")
+	m := flag.Int("m", 16, "HNSW M")
+	efConstruction := flag.Int("ef-construction", 200, "HNSW efConstruction")
+	efSearch := flag.String("ef", "16,32,64,128,256", "comma-separated efSearch values to sweep")
+	metric := flag.String("metric", vectorstore.MetricCosine, "distance metric: cosine, l2 or ip")
+	seed := flag.Int64("seed", 1, "random seed")
+	flag.Parse()
+
+	var efValues []int
+	for _, v := range strings.Split(*efSearch, ",") {
+		ef, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			log.Fatalf("Invalid ef value %q: %v", v, err)
+		}
+		efValues = append(efValues, ef)
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	rng := rand.New(rand.NewSource(*seed))
+
+	// Generate clustered data so the neighbourhood structure resembles embeddings
+	centers := make([][]float32, *clusters)
+	for i := range centers {
+		centers[i] = randomVector(rng, *dim, 1)
+	}
+	sample := func() []float32 {
+		center := centers[rng.Intn(len(centers))]
+		noise := randomVector(rng, *dim, 0.3)
+		for i := range noise {
+			noise[i] += center[i]
+		}
+		return noise
+	}
+
+	chunks := make([]types.Chunk, *n)
+	embeddings := make([][]float32, *n)
+	for i := range chunks {
+		id := strconv.Itoa(i)
+		chunks[i] = types.Chunk{ID: id, DocumentID: id}
+		embeddings[i] = sample()
+	}
+	queryVectors := make([][]float32, *queries)
+	for i := range queryVectors {
+		queryVectors[i] = sample()
+	}
+
+	exact, err := vectorstore.NewMemoryStore(*metric, "", logger)
+	if err != nil {
+		log.Fatalf("Failed to create memory store: %v", err)
+	}
+	if err := exact.AddChunks(chunks, embeddings); err != nil {
+		log.Fatalf("Failed to index memory store: %v", err)
+	}
+
+	config := vectorstore.HNSWConfig{M: *m, EfConstruction: *efConstruction, EfSearch: efValues[0], Metric: *metric}
+	index, err := vectorstore.NewHNSWStore(config, "", logger)
+	if err != nil {
+		log.Fatalf("Failed to create HNSW store: %v", err)
+	}
+
+	start := time.Now()
+	for i := range chunks {
+		if err := index.AddChunks(chunks[i:i+1], embeddings[i:i+1]); err != nil {
+			log.Fatalf("Failed to index HNSW store: %v", err)
+		}
+	}
+	buildTime := time.Since(start)
+
+	fmt.Printf("n=%d dim=%d clusters=%d queries=%d k=%d M=%d efConstruction=%d metric=%s\n",
+		*n, *dim, *clusters, *queries, *k, *m, *efConstruction, *metric)
+	fmt.Printf("HNSW build: %s (%.0f inserts/s)\n\n", buildTime.Round(time.Millisecond), float64(*n)/buildTime.Seconds())
+
+	// Exact results are the ground truth
+	truth := make([]map[string]bool, len(queryVectors))
+	start = time.Now()
+	for i, q := range queryVectors {
+		results, _ := exact.QueryDocuments("", q, *k, nil)
+		truth[i] = make(map[string]bool, len(results))
+		for _, r := range results {
+			truth[i][r.ID] = true
+		}
+	}
+	exactLatency := time.Since(start) / time.Duration(len(queryVectors))
+
+	fmt.Printf("%-8s %-10s %-12s %s\n", "ef", "recall@k", "latency", "speedup")
+	fmt.Printf("%-8s %-10s %-12s %s\n", "exact", "1.0000", exactLatency.Round(time.Microsecond), "1.0x")
+	for _, ef := range efValues {
+		index.SetEfSearch(ef)
+
+		hits := 0
+		start = time.Now()
+		for i, q := range queryVectors {
+			results, _ := index.QueryDocuments("", q, *k, nil)
+			for _, r := range results {
+				if truth[i][r.ID] {
+					hits++
+				}
+			}
+		}
+		latency := time.Since(start) / time.Duration(len(queryVectors))
+
+		recall := float64(hits) / float64(len(queryVectors)**k)
+		fmt.Printf("%-8d %-10.4f %-12s %.1fx\n", ef, recall, latency.Round(time.Microsecond), float64(exactLatency)/float64(latency))
+	}
+}
+
+func randomVector(rng *rand.Rand, dim int, scale float64) []float32 {
+	v := make([]float32, dim)
+	for i := range v {
+		v[i] = float32(rng.NormFloat64() * scale)
+	}
+	return v
+}
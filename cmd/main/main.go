@@ -1,19 +1,39 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	_ "github.com/robstave/gorag/docs"
 	"github.com/robstave/gorag/internal/adapters/controller"
 	"github.com/robstave/gorag/internal/adapters/repositories"
+	"github.com/robstave/gorag/internal/adapters/repositories/keyword"
+	"github.com/robstave/gorag/internal/adapters/repositories/vectorstore"
 	"github.com/robstave/gorag/internal/domain"
-	"github.com/robstave/gorag/internal/domain/types"
+	"github.com/robstave/gorag/internal/domain/chunking"
+	"github.com/robstave/gorag/internal/domain/embedding"
+	"github.com/robstave/gorag/internal/domain/llm"
+	"github.com/robstave/gorag/internal/domain/prompt"
+	"github.com/robstave/gorag/internal/domain/rerank"
 	"github.com/robstave/gorag/internal/logger"
+	"github.com/robstave/gorag/prompts"
 	httpSwagger "github.com/swaggo/echo-swagger"
+	"gorm.io/driver/postgres"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
@@ -27,31 +47,163 @@ func main() {
 	slogger := logger.InitializeLogger()
 	logger.SetLogger(slogger)
 
-	// Set database path
-	dbPath := "./gorag.db"
-	if path := os.Getenv("DB_PATH"); path != "" {
-		dbPath = path
-	}
-	slogger.Info("DBPath set", "dbpath", dbPath)
-
-	// Open SQLite database
-	db, err := gorm.Open(sqlite.Open(dbPath), &gorm.Config{})
+	// Open the database selected by DB_DRIVER
+	db, err := openDatabase(slogger)
 	if err != nil {
-		slogger.Error("Failed to connect to database", "path", dbPath, "error", err)
+		slogger.Error("Failed to connect to database", "error", err)
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
 
-	// Auto-migrate document model
-	if err = db.AutoMigrate(&types.Document{}); err != nil {
+	// Migrate the schema, upgrading databases created by earlier versions
+	if err = repositories.Migrate(db); err != nil {
 		slogger.Error("Failed to migrate database", "error", err)
 		log.Fatalf("Failed to migrate database: %v", err)
 	}
 
-	// Initialize Repository, Service, and Controller
-	repo := repositories.NewRepositorySQLite(db)
-	service := domain.NewService(slogger, repo)
+	repo := repositories.NewRepository(db)
+
+	// Initialize the embedding provider selected by configuration
+	embedConfig := embedding.Config{
+		Provider: os.Getenv("EMBEDDING_PROVIDER"),
+		Model:    os.Getenv("EMBEDDING_MODEL"),
+		BaseURL:  os.Getenv("EMBEDDING_BASE_URL"),
+		APIKey:   os.Getenv("EMBEDDING_API_KEY"),
+	}
+	if embedConfig.Model == "" {
+		embedConfig.Model = os.Getenv("OPENAI_EMBEDDING_MODEL")
+	}
+	if embedConfig.APIKey == "" {
+		embedConfig.APIKey = os.Getenv("OPENAI_API_KEY")
+	}
+	embedConfig.Dimension = envInt(slogger, "EMBEDDING_DIMENSION", 0)
+	embedConfig.Timeout = envDuration(slogger, "EMBEDDING_TIMEOUT", 30*time.Second)
+	maxRetries := envInt(slogger, "EMBEDDING_MAX_RETRIES", 5)
+	embedConfig.Batch = embedding.BatchConfig{
+		BatchSize:       envInt(slogger, "EMBEDDING_BATCH_SIZE", 64),
+		Concurrency:     envInt(slogger, "EMBEDDING_CONCURRENCY", 4),
+		MaxRetries:      &maxRetries,
+		TokensPerMinute: envInt(slogger, "EMBEDDING_TPM", 0),
+	}
+	embedService, err := embedding.New(embedConfig, slogger)
+	if err != nil {
+		slogger.Error("Failed to initialize embedding provider", "provider", embedConfig.Provider, "error", err)
+		log.Fatalf("Failed to initialize embedding provider: %v", err)
+	}
+	if os.Getenv("EMBEDDING_CACHE") != "false" {
+		embedService = embedding.NewCachedEmbedder(embedService, repo, slogger)
+	}
+	slogger.Info("Embedding provider initialized", "model", embedService.ModelID(), "dimension", embedService.Dimension())
+
+	// Initialize the vector store. Without one documents are only kept in
+	// SQLite and search is unavailable.
+	vectorStore, err := newVectorStore(db, embedService.Dimension(), slogger)
+	if err != nil {
+		slogger.Error("Failed to initialize vector store", "error", err)
+		log.Fatalf("Failed to initialize vector store: %v", err)
+	}
+
+	// Initialize the keyword index used for keyword and hybrid search. It needs
+	// SQLite built with FTS5; without it only vector search is available.
+	var keywordIndex keyword.Index
+	if db.Dialector.Name() == "postgres" {
+		slogger.Warn("Keyword index is not supported with Postgres, keyword and hybrid search requests will be rejected")
+	} else if os.Getenv("KEYWORD_SEARCH") != "false" {
+		fts, err := keyword.NewSQLiteFTS(db, slogger)
+		if err != nil {
+			slogger.Warn("Keyword index unavailable, keyword and hybrid search disabled", "error", err)
+		} else {
+			keywordIndex = fts
+		}
+	}
+
+	// Initialize the optional reranker applied to search results
+	var reranker rerank.Reranker
+	if provider := os.Getenv("RERANK_PROVIDER"); provider != "" {
+		rerankConfig := rerank.Config{
+			Provider: provider,
+			Model:    os.Getenv("RERANK_MODEL"),
+			BaseURL:  os.Getenv("RERANK_BASE_URL"),
+			APIKey:   os.Getenv("RERANK_API_KEY"),
+			Timeout:  envDuration(slogger, "RERANK_TIMEOUT", 30*time.Second),
+		}
+		if rerankConfig.APIKey == "" && provider == rerank.ProviderLLM {
+			rerankConfig.APIKey = os.Getenv("OPENAI_API_KEY")
+		}
+		reranker, err = rerank.New(rerankConfig, slogger)
+		if err != nil {
+			slogger.Error("Failed to initialize reranker", "provider", provider, "error", err)
+			log.Fatalf("Failed to initialize reranker: %v", err)
+		}
+		slogger.Info("Reranker initialized", "model", reranker.ModelID())
+	}
+
+	// Initialize the optional chat model used to answer questions
+	var chatModel llm.LLM
+	if provider := os.Getenv("LLM_PROVIDER"); provider != "" {
+		llmConfig := llm.Config{
+			Provider:    provider,
+			Model:       os.Getenv("LLM_MODEL"),
+			BaseURL:     os.Getenv("LLM_BASE_URL"),
+			APIKey:      os.Getenv("LLM_API_KEY"),
+			MaxTokens:   envInt(slogger, "LLM_MAX_TOKENS", 0),
+			Temperature: envFloat(slogger, "LLM_TEMPERATURE", 0),
+			Timeout:     envDuration(slogger, "LLM_TIMEOUT", 120*time.Second),
+		}
+		if llmConfig.APIKey == "" {
+			llmConfig.APIKey = os.Getenv("OPENAI_API_KEY")
+		}
+		chatModel, err = llm.New(llmConfig, slogger)
+		if err != nil {
+			slogger.Error("Failed to initialize chat model", "provider", provider, "error", err)
+			log.Fatalf("Failed to initialize chat model: %v", err)
+		}
+		slogger.Info("Chat model initialized", "model", chatModel.ModelID())
+	}
+
+	// Initialize the chunker used to split documents before embedding
+	chunkConfig := chunking.DefaultConfig()
+	if v := os.Getenv("CHUNK_STRATEGY"); v != "" {
+		chunkConfig.Strategy = v
+	}
+	chunkConfig.Size = envInt(slogger, "CHUNK_SIZE", chunkConfig.Size)
+	chunkConfig.Overlap = envInt(slogger, "CHUNK_OVERLAP", chunkConfig.Overlap)
+	chunker, err := chunking.NewChunker(chunkConfig)
+	if err != nil {
+		slogger.Error("Invalid chunking configuration", "error", err)
+		log.Fatalf("Invalid chunking configuration: %v", err)
+	}
+
+	// Load the prompt templates, falling back to the embedded defaults
+	promptsDir := "prompts"
+	if v := os.Getenv("PROMPTS_DIR"); v != "" {
+		promptsDir = v
+	}
+	promptRegistry, err := prompt.NewRegistry(promptsDir, prompts.Defaults, slogger)
+	if err != nil {
+		slogger.Error("Invalid prompt templates", "dir", promptsDir, "error", err)
+		log.Fatalf("Invalid prompt templates: %v", err)
+	}
+
+	// Initialize Service and Controller
+	service := domain.NewService(slogger, repo, vectorStore, keywordIndex, embedService, reranker, chatModel, promptRegistry, chunker)
 	ctrl := controller.NewController(service, slogger)
 
+	// Run a one-off command such as "reconcile" instead of the server
+	if len(os.Args) > 1 {
+		code := runCommand(service, os.Args[1:], slogger)
+		closeVectorStore(vectorStore, slogger)
+		os.Exit(code)
+	}
+
+	// Drain the outbox into the vector store in the background
+	outboxInterval := envDuration(slogger, "OUTBOX_INTERVAL", 5*time.Second)
+	service.StartOutboxDispatcher(context.Background(), outboxInterval)
+
+	// Pick up edited prompt templates without a restart
+	if reloadInterval := envDuration(slogger, "PROMPTS_RELOAD_INTERVAL", 2*time.Second); reloadInterval > 0 {
+		promptRegistry.Watch(context.Background(), reloadInterval)
+	}
+
 	// Initialize Echo instance
 	e := echo.New()
 	e.Use(middleware.Logger())
@@ -66,6 +218,21 @@ func main() {
 	documentGroup.GET("/:id", ctrl.Getdocument)
 	documentGroup.PUT("/:id", ctrl.Updatedocument)
 	documentGroup.DELETE("/:id", ctrl.Deletedocument)
+	api.GET("/search", ctrl.Search)
+	api.POST("/ask", ctrl.Ask)
+	api.POST("/ask/stream", ctrl.AskStream)
+
+	conversationGroup := api.Group("/conversations")
+	conversationGroup.POST("", ctrl.CreateConversation)
+	conversationGroup.GET("", ctrl.GetConversations)
+	conversationGroup.GET("/:id", ctrl.GetConversation)
+	conversationGroup.DELETE("/:id", ctrl.DeleteConversation)
+	conversationGroup.POST("/:id/messages", ctrl.SendMessage)
+
+	adminGroup := api.Group("/admin")
+	adminGroup.POST("/reconcile", ctrl.Reconcile)
+	adminGroup.GET("/embedding-cache", ctrl.EmbeddingCacheStats)
+	adminGroup.POST("/embedding-cache/prune", ctrl.PruneEmbeddingCache)
 
 	// Swagger endpoint
 	e.GET("/swagger/*", httpSwagger.WrapHandler)
@@ -76,8 +243,306 @@ func main() {
 		port = p
 	}
 	slogger.Info("Starting server", "port", port)
-	if err := e.Start(":" + port); err != nil && err != http.ErrServerClosed {
-		slogger.Error("Shutting down the server", "error", err)
-		log.Fatalf("Shutting down the server: %v", err)
+	go func() {
+		if err := e.Start(":" + port); err != nil && err != http.ErrServerClosed {
+			slogger.Error("Shutting down the server", "error", err)
+			log.Fatalf("Shutting down the server: %v", err)
+		}
+	}()
+
+	// Stop on SIGINT or SIGTERM, letting requests finish and flushing the
+	// vector store before exiting
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	<-ctx.Done()
+
+	slogger.Info("Shutting down the server")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := e.Shutdown(shutdownCtx); err != nil {
+		slogger.Error("Failed to shut down the server", "error", err)
+	}
+	closeVectorStore(vectorStore, slogger)
+}
+
+// closeVectorStore closes vector stores that buffer writes, such as the
+// memory store's snapshot
+func closeVectorStore(store vectorstore.VectorStore, slogger *slog.Logger) {
+	if closer, ok := store.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			slogger.Error("Failed to close vector store", "error", err)
+		}
+	}
+}
+
+// openDatabase opens the database selected by DB_DRIVER: a SQLite file at
+// DB_PATH, or Postgres at DATABASE_URL
+func openDatabase(slogger *slog.Logger) (*gorm.DB, error) {
+	switch driver := os.Getenv("DB_DRIVER"); driver {
+	case "", "sqlite":
+		dbPath := "./gorag.db"
+		if path := os.Getenv("DB_PATH"); path != "" {
+			dbPath = path
+		}
+		slogger.Info("DBPath set", "dbpath", dbPath)
+		return gorm.Open(sqlite.Open(dbPath), &gorm.Config{})
+	case "postgres":
+		dsn := os.Getenv("DATABASE_URL")
+		if dsn == "" {
+			return nil, errors.New("DATABASE_URL is required for DB_DRIVER=postgres")
+		}
+		slogger.Info("Using Postgres database")
+		return gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	default:
+		return nil, fmt.Errorf("unknown database driver: %s", driver)
+	}
+}
+
+// newVectorStore creates the vector store selected by VECTOR_STORE. The sqlite
+// and pgvector stores keep embeddings in the same database as the documents. When it is
+// unset, Chroma is used if CHROMA_URL is set and no vector store otherwise.
+// dimension is the embedding dimension, or 0 when the embedder does not know
+// it; the redis, qdrant and pgvector stores need it to create their index.
+func newVectorStore(db *gorm.DB, dimension int, slogger *slog.Logger) (vectorstore.VectorStore, error) {
+	kind := os.Getenv("VECTOR_STORE")
+	if kind == "" && os.Getenv("CHROMA_URL") != "" {
+		kind = "chroma"
+	}
+
+	switch kind {
+	case "", "none":
+		slogger.Warn("No vector store configured, search is disabled")
+		return nil, nil
+	case "chroma":
+		config := vectorstore.ChromaConfig{
+			URL:        os.Getenv("CHROMA_URL"),
+			Collection: os.Getenv("CHROMA_COLLECTION"),
+			APIVersion: os.Getenv("CHROMA_API_VERSION"),
+			Tenant:     os.Getenv("CHROMA_TENANT"),
+			Database:   os.Getenv("CHROMA_DATABASE"),
+			AuthToken:  os.Getenv("CHROMA_AUTH_TOKEN"),
+			AuthHeader: os.Getenv("CHROMA_AUTH_HEADER"),
+			Username:   os.Getenv("CHROMA_USERNAME"),
+			Password:   os.Getenv("CHROMA_PASSWORD"),
+			Metric:     os.Getenv("VECTOR_METRIC"),
+		}
+		if config.URL == "" {
+			config.URL = "http://localhost:8000"
+		}
+		if config.Collection == "" {
+			config.Collection = "gorag"
+		}
+		chromaClient, err := vectorstore.NewChromaClient(config, slogger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to Chroma at %s: %w", config.URL, err)
+		}
+		slogger.Info("Chroma vector store connected", "url", config.URL, "collection", config.Collection, "tenant", config.Tenant, "database", config.Database)
+		return chromaClient, nil
+	case "qdrant":
+		config := vectorstore.QdrantConfig{
+			URL:        os.Getenv("QDRANT_URL"),
+			Collection: os.Getenv("QDRANT_COLLECTION"),
+			APIKey:     os.Getenv("QDRANT_API_KEY"),
+			Metric:     os.Getenv("VECTOR_METRIC"),
+			Dimension:  dimension,
+		}
+		if config.URL == "" {
+			config.URL = "http://localhost:6333"
+		}
+		if config.Collection == "" {
+			config.Collection = "gorag"
+		}
+		qdrantClient, err := vectorstore.NewQdrantClient(config, slogger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to Qdrant at %s: %w", config.URL, err)
+		}
+		slogger.Info("Qdrant vector store connected", "url", config.URL, "collection", config.Collection)
+		return qdrantClient, nil
+	case "pgvector":
+		config := vectorstore.PgVectorConfig{
+			Metric:         os.Getenv("VECTOR_METRIC"),
+			Dimension:      dimension,
+			Index:          os.Getenv("PGVECTOR_INDEX"),
+			M:              envInt(slogger, "HNSW_M", 0),
+			EfConstruction: envInt(slogger, "HNSW_EF_CONSTRUCTION", 0),
+			EfSearch:       envInt(slogger, "HNSW_EF_SEARCH", 0),
+			Lists:          envInt(slogger, "PGVECTOR_LISTS", 100),
+			Probes:         envInt(slogger, "PGVECTOR_PROBES", 0),
+		}
+		pgStore, err := vectorstore.NewPgVectorStore(db, config, slogger)
+		if err != nil {
+			return nil, err
+		}
+		slogger.Info("pgvector store initialized", "index", config.Index)
+		return pgStore, nil
+	case "memory":
+		snapshotPath := os.Getenv("VECTOR_SNAPSHOT_PATH")
+		memoryStore, err := vectorstore.NewMemoryStore(os.Getenv("VECTOR_METRIC"), snapshotPath, slogger)
+		if err != nil {
+			return nil, err
+		}
+		slogger.Info("In-memory vector store initialized", "snapshot", snapshotPath)
+		return memoryStore, nil
+	case "hnsw":
+		config := vectorstore.HNSWConfig{
+			M:              envInt(slogger, "HNSW_M", 16),
+			EfConstruction: envInt(slogger, "HNSW_EF_CONSTRUCTION", 200),
+			EfSearch:       envInt(slogger, "HNSW_EF_SEARCH", 64),
+			Metric:         os.Getenv("VECTOR_METRIC"),
+		}
+		snapshotPath := os.Getenv("VECTOR_SNAPSHOT_PATH")
+		hnswStore, err := vectorstore.NewHNSWStore(config, snapshotPath, slogger)
+		if err != nil {
+			return nil, err
+		}
+		slogger.Info("HNSW vector store initialized", "m", config.M, "efConstruction", config.EfConstruction, "efSearch", config.EfSearch, "snapshot", snapshotPath)
+		return hnswStore, nil
+	case "sqlite":
+		sqliteStore, err := vectorstore.NewSQLiteStore(db, os.Getenv("VECTOR_METRIC"), slogger)
+		if err != nil {
+			return nil, err
+		}
+		slogger.Info("SQLite vector store initialized")
+		return sqliteStore, nil
+	case "redis":
+		config := vectorstore.RedisConfig{
+			URL:            os.Getenv("REDIS_URL"),
+			Index:          os.Getenv("REDIS_INDEX"),
+			Prefix:         os.Getenv("REDIS_PREFIX"),
+			Algorithm:      os.Getenv("REDIS_ALGORITHM"),
+			Metric:         os.Getenv("VECTOR_METRIC"),
+			Dimension:      dimension,
+			M:              envInt(slogger, "HNSW_M", 0),
+			EfConstruction: envInt(slogger, "HNSW_EF_CONSTRUCTION", 0),
+			EfRuntime:      envInt(slogger, "HNSW_EF_SEARCH", 0),
+		}
+		if config.URL == "" {
+			config.URL = "redis://localhost:6379"
+		}
+		if config.Index == "" {
+			config.Index = "gorag"
+		}
+		redisStore, err := vectorstore.NewRedisStore(config, slogger)
+		if err != nil {
+			// The URL may carry a password, so it is not logged
+			return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+		}
+		slogger.Info("Redis vector store connected", "index", config.Index)
+		return redisStore, nil
+	default:
+		return nil, fmt.Errorf("unknown vector store: %s", kind)
+	}
+}
+
+// envInt reads an integer environment variable, falling back to def when it is
+// unset or invalid
+func envInt(slogger *slog.Logger, name string, def int) int {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		slogger.Warn("Invalid integer environment variable, using default", "name", name, "value", v, "default", def, "error", err)
+		return def
+	}
+	return n
+}
+
+// envFloat reads a float environment variable, falling back to def when it
+// is unset or invalid
+func envFloat(slogger *slog.Logger, name string, def float64) float64 {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		slogger.Warn("Invalid number environment variable, using default", "name", name, "value", v, "default", def, "error", err)
+		return def
+	}
+	return f
+}
+
+// envDuration reads a duration environment variable such as "5s", falling back
+// to def when it is unset or invalid
+func envDuration(slogger *slog.Logger, name string, def time.Duration) time.Duration {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		slogger.Warn("Invalid duration environment variable, using default", "name", name, "value", v, "default", def, "error", err)
+		return def
+	}
+	return d
+}
+
+// runCommand runs a one-off CLI command instead of the server and returns the
+// process exit code
+func runCommand(service domain.Domain, args []string, slogger *slog.Logger) int {
+	switch args[0] {
+	case "reconcile":
+		return runReconcile(service, args[1:], slogger)
+	case "prune-embeddings":
+		return runPruneEmbeddings(service, args[1:], slogger)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", args[0])
+		fmt.Fprintln(os.Stderr, "usage: gorag [reconcile [--repair] | prune-embeddings [--keep model,...]]")
+		return 2
+	}
+}
+
+// runPruneEmbeddings deletes cached embeddings for models that are no longer used
+func runPruneEmbeddings(service domain.Domain, args []string, slogger *slog.Logger) int {
+	fs := flag.NewFlagSet("prune-embeddings", flag.ContinueOnError)
+	keep := fs.String("keep", "", "comma-separated model IDs to keep (default: the configured model)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	var keepModels []string
+	for _, model := range strings.Split(*keep, ",") {
+		if model = strings.TrimSpace(model); model != "" {
+			keepModels = append(keepModels, model)
+		}
+	}
+
+	removed, err := service.PruneEmbeddingCache(keepModels)
+	if err != nil {
+		slogger.Error("Prune failed", "error", err)
+		return 1
+	}
+
+	fmt.Printf("removed %d cached embeddings\n", removed)
+	return 0
+}
+
+// runReconcile diffs SQLite against the vector store and prints the report as JSON
+func runReconcile(service domain.Domain, args []string, slogger *slog.Logger) int {
+	fs := flag.NewFlagSet("reconcile", flag.ContinueOnError)
+	repair := fs.Bool("repair", false, "re-index missing and stale documents and delete orphaned entries")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	report, err := service.Reconcile(*repair)
+	if err != nil {
+		slogger.Error("Reconcile failed", "error", err)
+		return 1
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		slogger.Error("Failed to write reconcile report", "error", err)
+		return 1
+	}
+
+	drifted := len(report.Missing) > 0 || len(report.Orphaned) > 0 || len(report.Stale) > 0
+	if len(report.Errors) > 0 || (drifted && !*repair) {
+		return 1
 	}
+	return 0
 }
@@ -0,0 +1,82 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Reconcile compares SQLite documents against the vector store
+// @Summary Reconcile documents with the vector store
+// @Description Report documents missing from the vector index, orphaned index entries and stale embeddings. Set repair=true to fix them.
+// @Tags admin
+// @Produce json
+// @Param repair query bool false "Repair missing, orphaned and stale entries (default false)"
+// @Success 200 {object} types.ReconcileReport
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/reconcile [post]
+func (hc *Controller) Reconcile(c echo.Context) error {
+	repair := false
+	if repairStr := c.QueryParam("repair"); repairStr != "" {
+		parsed, err := strconv.ParseBool(repairStr)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, echo.Map{"message": "Invalid repair parameter"})
+		}
+		repair = parsed
+	}
+
+	report, err := hc.service.Reconcile(repair)
+	if err != nil {
+		hc.logger.Error("Failed to reconcile documents", "error", err)
+		return c.JSON(http.StatusInternalServerError, echo.Map{"message": "Failed to reconcile documents"})
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
+
+// EmbeddingCacheStats returns embedding cache statistics
+// @Summary Get embedding cache statistics
+// @Description Hits and misses since startup and the number of cached embeddings per model
+// @Tags admin
+// @Produce json
+// @Success 200 {object} types.EmbeddingCacheStats
+// @Failure 500 {object} map[string]string
+// @Router /admin/embedding-cache [get]
+func (hc *Controller) EmbeddingCacheStats(c echo.Context) error {
+	stats, err := hc.service.EmbeddingCacheStats()
+	if err != nil {
+		hc.logger.Error("Failed to retrieve embedding cache stats", "error", err)
+		return c.JSON(http.StatusInternalServerError, echo.Map{"message": "Failed to retrieve embedding cache stats"})
+	}
+
+	return c.JSON(http.StatusOK, stats)
+}
+
+// PruneEmbeddingCache deletes cached embeddings for unused models
+// @Summary Prune the embedding cache
+// @Description Delete cached embeddings for every model not listed in keep (default: the current model)
+// @Tags admin
+// @Produce json
+// @Param keep query string false "Comma-separated model IDs to keep"
+// @Success 200 {object} map[string]int64
+// @Failure 500 {object} map[string]string
+// @Router /admin/embedding-cache/prune [post]
+func (hc *Controller) PruneEmbeddingCache(c echo.Context) error {
+	var keep []string
+	for _, model := range strings.Split(c.QueryParam("keep"), ",") {
+		if model = strings.TrimSpace(model); model != "" {
+			keep = append(keep, model)
+		}
+	}
+
+	removed, err := hc.service.PruneEmbeddingCache(keep)
+	if err != nil {
+		hc.logger.Error("Failed to prune embedding cache", "error", err)
+		return c.JSON(http.StatusInternalServerError, echo.Map{"message": "Failed to prune embedding cache"})
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{"removed": removed})
+}
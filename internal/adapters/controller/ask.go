@@ -0,0 +1,165 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/robstave/gorag/internal/domain/types"
+)
+
+// Ask answers a question from the indexed documents
+// @Summary Ask a question
+// @Description Retrieve relevant chunks, generate an answer with the configured chat model and map its [n] citation markers to document spans. The filter field takes the same expression as the search filter parameter.
+// @Tags ask
+// @Accept json
+// @Produce json
+// @Param request body types.AskRequest true "Question and retrieval options"
+// @Success 200 {object} types.AskResponse
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /ask [post]
+func (c *Controller) Ask(ctx echo.Context) error {
+	request, err := bindAskRequest(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, echo.Map{"message": err.Error()})
+	}
+
+	c.logger.Info("Answering question", "question", request.Question, "mode", request.Mode, "limit", request.Limit)
+
+	response, err := c.service.Ask(ctx.Request().Context(), request)
+	if errors.Is(err, types.ErrLLMUnavailable) {
+		return ctx.JSON(http.StatusServiceUnavailable, echo.Map{"message": err.Error()})
+	}
+	if errors.Is(err, types.ErrKeywordSearchUnavailable) || errors.Is(err, types.ErrUnknownPromptTemplate) {
+		return ctx.JSON(http.StatusBadRequest, echo.Map{"message": err.Error()})
+	}
+	if err != nil {
+		c.logger.Error("Failed to answer question", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, echo.Map{"message": "Failed to answer question"})
+	}
+
+	return ctx.JSON(http.StatusOK, response)
+}
+
+// AskStream answers a question like Ask but streams the answer as
+// Server-Sent Events
+// @Summary Ask a question with a streamed answer
+// @Description Same request as /ask. The response is a text/event-stream of a sources event, delta events carrying pieces of the answer, a citations event and a done event with the full answer, model and token usage. If generation fails after the stream has started an error event ends it. Closing the connection cancels generation.
+// @Tags ask
+// @Accept json
+// @Produce text/event-stream
+// @Param request body types.AskRequest true "Question and retrieval options"
+// @Success 200 {string} string "event stream"
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /ask/stream [post]
+func (c *Controller) AskStream(ctx echo.Context) error {
+	request, err := bindAskRequest(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, echo.Map{"message": err.Error()})
+	}
+
+	c.logger.Info("Streaming answer", "question", request.Question, "mode", request.Mode, "limit", request.Limit)
+
+	// Headers are only sent with the first event so that errors during
+	// retrieval can still be reported with a status code
+	started := false
+	emit := func(event types.AskEvent) error {
+		if !started {
+			header := ctx.Response().Header()
+			header.Set(echo.HeaderContentType, "text/event-stream")
+			header.Set(echo.HeaderCacheControl, "no-cache")
+			header.Set(echo.HeaderConnection, "keep-alive")
+			header.Set("X-Accel-Buffering", "no")
+			ctx.Response().WriteHeader(http.StatusOK)
+			started = true
+		}
+		return writeEvent(ctx.Response(), event)
+	}
+
+	err = c.service.AskStream(ctx.Request().Context(), request, emit)
+	if err == nil {
+		return nil
+	}
+	if ctx.Request().Context().Err() != nil {
+		c.logger.Info("Client disconnected from answer stream", "question", request.Question)
+		return nil
+	}
+	if started {
+		c.logger.Error("Failed to stream answer", "error", err)
+		return writeEvent(ctx.Response(), types.AskEvent{
+			Type: types.AskEventError,
+			Data: echo.Map{"message": "Failed to answer question"},
+		})
+	}
+	if errors.Is(err, types.ErrLLMUnavailable) {
+		return ctx.JSON(http.StatusServiceUnavailable, echo.Map{"message": err.Error()})
+	}
+	if errors.Is(err, types.ErrKeywordSearchUnavailable) || errors.Is(err, types.ErrUnknownPromptTemplate) {
+		return ctx.JSON(http.StatusBadRequest, echo.Map{"message": err.Error()})
+	}
+	c.logger.Error("Failed to answer question", "error", err)
+	return ctx.JSON(http.StatusInternalServerError, echo.Map{"message": "Failed to answer question"})
+}
+
+// writeEvent writes one Server-Sent Event with a JSON payload and flushes it
+// to the client
+func writeEvent(response *echo.Response, event types.AskEvent) error {
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(response, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+		return err
+	}
+	response.Flush()
+	return nil
+}
+
+// askBody is the JSON body of an ask request. The filter is kept raw so it can
+// be parsed with the same syntax as the search filter parameter.
+type askBody struct {
+	types.AskRequest
+	Filter json.RawMessage `json:"filter"`
+}
+
+// bindAskRequest binds and validates an ask request
+func bindAskRequest(ctx echo.Context) (types.AskRequest, error) {
+	var body askBody
+	if err := ctx.Bind(&body); err != nil {
+		return types.AskRequest{}, errors.New("invalid ask request body")
+	}
+
+	request := body.AskRequest
+	request.Question = strings.TrimSpace(request.Question)
+	if request.Question == "" {
+		return request, errors.New("question is required")
+	}
+	if request.Limit < 0 || request.MaxContextTokens < 0 {
+		return request, errors.New("limit and max_context_tokens must not be negative")
+	}
+	request.Limit = min(request.Limit, maxSearchLimit)
+	if request.MinScore < 0 || request.MinScore > 1 {
+		return request, errors.New("min_score must be between 0 and 1")
+	}
+	switch request.Mode {
+	case "", types.SearchModeVector, types.SearchModeKeyword, types.SearchModeHybrid:
+	default:
+		return request, errors.New("mode must be vector, keyword or hybrid")
+	}
+
+	if len(body.Filter) > 0 && string(body.Filter) != "null" {
+		filter, err := types.ParseFilter(body.Filter)
+		if err != nil {
+			return request, err
+		}
+		request.Filter = filter
+	}
+	return request, nil
+}
@@ -0,0 +1,150 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/robstave/gorag/internal/domain/types"
+)
+
+// conversationBody is the JSON body for creating a conversation
+type conversationBody struct {
+	Title string `json:"title"`
+}
+
+// CreateConversation starts a new conversation
+// @Summary Create a conversation
+// @Description Start a new conversation. The title is optional; without one the first question becomes the title.
+// @Tags conversations
+// @Accept json
+// @Produce json
+// @Param request body conversationBody false "Conversation title"
+// @Success 201 {object} types.Conversation
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /conversations [post]
+func (c *Controller) CreateConversation(ctx echo.Context) error {
+	var body conversationBody
+	if err := ctx.Bind(&body); err != nil {
+		c.logger.Error("Failed to bind conversation data", "error", err)
+		return ctx.JSON(http.StatusBadRequest, echo.Map{"message": "Invalid conversation data"})
+	}
+
+	conversation, err := c.service.CreateConversation(body.Title)
+	if err != nil {
+		c.logger.Error("Failed to create conversation", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, echo.Map{"message": "Failed to create conversation"})
+	}
+
+	return ctx.JSON(http.StatusCreated, conversation)
+}
+
+// GetConversations lists conversations
+// @Summary List conversations
+// @Description List conversations without their messages, most recently active first
+// @Tags conversations
+// @Produce json
+// @Success 200 {array} types.Conversation
+// @Failure 500 {object} map[string]string
+// @Router /conversations [get]
+func (c *Controller) GetConversations(ctx echo.Context) error {
+	conversations, err := c.service.GetConversations()
+	if err != nil {
+		c.logger.Error("Failed to retrieve conversations", "error", err)
+		return ctx.JSON(http.StatusInternalServerError, echo.Map{"message": "Failed to retrieve conversations"})
+	}
+
+	return ctx.JSON(http.StatusOK, conversations)
+}
+
+// GetConversation retrieves a conversation with its messages
+// @Summary Get a conversation
+// @Description Get a conversation and all of its messages in order
+// @Tags conversations
+// @Produce json
+// @Param id path string true "conversation ID"
+// @Success 200 {object} types.Conversation
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /conversations/{id} [get]
+func (c *Controller) GetConversation(ctx echo.Context) error {
+	id := ctx.Param("id")
+
+	conversation, err := c.service.GetConversationByID(id)
+	if errors.Is(err, types.ErrConversationNotFound) {
+		return ctx.JSON(http.StatusNotFound, echo.Map{"message": err.Error()})
+	}
+	if err != nil {
+		c.logger.Error("Failed to retrieve conversation", "id", id, "error", err)
+		return ctx.JSON(http.StatusInternalServerError, echo.Map{"message": "Failed to retrieve conversation"})
+	}
+
+	return ctx.JSON(http.StatusOK, conversation)
+}
+
+// DeleteConversation deletes a conversation and its messages
+// @Summary Delete a conversation
+// @Description Delete a conversation and all of its messages
+// @Tags conversations
+// @Param id path string true "conversation ID"
+// @Success 204 {object} nil
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /conversations/{id} [delete]
+func (c *Controller) DeleteConversation(ctx echo.Context) error {
+	id := ctx.Param("id")
+
+	err := c.service.DeleteConversation(id)
+	if errors.Is(err, types.ErrConversationNotFound) {
+		return ctx.JSON(http.StatusNotFound, echo.Map{"message": err.Error()})
+	}
+	if err != nil {
+		c.logger.Error("Failed to delete conversation", "id", id, "error", err)
+		return ctx.JSON(http.StatusInternalServerError, echo.Map{"message": "Failed to delete conversation"})
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// SendMessage posts a question to a conversation
+// @Summary Post a message to a conversation
+// @Description Answer the question as the next turn of the conversation. The question is first rewritten with the recent history into a standalone search query, which is returned as the answer's query. The body and retrieval options are the same as for /ask.
+// @Tags conversations
+// @Accept json
+// @Produce json
+// @Param id path string true "conversation ID"
+// @Param request body types.AskRequest true "Question and retrieval options"
+// @Success 200 {object} types.ConversationTurn
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /conversations/{id}/messages [post]
+func (c *Controller) SendMessage(ctx echo.Context) error {
+	id := ctx.Param("id")
+
+	request, err := bindAskRequest(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, echo.Map{"message": err.Error()})
+	}
+
+	c.logger.Info("Answering conversation message", "id", id, "question", request.Question, "mode", request.Mode)
+
+	turn, err := c.service.SendMessage(ctx.Request().Context(), id, request)
+	if errors.Is(err, types.ErrConversationNotFound) {
+		return ctx.JSON(http.StatusNotFound, echo.Map{"message": err.Error()})
+	}
+	if errors.Is(err, types.ErrLLMUnavailable) {
+		return ctx.JSON(http.StatusServiceUnavailable, echo.Map{"message": err.Error()})
+	}
+	if errors.Is(err, types.ErrKeywordSearchUnavailable) || errors.Is(err, types.ErrUnknownPromptTemplate) {
+		return ctx.JSON(http.StatusBadRequest, echo.Map{"message": err.Error()})
+	}
+	if err != nil {
+		c.logger.Error("Failed to answer conversation message", "id", id, "error", err)
+		return ctx.JSON(http.StatusInternalServerError, echo.Map{"message": "Failed to answer message"})
+	}
+
+	return ctx.JSON(http.StatusOK, turn)
+}
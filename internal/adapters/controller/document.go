@@ -2,6 +2,7 @@
 package controller
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/labstack/echo/v4"
@@ -27,6 +28,9 @@ func (hc *Controller) Createdocument(c echo.Context) error {
 
 	// Call the service to create the document
 	createddocument, err := hc.service.Createdocument(document)
+	if errors.Is(err, types.ErrInvalidMetadata) {
+		return c.JSON(http.StatusBadRequest, echo.Map{"message": err.Error()})
+	}
 	if err != nil {
 		hc.logger.Error("Failed to create document", "error", err)
 		return c.JSON(http.StatusInternalServerError, echo.Map{"message": "Failed to create document"})
@@ -100,6 +104,9 @@ func (hc *Controller) Updatedocument(c echo.Context) error {
 	document.ID = id
 
 	updateddocument, err := hc.service.Updatedocument(document)
+	if errors.Is(err, types.ErrInvalidMetadata) {
+		return c.JSON(http.StatusBadRequest, echo.Map{"message": err.Error()})
+	}
 	if err != nil {
 		hc.logger.Error("Failed to update document", "id", id, "error", err)
 		return c.JSON(http.StatusNotFound, echo.Map{"message": "document not found or update failed"})
@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -8,14 +9,30 @@ import (
 	"github.com/robstave/gorag/internal/domain/types"
 )
 
+// Upper bounds for the result counts a client can request. Larger values are
+// clamped, since stores size their result buffers from them.
+const (
+	maxSearchLimit      = 100
+	maxRerankCandidates = 1000
+)
+
 // Search handles document search requests
 // @Summary Search for documents
-// @Description Search for documents using semantic similarity
+// @Description Search for documents using semantic similarity, keyword matching or a hybrid of both
 // @Tags search
 // @Accept json
 // @Produce json
 // @Param query query string true "Search query"
-// @Param limit query int false "Maximum number of results to return (default 5)"
+// @Param limit query int false "Maximum number of results to return (default 5, at most 100)"
+// @Param min_score query number false "Minimum vector similarity between 0 and 1; ignored in keyword mode"
+// @Param mode query string false "Search mode: vector (default), keyword or hybrid"
+// @Param fusion query string false "Hybrid fusion method: rrf (default) or weighted"
+// @Param vector_weight query number false "Weight of the vector ranking in hybrid mode, between 0 and 1 (default 0.5)"
+// @Param rerank_candidates query int false "Number of results retrieved for the reranker to choose from (default 50, at most 1000)"
+// @Param rerank query bool false "Set to false to skip reranking"
+// @Param mmr_lambda query number false "Enable MMR diversification, trading relevance (1) against diversity (0)"
+// @Param max_per_document query int false "Maximum number of chunks returned from one document"
+// @Param filter query string false "Metadata filter as JSON, e.g. {\"lang\":\"go\",\"year\":{\"$gte\":2020}}"
 // @Success 200 {object} types.SearchResponse
 // @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
@@ -34,20 +51,109 @@ func (c *Controller) Search(ctx echo.Context) error {
 			return ctx.JSON(http.StatusBadRequest, echo.Map{"message": "Invalid limit parameter"})
 		}
 		if parsedLimit > 0 {
-			limit = parsedLimit
+			limit = min(parsedLimit, maxSearchLimit)
+		}
+	}
+
+	var minScore float64
+	if minScoreStr := ctx.QueryParam("min_score"); minScoreStr != "" {
+		parsed, err := strconv.ParseFloat(minScoreStr, 64)
+		if err != nil || parsed < 0 || parsed > 1 {
+			return ctx.JSON(http.StatusBadRequest, echo.Map{"message": "Invalid min_score parameter, must be between 0 and 1"})
+		}
+		minScore = parsed
+	}
+
+	var filter *types.Filter
+	if filterStr := ctx.QueryParam("filter"); filterStr != "" {
+		parsed, err := types.ParseFilter([]byte(filterStr))
+		if err != nil {
+			return ctx.JSON(http.StatusBadRequest, echo.Map{"message": err.Error()})
+		}
+		filter = parsed
+	}
+
+	mode := ctx.QueryParam("mode")
+	switch mode {
+	case "", types.SearchModeVector, types.SearchModeKeyword, types.SearchModeHybrid:
+	default:
+		return ctx.JSON(http.StatusBadRequest, echo.Map{"message": "Invalid mode parameter, must be vector, keyword or hybrid"})
+	}
+
+	fusion := ctx.QueryParam("fusion")
+	switch fusion {
+	case "", types.FusionRRF, types.FusionWeighted:
+	default:
+		return ctx.JSON(http.StatusBadRequest, echo.Map{"message": "Invalid fusion parameter, must be rrf or weighted"})
+	}
+
+	var vectorWeight *float64
+	if weightStr := ctx.QueryParam("vector_weight"); weightStr != "" {
+		parsed, err := strconv.ParseFloat(weightStr, 64)
+		if err != nil || parsed < 0 || parsed > 1 {
+			return ctx.JSON(http.StatusBadRequest, echo.Map{"message": "Invalid vector_weight parameter, must be between 0 and 1"})
+		}
+		vectorWeight = &parsed
+	}
+
+	var rerankCandidates int
+	if candidatesStr := ctx.QueryParam("rerank_candidates"); candidatesStr != "" {
+		parsed, err := strconv.Atoi(candidatesStr)
+		if err != nil || parsed < 0 {
+			return ctx.JSON(http.StatusBadRequest, echo.Map{"message": "Invalid rerank_candidates parameter"})
+		}
+		rerankCandidates = min(parsed, maxRerankCandidates)
+	}
+
+	skipRerank := false
+	if rerankStr := ctx.QueryParam("rerank"); rerankStr != "" {
+		parsed, err := strconv.ParseBool(rerankStr)
+		if err != nil {
+			return ctx.JSON(http.StatusBadRequest, echo.Map{"message": "Invalid rerank parameter"})
 		}
+		skipRerank = !parsed
+	}
+
+	var mmrLambda *float64
+	if lambdaStr := ctx.QueryParam("mmr_lambda"); lambdaStr != "" {
+		parsed, err := strconv.ParseFloat(lambdaStr, 64)
+		if err != nil || parsed < 0 || parsed > 1 {
+			return ctx.JSON(http.StatusBadRequest, echo.Map{"message": "Invalid mmr_lambda parameter, must be between 0 and 1"})
+		}
+		mmrLambda = &parsed
+	}
+
+	var maxPerDocument int
+	if capStr := ctx.QueryParam("max_per_document"); capStr != "" {
+		parsed, err := strconv.Atoi(capStr)
+		if err != nil || parsed < 0 {
+			return ctx.JSON(http.StatusBadRequest, echo.Map{"message": "Invalid max_per_document parameter"})
+		}
+		maxPerDocument = parsed
 	}
 
 	// Create search query
 	searchQuery := types.SearchQuery{
-		Query: query,
-		Limit: limit,
+		Query:            query,
+		Limit:            limit,
+		Mode:             mode,
+		Fusion:           fusion,
+		VectorWeight:     vectorWeight,
+		MinScore:         minScore,
+		Filter:           filter,
+		RerankCandidates: rerankCandidates,
+		SkipRerank:       skipRerank,
+		MMRLambda:        mmrLambda,
+		MaxPerDocument:   maxPerDocument,
 	}
 
-	c.logger.Info("Searching documents", "query", query, "limit", limit)
+	c.logger.Info("Searching documents", "query", query, "mode", mode, "limit", limit, "minScore", minScore, "filtered", filter != nil)
 
 	// Call the service to search documents
-	results, err := c.service.SearchDocuments(searchQuery)
+	results, err := c.service.SearchDocuments(ctx.Request().Context(), searchQuery)
+	if errors.Is(err, types.ErrKeywordSearchUnavailable) {
+		return ctx.JSON(http.StatusBadRequest, echo.Map{"message": err.Error()})
+	}
 	if err != nil {
 		c.logger.Error("Failed to search documents", "error", err)
 		return ctx.JSON(http.StatusInternalServerError, echo.Map{"message": "Failed to search documents"})
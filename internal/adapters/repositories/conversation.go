@@ -0,0 +1,71 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/robstave/gorag/internal/domain/types"
+	"gorm.io/gorm"
+)
+
+func (r *RepositoryGorm) CreateConversation(conversation types.Conversation) error {
+	return r.db.Create(&conversation).Error
+}
+
+// GetConversation returns the conversation with its messages in the order
+// they were written, or nil if it does not exist
+func (r *RepositoryGorm) GetConversation(id string) (*types.Conversation, error) {
+	var conversation types.Conversation
+	result := r.db.Preload("Messages", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	}).First(&conversation, "id = ?", id)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &conversation, nil
+}
+
+// GetConversations returns every conversation without its messages, most
+// recently active first
+func (r *RepositoryGorm) GetConversations() ([]types.Conversation, error) {
+	var conversations []types.Conversation
+	result := r.db.Order("updated_at DESC").Find(&conversations)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return conversations, nil
+}
+
+// DeleteConversation removes the conversation and its messages in one transaction
+func (r *RepositoryGorm) DeleteConversation(id string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&types.ConversationMessage{}, "conversation_id = ?", id).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&types.Conversation{}, "id = ?", id).Error
+	})
+}
+
+// AddConversationMessages appends messages to the conversation and saves the
+// conversation's title and activity time in one transaction. It returns
+// types.ErrConversationNotFound if the conversation was deleted meanwhile.
+func (r *RepositoryGorm) AddConversationMessages(conversation types.Conversation, messages []types.ConversationMessage) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&types.Conversation{}).Where("id = ?", conversation.ID).Updates(map[string]interface{}{
+			"title":      conversation.Title,
+			"updated_at": time.Now(),
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return types.ErrConversationNotFound
+		}
+		for i := range messages {
+			messages[i].ConversationID = conversation.ID
+		}
+		return tx.Create(&messages).Error
+	})
+}
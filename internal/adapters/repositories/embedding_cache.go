@@ -0,0 +1,103 @@
+package repositories
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/robstave/gorag/internal/domain/types"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// cacheQueryBatch keeps IN clauses below SQLite's bound parameter limit
+const cacheQueryBatch = 500
+
+// GetCachedEmbeddings returns the cached embeddings for the given text hashes.
+// A dimension of 0 matches any dimension.
+func (r *RepositoryGorm) GetCachedEmbeddings(model string, dimension int, hashes []string) (map[string][]float32, error) {
+	found := make(map[string][]float32, len(hashes))
+
+	for start := 0; start < len(hashes); start += cacheQueryBatch {
+		end := start + cacheQueryBatch
+		if end > len(hashes) {
+			end = len(hashes)
+		}
+
+		query := r.db.Where("model = ? AND hash IN ?", model, hashes[start:end])
+		if dimension > 0 {
+			query = query.Where("dimension = ?", dimension)
+		}
+
+		var entries []types.EmbeddingCacheEntry
+		if err := query.Find(&entries).Error; err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			found[entry.Hash] = decodeVector(entry.Vector)
+		}
+	}
+
+	return found, nil
+}
+
+// PutCachedEmbeddings stores embeddings keyed by text hash, keeping existing entries
+func (r *RepositoryGorm) PutCachedEmbeddings(model string, embeddings map[string][]float32) error {
+	if len(embeddings) == 0 {
+		return nil
+	}
+
+	entries := make([]types.EmbeddingCacheEntry, 0, len(embeddings))
+	for hash, vector := range embeddings {
+		entries = append(entries, types.EmbeddingCacheEntry{
+			Model:     model,
+			Dimension: len(vector),
+			Hash:      hash,
+			Vector:    encodeVector(vector),
+		})
+	}
+
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(entries, 100).Error
+}
+
+// GetEmbeddingCacheStats counts cached embeddings per model and dimension
+func (r *RepositoryGorm) GetEmbeddingCacheStats() ([]types.EmbeddingCacheModelStats, error) {
+	var stats []types.EmbeddingCacheModelStats
+	result := r.db.Model(&types.EmbeddingCacheEntry{}).
+		Select("model, dimension, COUNT(*) AS entries").
+		Group("model, dimension").
+		Order("model, dimension").
+		Scan(&stats)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return stats, nil
+}
+
+// PruneEmbeddingCache deletes cached embeddings for every model not in keepModels
+// and returns the number of entries removed
+func (r *RepositoryGorm) PruneEmbeddingCache(keepModels []string) (int64, error) {
+	query := r.db.Session(&gorm.Session{AllowGlobalUpdate: true})
+	if len(keepModels) > 0 {
+		query = query.Where("model NOT IN ?", keepModels)
+	}
+	result := query.Delete(&types.EmbeddingCacheEntry{})
+	return result.RowsAffected, result.Error
+}
+
+// encodeVector packs a vector as little-endian float32s
+func encodeVector(vector []float32) []byte {
+	buf := make([]byte, 4*len(vector))
+	for i, v := range vector {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(v))
+	}
+	return buf
+}
+
+// decodeVector unpacks a vector written by encodeVector
+func decodeVector(buf []byte) []float32 {
+	vector := make([]float32, len(buf)/4)
+	for i := range vector {
+		vector[i] = math.Float32frombits(binary.LittleEndian.Uint32(buf[4*i:]))
+	}
+	return vector
+}
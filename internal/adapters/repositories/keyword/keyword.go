@@ -0,0 +1,57 @@
+// Package keyword provides full-text indexes used for keyword and hybrid search
+package keyword
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/robstave/gorag/internal/domain/types"
+)
+
+// MetricBM25 is reported as the metric of keyword search results
+const MetricBM25 = "bm25"
+
+// Index represents a full-text index over document chunks
+type Index interface {
+	// IndexDocument replaces the indexed chunks of a document
+	IndexDocument(documentID string, chunks []types.Chunk) error
+
+	// DeleteDocument removes every chunk of a document from the index
+	DeleteDocument(documentID string) error
+
+	// Search returns the limit chunks that best match the query terms, best
+	// first. When filter is not nil only chunks whose metadata matches it are
+	// returned.
+	Search(query string, limit int, filter *types.Filter) ([]types.SearchResult, error)
+
+	// Count returns the number of indexed chunks
+	Count() (int64, error)
+}
+
+// matchQuery turns free text into a match expression that is safe to pass to
+// the index. Each whitespace-separated word becomes a phrase of its tokens, so
+// identifiers such as os.ReadFile or ERR-404 match their parts in order, and
+// the phrases are OR-ed together for BM25 to rank. It returns "" when the text
+// has no searchable tokens.
+func matchQuery(text string) string {
+	var phrases []string
+	for _, word := range strings.Fields(text) {
+		tokens := strings.FieldsFunc(word, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+		})
+		if len(tokens) > 0 {
+			phrases = append(phrases, `"`+strings.Join(tokens, " ")+`"`)
+		}
+	}
+	return strings.Join(phrases, " OR ")
+}
+
+// bm25Score maps a BM25 rank, which is negative with lower being better, to a
+// similarity in [0, 1) where higher is better
+func bm25Score(rank float64) float64 {
+	relevance := -rank
+	if relevance <= 0 {
+		return 0
+	}
+	return relevance / (1 + relevance)
+}
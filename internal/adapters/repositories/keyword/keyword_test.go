@@ -0,0 +1,36 @@
+package keyword
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchQuery(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{text: "router password", want: `"router" OR "password"`},
+		{text: "os.ReadFile", want: `"os ReadFile"`},
+		{text: "ERR-404 timeout", want: `"ERR 404" OR "timeout"`},
+		{text: "snake_case", want: `"snake_case"`},
+		{text: `"quoted" NEAR(a b)`, want: `"quoted" OR "NEAR a" OR "b"`},
+		{text: "a AND b", want: `"a" OR "AND" OR "b"`},
+		{text: "* - ()", want: ""},
+		{text: "  ", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			assert.Equal(t, tt.want, matchQuery(tt.text))
+		})
+	}
+}
+
+func TestBM25Score(t *testing.T) {
+	assert.Equal(t, 0.0, bm25Score(0))
+	assert.Equal(t, 0.0, bm25Score(1.5))
+	assert.InDelta(t, 0.5, bm25Score(-1), 1e-9)
+	assert.InDelta(t, 0.75, bm25Score(-3), 1e-9)
+	assert.Greater(t, bm25Score(-4), bm25Score(-3), "a lower rank is a better match")
+}
@@ -0,0 +1,168 @@
+package keyword
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/robstave/gorag/internal/domain/types"
+	"gorm.io/gorm"
+)
+
+// ftsTable is the FTS5 table holding indexed chunks
+const ftsTable = "chunk_fts"
+
+// ftsSchema indexes the document name and chunk text; the other columns are
+// stored so results can be built without touching the vector store. Underscores
+// are token characters so snake_case identifiers stay whole.
+const ftsSchema = `CREATE VIRTUAL TABLE IF NOT EXISTS ` + ftsTable + ` USING fts5(
+	name,
+	text,
+	id UNINDEXED,
+	document_id UNINDEXED,
+	chunk_index UNINDEXED,
+	start_offset UNINDEXED,
+	end_offset UNINDEXED,
+	content_hash UNINDEXED,
+	metadata UNINDEXED,
+	tokenize = "unicode61 tokenchars '_'"
+)`
+
+// ftsRank weights matches in the document name twice as high as matches in the text
+const ftsRank = "bm25(" + ftsTable + ", 2.0, 1.0)"
+
+// SQLiteFTS is an Index backed by an SQLite FTS5 table in the same database as
+// the documents, ranked with BM25. FTS5 must be compiled in, which for the
+// default driver means building with -tags sqlite_fts5.
+type SQLiteFTS struct {
+	db     *gorm.DB
+	logger *slog.Logger
+}
+
+// ftsRow is a chunk read back from the FTS table
+type ftsRow struct {
+	ID          string
+	DocumentID  string
+	ChunkIndex  int
+	StartOffset int
+	EndOffset   int
+	Text        string
+	ContentHash string
+	Metadata    string
+	Rank        float64
+}
+
+// NewSQLiteFTS creates the FTS5 table if needed. It fails when the SQLite
+// build does not include FTS5.
+func NewSQLiteFTS(db *gorm.DB, logger *slog.Logger) (*SQLiteFTS, error) {
+	if name := db.Dialector.Name(); name != "sqlite" {
+		return nil, fmt.Errorf("FTS5 keyword index requires SQLite, got %s", name)
+	}
+	if err := db.Exec(ftsSchema).Error; err != nil {
+		logger.Error("Failed to create FTS5 table", "error", err)
+		return nil, fmt.Errorf("failed to create FTS5 table (is SQLite built with FTS5?): %w", err)
+	}
+	return &SQLiteFTS{db: db, logger: logger}, nil
+}
+
+// IndexDocument replaces the chunks of a document in one transaction
+func (f *SQLiteFTS) IndexDocument(documentID string, chunks []types.Chunk) error {
+	err := f.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM "+ftsTable+" WHERE document_id = ?", documentID).Error; err != nil {
+			return err
+		}
+
+		for _, chunk := range chunks {
+			metadata, err := json.Marshal(chunk.Metadata)
+			if err != nil {
+				return fmt.Errorf("failed to encode metadata for %s: %w", chunk.ID, err)
+			}
+			name, _ := chunk.Metadata["name"].(string)
+
+			err = tx.Exec("INSERT INTO "+ftsTable+" (name, text, id, document_id, chunk_index, start_offset, end_offset, content_hash, metadata) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+				name, chunk.Text, chunk.ID, chunk.DocumentID, chunk.Index, chunk.Start, chunk.End, chunk.ContentHash, string(metadata)).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		f.logger.Error("Failed to update keyword index", "id", documentID, "error", err)
+	}
+	return err
+}
+
+// DeleteDocument removes every chunk of a document
+func (f *SQLiteFTS) DeleteDocument(documentID string) error {
+	return f.db.Exec("DELETE FROM "+ftsTable+" WHERE document_id = ?", documentID).Error
+}
+
+// Search returns the limit chunks with the best BM25 rank. Filters are applied
+// to the stored metadata while reading rows in rank order, so only as many
+// rows are read as needed to fill the limit.
+func (f *SQLiteFTS) Search(query string, limit int, filter *types.Filter) ([]types.SearchResult, error) {
+	match := matchQuery(query)
+	if match == "" {
+		return []types.SearchResult{}, nil
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+
+	statement := "SELECT id, document_id, chunk_index, start_offset, end_offset, text, content_hash, metadata, " +
+		ftsRank + " AS rank FROM " + ftsTable + " WHERE " + ftsTable + " MATCH ? ORDER BY rank"
+	args := []interface{}{match}
+	if filter == nil {
+		statement += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	rows, err := f.db.Raw(statement, args...).Rows()
+	if err != nil {
+		f.logger.Error("Failed to query keyword index", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	// The limit comes from the caller, so the results grow as rows match
+	results := []types.SearchResult{}
+	for rows.Next() && len(results) < limit {
+		var row ftsRow
+		if err := f.db.ScanRows(rows, &row); err != nil {
+			return nil, err
+		}
+
+		if filter != nil {
+			var metadata map[string]interface{}
+			if err := json.Unmarshal([]byte(row.Metadata), &metadata); err != nil || !filter.Match(metadata) {
+				continue
+			}
+		}
+
+		results = append(results, types.SearchResult{
+			ID:       row.ID,
+			Score:    bm25Score(row.Rank),
+			Distance: row.Rank,
+			Metric:   MetricBM25,
+			Document: types.Document{ID: row.DocumentID},
+			Chunk: types.Chunk{
+				ID:          row.ID,
+				DocumentID:  row.DocumentID,
+				Index:       row.ChunkIndex,
+				Start:       row.StartOffset,
+				End:         row.EndOffset,
+				Text:        row.Text,
+				ContentHash: row.ContentHash,
+			},
+		})
+	}
+	return results, rows.Err()
+}
+
+// Count returns the number of indexed chunks
+func (f *SQLiteFTS) Count() (int64, error) {
+	var count int64
+	err := f.db.Raw("SELECT count(*) FROM " + ftsTable).Scan(&count).Error
+	return count, err
+}
@@ -0,0 +1,173 @@
+//go:build sqlite_fts5
+
+package keyword
+
+import (
+	"io"
+	"log/slog"
+	"path/filepath"
+	"testing"
+
+	"github.com/robstave/gorag/internal/domain/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestFTS(t *testing.T) *SQLiteFTS {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "fts.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	fts, err := NewSQLiteFTS(db, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	return fts
+}
+
+// ftsChunk returns a chunk of a document with the given name in its metadata
+func ftsChunk(documentID string, index int, name, text string, metadata map[string]interface{}) types.Chunk {
+	merged := map[string]interface{}{"name": name}
+	for key, value := range metadata {
+		merged[key] = value
+	}
+	return types.Chunk{
+		ID:          documentID + ":" + string(rune('0'+index)),
+		DocumentID:  documentID,
+		Index:       index,
+		Start:       index * 100,
+		End:         index*100 + len(text),
+		Text:        text,
+		ContentHash: "hash-" + documentID,
+		Metadata:    merged,
+	}
+}
+
+func resultIDs(results []types.SearchResult) []string {
+	ids := make([]string, len(results))
+	for i, result := range results {
+		ids[i] = result.ID
+	}
+	return ids
+}
+
+func TestSQLiteFTSSearch(t *testing.T) {
+	fts := newTestFTS(t)
+	require.NoError(t, fts.IndexDocument("router", []types.Chunk{
+		ftsChunk("router", 0, "Router setup", "Hold the reset button to restore the factory password.", nil),
+		ftsChunk("router", 1, "Router setup", "Firmware updates are installed from the admin page.", nil),
+	}))
+	require.NoError(t, fts.IndexDocument("bread", []types.Chunk{
+		ftsChunk("bread", 0, "Banana bread", "Mash the bananas and fold in the walnuts.", nil),
+	}))
+	require.NoError(t, fts.IndexDocument("code", []types.Chunk{
+		ftsChunk("code", 0, "Go notes", "Call os.ReadFile to load config_path into memory.", nil),
+	}))
+
+	count, err := fts.Count()
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), count)
+
+	results, err := fts.Search("reset password", 10, nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"router:0"}, resultIDs(results))
+	result := results[0]
+	assert.Equal(t, MetricBM25, result.Metric)
+	assert.Less(t, result.Distance, 0.0)
+	assert.Equal(t, bm25Score(result.Distance), result.Score)
+	assert.Greater(t, result.Score, 0.0)
+	assert.Less(t, result.Score, 1.0)
+	assert.Equal(t, types.Chunk{
+		ID:          "router:0",
+		DocumentID:  "router",
+		Index:       0,
+		Start:       0,
+		End:         len("Hold the reset button to restore the factory password."),
+		Text:        "Hold the reset button to restore the factory password.",
+		ContentHash: "hash-router",
+	}, result.Chunk)
+
+	// Matches in the document name count, and rank above text-only matches
+	results, err = fts.Search("router firmware", 10, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"router:1", "router:0"}, resultIDs(results))
+
+	// Identifiers and operator characters are matched as phrases, not syntax
+	results, err = fts.Search("os.ReadFile", 10, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"code:0"}, resultIDs(results))
+	results, err = fts.Search("config_path", 10, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"code:0"}, resultIDs(results))
+	results, err = fts.Search(`walnuts AND "NOT" *`, 10, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bread:0"}, resultIDs(results))
+
+	results, err = fts.Search("* ()", 10, nil)
+	require.NoError(t, err)
+	assert.Empty(t, results)
+	assert.NotNil(t, results)
+
+	results, err = fts.Search("the", 2, nil)
+	require.NoError(t, err)
+	assert.Len(t, results, 2)
+}
+
+func TestSQLiteFTSFilter(t *testing.T) {
+	fts := newTestFTS(t)
+	for i, lang := range []string{"go", "rust", "go", "python"} {
+		documentID := "doc" + string(rune('a'+i))
+		require.NoError(t, fts.IndexDocument(documentID, []types.Chunk{
+			ftsChunk(documentID, 0, "Guide", "How to handle errors in this language.", map[string]interface{}{"lang": lang}),
+		}))
+	}
+
+	filter := &types.Filter{Field: "lang", Op: types.FilterEq, Value: "go"}
+	results, err := fts.Search("errors", 10, filter)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"doca:0", "docc:0"}, resultIDs(results))
+
+	// The limit counts matching rows only
+	results, err = fts.Search("errors", 1, filter)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Contains(t, []string{"doca:0", "docc:0"}, results[0].ID)
+}
+
+func TestSQLiteFTSReindexAndDelete(t *testing.T) {
+	fts := newTestFTS(t)
+	require.NoError(t, fts.IndexDocument("doc", []types.Chunk{
+		ftsChunk("doc", 0, "Notes", "The old wording about otters.", nil),
+		ftsChunk("doc", 1, "Notes", "A second chunk about beavers.", nil),
+	}))
+
+	// Re-indexing replaces every chunk of the document
+	require.NoError(t, fts.IndexDocument("doc", []types.Chunk{
+		ftsChunk("doc", 0, "Notes", "The new wording about seals.", nil),
+	}))
+	results, err := fts.Search("otters beavers", 10, nil)
+	require.NoError(t, err)
+	assert.Empty(t, results)
+	results, err = fts.Search("seals", 10, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"doc:0"}, resultIDs(results))
+
+	require.NoError(t, fts.DeleteDocument("doc"))
+	count, err := fts.Count()
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestSQLiteFTSRequiresSQLite(t *testing.T) {
+	db, err := gorm.Open(postgres.Open("host=localhost dbname=gorag"), &gorm.Config{
+		DisableAutomaticPing: true,
+		Logger:               logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+
+	_, err = NewSQLiteFTS(db, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	assert.ErrorContains(t, err, "requires SQLite")
+}
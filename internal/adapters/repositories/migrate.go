@@ -0,0 +1,35 @@
+package repositories
+
+import (
+	"github.com/robstave/gorag/internal/domain/types"
+	"gorm.io/gorm"
+)
+
+// legacyNameIndex is the global unique index on documents.name created before
+// names were scoped per collection
+const legacyNameIndex = "idx_documents_name"
+
+// Migrate brings the database schema up to date. It is safe to run on every
+// startup and upgrades databases created by earlier versions.
+func Migrate(db *gorm.DB) error {
+	if err := db.AutoMigrate(&types.Document{}, &types.OutboxEntry{}, &types.EmbeddingCacheEntry{}, &types.Conversation{}, &types.ConversationMessage{}); err != nil {
+		return err
+	}
+
+	// Existing rows predate collections; place them in the default collection
+	if err := db.Model(&types.Document{}).
+		Where("collection IS NULL OR collection = ''").
+		Update("collection", types.DefaultCollection).Error; err != nil {
+		return err
+	}
+
+	// Names used to be globally unique; uniqueness is now per collection
+	migrator := db.Migrator()
+	if migrator.HasIndex(&types.Document{}, legacyNameIndex) {
+		if err := migrator.DropIndex(&types.Document{}, legacyNameIndex); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -5,6 +5,8 @@ package mocks
 import (
 	mock "github.com/stretchr/testify/mock"
 
+	time "time"
+
 	types "github.com/robstave/gorag/internal/domain/types"
 )
 
@@ -13,6 +15,34 @@ type Repository struct {
 	mock.Mock
 }
 
+// AddConversationMessages provides a mock function with given fields: conversation, messages
+func (_m *Repository) AddConversationMessages(conversation types.Conversation, messages []types.ConversationMessage) error {
+	ret := _m.Called(conversation, messages)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(types.Conversation, []types.ConversationMessage) error); ok {
+		r0 = rf(conversation, messages)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateConversation provides a mock function with given fields: conversation
+func (_m *Repository) CreateConversation(conversation types.Conversation) error {
+	ret := _m.Called(conversation)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(types.Conversation) error); ok {
+		r0 = rf(conversation)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Createdocument provides a mock function with given fields: document
 func (_m *Repository) Createdocument(document types.Document) error {
 	ret := _m.Called(document)
@@ -27,6 +57,34 @@ func (_m *Repository) Createdocument(document types.Document) error {
 	return r0
 }
 
+// DeleteConversation provides a mock function with given fields: id
+func (_m *Repository) DeleteConversation(id string) error {
+	ret := _m.Called(id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteOutboxEntry provides a mock function with given fields: id
+func (_m *Repository) DeleteOutboxEntry(id uint) error {
+	ret := _m.Called(id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uint) error); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Deletedocument provides a mock function with given fields: id
 func (_m *Repository) Deletedocument(id string) error {
 	ret := _m.Called(id)
@@ -64,6 +122,144 @@ func (_m *Repository) GetAlldocuments() ([]types.Document, error) {
 	return r0, r1
 }
 
+// GetCachedEmbeddings provides a mock function with given fields: model, dimension, hashes
+func (_m *Repository) GetCachedEmbeddings(model string, dimension int, hashes []string) (map[string][]float32, error) {
+	ret := _m.Called(model, dimension, hashes)
+
+	var r0 map[string][]float32
+	if rf, ok := ret.Get(0).(func(string, int, []string) map[string][]float32); ok {
+		r0 = rf(model, dimension, hashes)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string][]float32)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, int, []string) error); ok {
+		r1 = rf(model, dimension, hashes)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetConversation provides a mock function with given fields: id
+func (_m *Repository) GetConversation(id string) (*types.Conversation, error) {
+	ret := _m.Called(id)
+
+	var r0 *types.Conversation
+	if rf, ok := ret.Get(0).(func(string) *types.Conversation); ok {
+		r0 = rf(id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.Conversation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetConversations provides a mock function with given fields:
+func (_m *Repository) GetConversations() ([]types.Conversation, error) {
+	ret := _m.Called()
+
+	var r0 []types.Conversation
+	if rf, ok := ret.Get(0).(func() []types.Conversation); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]types.Conversation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetDueOutbox provides a mock function with given fields: now, limit
+func (_m *Repository) GetDueOutbox(now time.Time, limit int) ([]types.OutboxEntry, error) {
+	ret := _m.Called(now, limit)
+
+	var r0 []types.OutboxEntry
+	if rf, ok := ret.Get(0).(func(time.Time, int) []types.OutboxEntry); ok {
+		r0 = rf(now, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]types.OutboxEntry)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(time.Time, int) error); ok {
+		r1 = rf(now, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetEmbeddingCacheStats provides a mock function with given fields:
+func (_m *Repository) GetEmbeddingCacheStats() ([]types.EmbeddingCacheModelStats, error) {
+	ret := _m.Called()
+
+	var r0 []types.EmbeddingCacheModelStats
+	if rf, ok := ret.Get(0).(func() []types.EmbeddingCacheModelStats); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]types.EmbeddingCacheModelStats)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPendingOutbox provides a mock function with given fields: limit
+func (_m *Repository) GetPendingOutbox(limit int) ([]types.OutboxEntry, error) {
+	ret := _m.Called(limit)
+
+	var r0 []types.OutboxEntry
+	if rf, ok := ret.Get(0).(func(int) []types.OutboxEntry); ok {
+		r0 = rf(limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]types.OutboxEntry)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetdocumentById provides a mock function with given fields: id
 func (_m *Repository) GetdocumentById(id string) (*types.Document, error) {
 	ret := _m.Called(id)
@@ -87,6 +283,55 @@ func (_m *Repository) GetdocumentById(id string) (*types.Document, error) {
 	return r0, r1
 }
 
+// PruneEmbeddingCache provides a mock function with given fields: keepModels
+func (_m *Repository) PruneEmbeddingCache(keepModels []string) (int64, error) {
+	ret := _m.Called(keepModels)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func([]string) int64); ok {
+		r0 = rf(keepModels)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func([]string) error); ok {
+		r1 = rf(keepModels)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PutCachedEmbeddings provides a mock function with given fields: model, embeddings
+func (_m *Repository) PutCachedEmbeddings(model string, embeddings map[string][]float32) error {
+	ret := _m.Called(model, embeddings)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, map[string][]float32) error); ok {
+		r0 = rf(model, embeddings)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateOutboxEntry provides a mock function with given fields: entry
+func (_m *Repository) UpdateOutboxEntry(entry types.OutboxEntry) error {
+	ret := _m.Called(entry)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(types.OutboxEntry) error); ok {
+		r0 = rf(entry)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Updatedocument provides a mock function with given fields: document
 func (_m *Repository) Updatedocument(document types.Document) error {
 	ret := _m.Called(document)
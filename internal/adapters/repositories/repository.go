@@ -2,6 +2,8 @@
 package repositories
 
 import (
+	"time"
+
 	"github.com/robstave/gorag/internal/domain/types"
 	"gorm.io/gorm"
 )
@@ -12,17 +14,38 @@ type Repository interface {
 	Createdocument(document types.Document) error
 	Updatedocument(document types.Document) error
 	Deletedocument(id string) error
+
+	GetPendingOutbox(limit int) ([]types.OutboxEntry, error)
+	GetDueOutbox(now time.Time, limit int) ([]types.OutboxEntry, error)
+	UpdateOutboxEntry(entry types.OutboxEntry) error
+	DeleteOutboxEntry(id uint) error
+
+	GetCachedEmbeddings(model string, dimension int, hashes []string) (map[string][]float32, error)
+	PutCachedEmbeddings(model string, embeddings map[string][]float32) error
+	GetEmbeddingCacheStats() ([]types.EmbeddingCacheModelStats, error)
+	PruneEmbeddingCache(keepModels []string) (int64, error)
+
+	CreateConversation(conversation types.Conversation) error
+	GetConversation(id string) (*types.Conversation, error)
+	GetConversations() ([]types.Conversation, error)
+	DeleteConversation(id string) error
+	AddConversationMessages(conversation types.Conversation, messages []types.ConversationMessage) error
 }
 
-type RepositorySQLite struct {
+// RepositoryGorm stores documents, the outbox, the embedding cache and
+// conversations with GORM. Its queries are portable across the SQLite and
+// Postgres dialects, so one implementation serves both DB_DRIVER values.
+type RepositoryGorm struct {
 	db *gorm.DB
 }
 
-func NewRepositorySQLite(db *gorm.DB) Repository {
-	return &RepositorySQLite{db: db}
+// NewRepository creates a repository on db, which may be a SQLite or a
+// Postgres connection
+func NewRepository(db *gorm.DB) Repository {
+	return &RepositoryGorm{db: db}
 }
 
-func (r *RepositorySQLite) GetdocumentById(id string) (*types.Document, error) {
+func (r *RepositoryGorm) GetdocumentById(id string) (*types.Document, error) {
 	var document types.Document
 	result := r.db.First(&document, "id = ?", id)
 	if result.Error != nil {
@@ -34,7 +57,7 @@ func (r *RepositorySQLite) GetdocumentById(id string) (*types.Document, error) {
 	return &document, nil
 }
 
-func (r *RepositorySQLite) GetAlldocuments() ([]types.Document, error) {
+func (r *RepositoryGorm) GetAlldocuments() ([]types.Document, error) {
 	var documents []types.Document
 	result := r.db.Find(&documents)
 	if result.Error != nil {
@@ -43,14 +66,79 @@ func (r *RepositorySQLite) GetAlldocuments() ([]types.Document, error) {
 	return documents, nil
 }
 
-func (r *RepositorySQLite) Createdocument(document types.Document) error {
-	return r.db.Create(&document).Error
+// Createdocument inserts the document and queues an outbox upsert in one transaction
+func (r *RepositoryGorm) Createdocument(document types.Document) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&document).Error; err != nil {
+			return err
+		}
+		return enqueueOutbox(tx, document.ID, types.OutboxUpsert)
+	})
+}
+
+// Updatedocument saves the document and queues an outbox upsert in one transaction
+func (r *RepositoryGorm) Updatedocument(document types.Document) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&document).Error; err != nil {
+			return err
+		}
+		return enqueueOutbox(tx, document.ID, types.OutboxUpsert)
+	})
+}
+
+// Deletedocument removes the document and queues an outbox delete in one transaction
+func (r *RepositoryGorm) Deletedocument(id string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&types.Document{}, "id = ?", id).Error; err != nil {
+			return err
+		}
+		return enqueueOutbox(tx, id, types.OutboxDelete)
+	})
+}
+
+func enqueueOutbox(tx *gorm.DB, documentID string, operation string) error {
+	entry := types.OutboxEntry{
+		DocumentID:    documentID,
+		Operation:     operation,
+		Status:        types.OutboxPending,
+		NextAttemptAt: time.Now(),
+	}
+	return tx.Create(&entry).Error
+}
+
+// GetPendingOutbox returns pending outbox entries in the order they were written.
+// A limit of -1 returns every pending entry.
+func (r *RepositoryGorm) GetPendingOutbox(limit int) ([]types.OutboxEntry, error) {
+	var entries []types.OutboxEntry
+	result := r.db.Where("status = ?", types.OutboxPending).Order("id").Limit(limit).Find(&entries)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return entries, nil
+}
+
+// GetDueOutbox returns pending outbox entries that can be applied at now, in
+// the order they were written. Every entry of a document with an entry still
+// waiting out its backoff is left out, so entries in backoff neither fill the
+// batch nor let later changes to the same document overtake them.
+func (r *RepositoryGorm) GetDueOutbox(now time.Time, limit int) ([]types.OutboxEntry, error) {
+	waiting := r.db.Model(&types.OutboxEntry{}).
+		Select("document_id").
+		Where("status = ? AND next_attempt_at > ?", types.OutboxPending, now)
+
+	var entries []types.OutboxEntry
+	result := r.db.Where("status = ? AND document_id NOT IN (?)", types.OutboxPending, waiting).
+		Order("id").Limit(limit).Find(&entries)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return entries, nil
 }
 
-func (r *RepositorySQLite) Updatedocument(document types.Document) error {
-	return r.db.Save(&document).Error
+func (r *RepositoryGorm) UpdateOutboxEntry(entry types.OutboxEntry) error {
+	return r.db.Save(&entry).Error
 }
 
-func (r *RepositorySQLite) Deletedocument(id string) error {
-	return r.db.Delete(&types.Document{}, "id = ?", id).Error
+func (r *RepositoryGorm) DeleteOutboxEntry(id uint) error {
+	return r.db.Delete(&types.OutboxEntry{}, id).Error
 }
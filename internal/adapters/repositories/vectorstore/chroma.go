@@ -8,34 +8,105 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 
 	"github.com/robstave/gorag/internal/domain/types"
 )
 
+// Chroma API versions
+const (
+	ChromaAPIv1 = "v1"
+	ChromaAPIv2 = "v2"
+)
+
+// Chroma's default tenant and database
+const (
+	ChromaDefaultTenant   = "default_tenant"
+	ChromaDefaultDatabase = "default_database"
+)
+
+// ChromaConfig configures a ChromaClient
+type ChromaConfig struct {
+	// URL is the base URL of the Chroma server
+	URL string
+	// Collection is the collection name
+	Collection string
+	// APIVersion is v1 or v2; when empty it is detected from the heartbeat endpoints
+	APIVersion string
+	// Tenant and Database scope the collection (default default_tenant and default_database)
+	Tenant   string
+	Database string
+	// AuthToken is sent in AuthHeader, as a bearer token when the header is Authorization
+	AuthToken  string
+	AuthHeader string
+	// Username and Password enable basic auth
+	Username string
+	Password string
+	// Metric is the collection's hnsw:space, set when the collection is created (default cosine)
+	Metric string
+}
+
 // ChromaClient implements the VectorStore interface for Chroma DB
 type ChromaClient struct {
 	baseURL      string
+	config       ChromaConfig
 	collectionID string
 	client       *http.Client
 	logger       *slog.Logger
 }
 
 // NewChromaClient creates a new Chroma client
-func NewChromaClient(baseURL string, collectionName string, logger *slog.Logger) (*ChromaClient, error) {
+func NewChromaClient(config ChromaConfig, logger *slog.Logger) (*ChromaClient, error) {
+	if config.Tenant == "" {
+		config.Tenant = ChromaDefaultTenant
+	}
+	if config.Database == "" {
+		config.Database = ChromaDefaultDatabase
+	}
+	if config.AuthHeader == "" {
+		config.AuthHeader = "Authorization"
+	}
+	if config.Metric == "" {
+		config.Metric = MetricCosine
+	}
+	if _, err := distanceFor(config.Metric); err != nil {
+		return nil, err
+	}
+
 	client := &http.Client{
 		Timeout: time.Second * 30,
 	}
 
 	chromaClient := &ChromaClient{
-		baseURL: strings.TrimSuffix(baseURL, "/"),
+		baseURL: strings.TrimSuffix(config.URL, "/"),
+		config:  config,
 		client:  client,
 		logger:  logger,
 	}
 
+	switch config.APIVersion {
+	case "":
+		version, err := chromaClient.detectAPIVersion()
+		if err != nil {
+			return nil, err
+		}
+		chromaClient.config.APIVersion = version
+		logger.Info("Detected Chroma API version", "version", version)
+	case ChromaAPIv1, ChromaAPIv2:
+	default:
+		return nil, fmt.Errorf("unknown Chroma API version: %s", config.APIVersion)
+	}
+
+	if chromaClient.config.APIVersion == ChromaAPIv2 {
+		if err := chromaClient.ensureDatabase(); err != nil {
+			return nil, err
+		}
+	}
+
 	// Ensure the collection exists
-	collID, err := chromaClient.getOrCreateCollection(collectionName)
+	collID, err := chromaClient.getOrCreateCollection(config.Collection)
 	if err != nil {
 		return nil, err
 	}
@@ -44,6 +115,134 @@ func NewChromaClient(baseURL string, collectionName string, logger *slog.Logger)
 	return chromaClient, nil
 }
 
+// detectAPIVersion returns the newest API version whose heartbeat responds
+func (c *ChromaClient) detectAPIVersion() (string, error) {
+	var lastErr error
+	for _, version := range []string{ChromaAPIv2, ChromaAPIv1} {
+		resp, err := c.send(http.MethodGet, fmt.Sprintf("%s/api/%s/heartbeat", c.baseURL, version), nil)
+		if err != nil {
+			c.logger.Error("Failed to reach Chroma", "error", err)
+			return "", err
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode == http.StatusOK {
+			return version, nil
+		}
+		lastErr = fmt.Errorf("heartbeat %s: %s", version, resp.Status)
+	}
+	return "", fmt.Errorf("failed to detect Chroma API version: %w", lastErr)
+}
+
+// ensureDatabase creates the tenant and database on a v2 server if they do
+// not exist yet
+func (c *ChromaClient) ensureDatabase() error {
+	tenantURL := fmt.Sprintf("%s/api/v2/tenants/%s", c.baseURL, url.PathEscape(c.config.Tenant))
+
+	resp, err := c.send(http.MethodGet, tenantURL+"/databases/"+url.PathEscape(c.config.Database), nil)
+	if err != nil {
+		c.logger.Error("Failed to get Chroma database", "error", err)
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	c.logger.Info("Creating Chroma database", "tenant", c.config.Tenant, "database", c.config.Database)
+
+	resp, err = c.send(http.MethodGet, tenantURL, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		if err := c.post(c.baseURL+"/api/v2/tenants", map[string]interface{}{"name": c.config.Tenant}, nil); err != nil {
+			return fmt.Errorf("failed to create tenant: %w", err)
+		}
+	}
+
+	if err := c.post(tenantURL+"/databases", map[string]interface{}{"name": c.config.Database}, nil); err != nil {
+		return fmt.Errorf("failed to create database: %w", err)
+	}
+	return nil
+}
+
+// collectionsURL returns the URL of the collections endpoint for the
+// configured API version, tenant and database
+func (c *ChromaClient) collectionsURL() string {
+	if c.config.APIVersion == ChromaAPIv2 {
+		return fmt.Sprintf("%s/api/v2/tenants/%s/databases/%s/collections",
+			c.baseURL, url.PathEscape(c.config.Tenant), url.PathEscape(c.config.Database))
+	}
+	return fmt.Sprintf("%s/api/v1/collections?%s", c.baseURL, url.Values{
+		"tenant":   {c.config.Tenant},
+		"database": {c.config.Database},
+	}.Encode())
+}
+
+// collectionURL returns the URL of an operation such as "query" on the collection
+func (c *ChromaClient) collectionURL(operation string) string {
+	if c.config.APIVersion == ChromaAPIv2 {
+		return fmt.Sprintf("%s/%s/%s", c.collectionsURL(), c.collectionID, operation)
+	}
+	return fmt.Sprintf("%s/api/v1/collections/%s/%s", c.baseURL, c.collectionID, operation)
+}
+
+// send performs a request with the configured auth headers
+func (c *ChromaClient) send(method string, url string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	if c.config.AuthToken != "" {
+		token := c.config.AuthToken
+		if strings.EqualFold(c.config.AuthHeader, "Authorization") {
+			token = "Bearer " + token
+		}
+		req.Header.Set(c.config.AuthHeader, token)
+	} else if c.config.Username != "" {
+		req.SetBasicAuth(c.config.Username, c.config.Password)
+	}
+
+	return c.client.Do(req)
+}
+
+// post sends reqBody as JSON and decodes the response into out when it is not nil
+func (c *ChromaClient) post(url string, reqBody interface{}, out interface{}) error {
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		c.logger.Error("Failed to marshal Chroma request", "error", err)
+		return err
+	}
+
+	resp, err := c.send(http.MethodPost, url, jsonData)
+	if err != nil {
+		c.logger.Error("Chroma request failed", "error", err)
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(resp.Body)
+		c.logger.Error("Chroma API error", "status", resp.Status, "body", string(body))
+		return fmt.Errorf("chroma API error: %s", resp.Status)
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		c.logger.Error("Failed to decode Chroma response", "error", err)
+		return err
+	}
+	return nil
+}
+
 // getOrCreateCollection gets or creates a collection in Chroma
 func (c *ChromaClient) getOrCreateCollection(name string) (string, error) {
 	// First check if collection exists
@@ -54,6 +253,11 @@ func (c *ChromaClient) getOrCreateCollection(name string) (string, error) {
 
 	for _, col := range collections {
 		if col.Name == name {
+			// Distances are scored with the collection's own space
+			if space := col.space(); space != c.config.Metric {
+				c.logger.Warn("Chroma collection uses a different distance space than configured", "collection", name, "space", space, "configured", c.config.Metric)
+				c.config.Metric = space
+			}
 			return col.ID, nil
 		}
 	}
@@ -63,8 +267,25 @@ func (c *ChromaClient) getOrCreateCollection(name string) (string, error) {
 }
 
 type collection struct {
-	ID   string `json:"id"`
-	Name string `json:"name"`
+	ID            string                 `json:"id"`
+	Name          string                 `json:"name"`
+	Metadata      map[string]interface{} `json:"metadata"`
+	Configuration map[string]interface{} `json:"configuration_json"`
+}
+
+// space returns the collection's distance space. Newer servers report it in
+// the configuration, older ones in the hnsw:space metadata, and collections
+// created without either use Chroma's default of l2.
+func (col collection) space() string {
+	if hnsw, ok := col.Configuration["hnsw"].(map[string]interface{}); ok {
+		if space, ok := hnsw["space"].(string); ok && space != "" {
+			return space
+		}
+	}
+	if space, ok := col.Metadata["hnsw:space"].(string); ok && space != "" {
+		return space
+	}
+	return MetricL2
 }
 
 type listCollectionsResponse struct {
@@ -73,9 +294,7 @@ type listCollectionsResponse struct {
 
 // listCollections lists all collections in Chroma
 func (c *ChromaClient) listCollections() ([]collection, error) {
-	url := fmt.Sprintf("%s/api/v1/collections", c.baseURL)
-
-	resp, err := c.client.Get(url)
+	resp, err := c.send(http.MethodGet, c.collectionsURL(), nil)
 	if err != nil {
 		c.logger.Error("Failed to list collections", "error", err)
 		return nil, err
@@ -88,8 +307,23 @@ func (c *ChromaClient) listCollections() ([]collection, error) {
 		return nil, fmt.Errorf("failed to list collections: %s", resp.Status)
 	}
 
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// Chroma returns a bare array; older proxies wrapped it in an object
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		var collections []collection
+		if err := json.Unmarshal(trimmed, &collections); err != nil {
+			c.logger.Error("Failed to decode collections response", "error", err)
+			return nil, err
+		}
+		return collections, nil
+	}
+
 	var result listCollectionsResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+	if err := json.Unmarshal(body, &result); err != nil {
 		c.logger.Error("Failed to decode collections response", "error", err)
 		return nil, err
 	}
@@ -107,117 +341,73 @@ type createCollectionResponse struct {
 	ID string `json:"id"`
 }
 
-// createCollection creates a new collection in Chroma
+// createCollection creates a new collection in Chroma using the configured
+// distance space
 func (c *ChromaClient) createCollection(name string) (string, error) {
-	url := fmt.Sprintf("%s/api/v1/collections", c.baseURL)
-
 	reqBody := createCollectionRequest{
 		Name:        name,
+		Metadata:    map[string]interface{}{"hnsw:space": c.config.Metric},
 		GetOrCreate: true,
 	}
 
-	jsonData, err := json.Marshal(reqBody)
-	if err != nil {
-		c.logger.Error("Failed to marshal create collection request", "error", err)
-		return "", err
-	}
-
-	resp, err := c.client.Post(url, "application/json", bytes.NewBuffer(jsonData))
-	if err != nil {
-		c.logger.Error("Failed to create collection", "error", err)
-		return "", err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		body, _ := io.ReadAll(resp.Body)
-		c.logger.Error("Chroma API error", "status", resp.Status, "body", string(body))
-		return "", fmt.Errorf("failed to create collection: %s", resp.Status)
-	}
-
 	var result createCollectionResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		c.logger.Error("Failed to decode create collection response", "error", err)
-		return "", err
+	if err := c.post(c.collectionsURL(), reqBody, &result); err != nil {
+		return "", fmt.Errorf("failed to create collection: %w", err)
 	}
 
+	c.logger.Info("Created Chroma collection", "collection", name, "space", c.config.Metric)
 	return result.ID, nil
 }
 
-// AddDocument adds a document to Chroma
-func (c *ChromaClient) AddDocument(doc types.Document, embedding []float32) error {
+// AddChunks upserts chunks into Chroma, replacing any existing entries with the same IDs
+func (c *ChromaClient) AddChunks(chunks []types.Chunk, embeddings [][]float32) error {
 	if c.collectionID == "" {
 		return errors.New("collection ID not set")
 	}
-
-	url := fmt.Sprintf("%s/api/v1/collections/%s/add", c.baseURL, c.collectionID)
-
-	// Add document to Chroma
-	reqBody := map[string]interface{}{
-		"ids":        []string{doc.ID},
-		"embeddings": [][]float32{embedding},
-		"metadatas": []map[string]interface{}{
-			{
-				"name":       doc.Name,
-				"created_at": doc.CreatedAt.Format(time.RFC3339),
-			},
-		},
-		"documents": []string{doc.Value},
+	if len(chunks) != len(embeddings) {
+		return fmt.Errorf("got %d chunks but %d embeddings", len(chunks), len(embeddings))
 	}
-
-	jsonData, err := json.Marshal(reqBody)
-	if err != nil {
-		c.logger.Error("Failed to marshal add document request", "error", err)
-		return err
+	if len(chunks) == 0 {
+		return nil
 	}
 
-	resp, err := c.client.Post(url, "application/json", bytes.NewBuffer(jsonData))
-	if err != nil {
-		c.logger.Error("Failed to add document to Chroma", "error", err)
-		return err
+	ids := make([]string, len(chunks))
+	documents := make([]string, len(chunks))
+	metadatas := make([]map[string]interface{}, len(chunks))
+	for i, chunk := range chunks {
+		ids[i] = chunk.ID
+		documents[i] = chunk.Text
+		metadatas[i] = chromaMetadata(chunk)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		body, _ := io.ReadAll(resp.Body)
-		c.logger.Error("Chroma API error", "status", resp.Status, "body", string(body))
-		return fmt.Errorf("failed to add document: %s", resp.Status)
+	// Upsert chunks into Chroma
+	reqBody := map[string]interface{}{
+		"ids":        ids,
+		"embeddings": embeddings,
+		"metadatas":  metadatas,
+		"documents":  documents,
 	}
 
+	if err := c.post(c.collectionURL("upsert"), reqBody, nil); err != nil {
+		return fmt.Errorf("failed to upsert chunks: %w", err)
+	}
 	return nil
 }
 
-// QueryDocuments queries documents from Chroma
-func (c *ChromaClient) QueryDocuments(query string, embedding []float32, limit int) ([]types.SearchResult, error) {
+// QueryDocuments queries documents from Chroma, restricted to entries whose
+// metadata matches filter
+func (c *ChromaClient) QueryDocuments(query string, embedding []float32, limit int, filter *types.Filter) ([]types.SearchResult, error) {
 	if c.collectionID == "" {
 		return nil, errors.New("collection ID not set")
 	}
 
-	url := fmt.Sprintf("%s/api/v1/collections/%s/query", c.baseURL, c.collectionID)
-
 	reqBody := map[string]interface{}{
 		"query_embeddings": [][]float32{embedding},
 		"n_results":        limit,
 		"include":          []string{"metadatas", "documents", "distances"},
 	}
-
-	jsonData, err := json.Marshal(reqBody)
-	if err != nil {
-		c.logger.Error("Failed to marshal query request", "error", err)
-		return nil, err
-	}
-
-	resp, err := c.client.Post(url, "application/json", bytes.NewBuffer(jsonData))
-	if err != nil {
-		c.logger.Error("Failed to query Chroma", "error", err)
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		c.logger.Error("Chroma API error", "status", resp.Status, "body", string(body))
-		return nil, fmt.Errorf("failed to query documents: %s", resp.Status)
+	if filter != nil {
+		reqBody["where"] = chromaWhere(*filter)
 	}
 
 	// Parse response
@@ -227,9 +417,8 @@ func (c *ChromaClient) QueryDocuments(query string, embedding []float32, limit i
 		Metadatas [][]map[string]interface{} `json:"metadatas"`
 		Distances [][]float64                `json:"distances"`
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&queryResp); err != nil {
-		c.logger.Error("Failed to decode query response", "error", err)
-		return nil, err
+	if err := c.post(c.collectionURL("query"), reqBody, &queryResp); err != nil {
+		return nil, fmt.Errorf("failed to query documents: %w", err)
 	}
 
 	// Ensure we have results
@@ -240,66 +429,213 @@ func (c *ChromaClient) QueryDocuments(query string, embedding []float32, limit i
 	// Map to search results
 	results := make([]types.SearchResult, len(queryResp.IDs[0]))
 	for i, id := range queryResp.IDs[0] {
-		result := types.SearchResult{
-			ID:    id,
-			Score: queryResp.Distances[0][i],
-			Document: types.Document{
-				ID:    id,
-				Value: queryResp.Documents[0][i],
-			},
+		chunk := types.Chunk{
+			ID:         id,
+			DocumentID: id,
+			Text:       queryResp.Documents[0][i],
 		}
 
-		// Add metadata if available
+		// Add chunk metadata if available
 		if i < len(queryResp.Metadatas[0]) {
-			metadata := queryResp.Metadatas[0][i]
-			if name, ok := metadata["name"].(string); ok {
-				result.Document.Name = name
-			}
+			applyChunkMetadata(&chunk, queryResp.Metadatas[0][i])
 		}
 
-		results[i] = result
+		results[i] = scoredResult(chunk, c.config.Metric, queryResp.Distances[0][i])
 	}
 
 	return results, nil
 }
 
-// DeleteDocument deletes a document from Chroma
-func (c *ChromaClient) DeleteDocument(id string) error {
+// chromaTagPrefix prefixes the boolean metadata keys that hold tags, since
+// Chroma metadata values must be scalars
+const chromaTagPrefix = "tag:"
+
+// chromaMetadata returns the metadata stored with a chunk in Chroma, with the
+// tags list replaced by one tag:<name> key per tag
+func chromaMetadata(chunk types.Chunk) map[string]interface{} {
+	metadata := chunkMetadata(chunk)
+	tags, ok := metadata[types.TagsField].([]string)
+	if !ok {
+		return metadata
+	}
+	delete(metadata, types.TagsField)
+	for _, tag := range tags {
+		metadata[chromaTagPrefix+tag] = true
+	}
+	return metadata
+}
+
+// chromaWhere translates a filter into a Chroma where clause
+func chromaWhere(filter types.Filter) map[string]interface{} {
+	switch filter.Op {
+	case types.FilterAnd, types.FilterOr:
+		clauses := make([]interface{}, len(filter.Filters))
+		for i, child := range filter.Filters {
+			clauses[i] = chromaWhere(child)
+		}
+		return map[string]interface{}{"$" + filter.Op: clauses}
+	case types.FilterContains:
+		return map[string]interface{}{
+			chromaTagPrefix + filter.Value.(string): map[string]interface{}{"$eq": true},
+		}
+	case types.FilterIn, types.FilterNin:
+		return map[string]interface{}{
+			filter.Field: map[string]interface{}{"$" + filter.Op: filter.Values},
+		}
+	default:
+		return map[string]interface{}{
+			filter.Field: map[string]interface{}{"$" + filter.Op: filter.Value},
+		}
+	}
+}
+
+// applyChunkMetadata copies chunk fields stored as Chroma metadata onto chunk.
+// Entries written before chunking have no document_id and keep their own ID.
+func applyChunkMetadata(chunk *types.Chunk, metadata map[string]interface{}) {
+	if documentID, ok := metadata["document_id"].(string); ok {
+		chunk.DocumentID = documentID
+	}
+	if index, ok := metadata["chunk_index"].(float64); ok {
+		chunk.Index = int(index)
+	}
+	if start, ok := metadata["start"].(float64); ok {
+		chunk.Start = int(start)
+	}
+	if end, ok := metadata["end"].(float64); ok {
+		chunk.End = int(end)
+	}
+	if hash, ok := metadata["content_hash"].(string); ok {
+		chunk.ContentHash = hash
+	}
+}
+
+// chromaListPageSize is the number of entries fetched per page when listing a collection
+const chromaListPageSize = 500
+
+// ListDocuments returns the ID and content hash of every entry in the collection
+func (c *ChromaClient) ListDocuments() ([]types.IndexedDocument, error) {
 	if c.collectionID == "" {
-		return errors.New("collection ID not set")
+		return nil, errors.New("collection ID not set")
 	}
 
-	url := fmt.Sprintf("%s/api/v1/collections/%s/delete", c.baseURL, c.collectionID)
+	var indexed []types.IndexedDocument
+	for offset := 0; ; offset += chromaListPageSize {
+		reqBody := map[string]interface{}{
+			"limit":   chromaListPageSize,
+			"offset":  offset,
+			"include": []string{"metadatas"},
+		}
+
+		var getResp struct {
+			IDs       []string                 `json:"ids"`
+			Metadatas []map[string]interface{} `json:"metadatas"`
+		}
+		if err := c.post(c.collectionURL("get"), reqBody, &getResp); err != nil {
+			return nil, fmt.Errorf("failed to list documents: %w", err)
+		}
+
+		for i, id := range getResp.IDs {
+			chunk := types.Chunk{ID: id, DocumentID: id}
+			if i < len(getResp.Metadatas) {
+				applyChunkMetadata(&chunk, getResp.Metadatas[i])
+			}
+			indexed = append(indexed, types.IndexedDocument{
+				ID:          id,
+				DocumentID:  chunk.DocumentID,
+				ContentHash: chunk.ContentHash,
+			})
+		}
+
+		if len(getResp.IDs) < chromaListPageSize {
+			break
+		}
+	}
+
+	return indexed, nil
+}
+
+// GetEmbeddings returns the stored embeddings of the given chunks
+func (c *ChromaClient) GetEmbeddings(ids []string) (map[string][]float32, error) {
+	if c.collectionID == "" {
+		return nil, errors.New("collection ID not set")
+	}
+
+	embeddings := make(map[string][]float32, len(ids))
+	if len(ids) == 0 {
+		return embeddings, nil
+	}
 
 	reqBody := map[string]interface{}{
-		"ids": []string{id},
+		"ids":     ids,
+		"include": []string{"embeddings"},
+	}
+	var getResp struct {
+		IDs        []string    `json:"ids"`
+		Embeddings [][]float32 `json:"embeddings"`
+	}
+	if err := c.post(c.collectionURL("get"), reqBody, &getResp); err != nil {
+		return nil, fmt.Errorf("failed to get embeddings: %w", err)
 	}
 
-	jsonData, err := json.Marshal(reqBody)
-	if err != nil {
-		c.logger.Error("Failed to marshal delete request", "error", err)
-		return err
+	for i, id := range getResp.IDs {
+		if i < len(getResp.Embeddings) {
+			embeddings[id] = getResp.Embeddings[i]
+		}
 	}
+	return embeddings, nil
+}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		c.logger.Error("Failed to create delete request", "error", err)
-		return err
+// DeleteDocument deletes every chunk of a document from Chroma, including
+// entries written before chunking that used the document ID directly
+func (c *ChromaClient) DeleteDocument(documentID string) error {
+	if c.collectionID == "" {
+		return errors.New("collection ID not set")
 	}
-	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.client.Do(req)
-	if err != nil {
-		c.logger.Error("Failed to delete from Chroma", "error", err)
+	if err := c.deleteEntries(map[string]interface{}{
+		"where": map[string]interface{}{"document_id": documentID},
+	}); err != nil {
 		return err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		c.logger.Error("Chroma API error", "status", resp.Status, "body", string(body))
-		return fmt.Errorf("failed to delete document: %s", resp.Status)
+	return c.deleteEntries(map[string]interface{}{
+		"ids": []string{documentID},
+	})
+}
+
+// DeleteStaleChunks removes the chunks of a document whose IDs are not in
+// keep. Chroma's where clauses cannot exclude IDs, so the document's entries
+// are fetched first.
+func (c *ChromaClient) DeleteStaleChunks(documentID string, keep []string) error {
+	if c.collectionID == "" {
+		return errors.New("collection ID not set")
 	}
 
+	var getResp struct {
+		IDs []string `json:"ids"`
+	}
+	reqBody := map[string]interface{}{
+		"where":   map[string]interface{}{"document_id": documentID},
+		"include": []string{},
+	}
+	if err := c.post(c.collectionURL("get"), reqBody, &getResp); err != nil {
+		return fmt.Errorf("failed to find document chunks: %w", err)
+	}
+
+	kept := idSet(keep)
+	stale := []string{documentID}
+	for _, id := range getResp.IDs {
+		if !kept[id] {
+			stale = append(stale, id)
+		}
+	}
+	return c.deleteEntries(map[string]interface{}{"ids": stale})
+}
+
+// deleteEntries deletes the entries matching reqBody from Chroma
+func (c *ChromaClient) deleteEntries(reqBody map[string]interface{}) error {
+	if err := c.post(c.collectionURL("delete"), reqBody, nil); err != nil {
+		return fmt.Errorf("failed to delete document: %w", err)
+	}
 	return nil
 }
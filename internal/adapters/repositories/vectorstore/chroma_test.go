@@ -0,0 +1,414 @@
+package vectorstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/robstave/gorag/internal/domain/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// chromaEntry is an entry stored in a fake Chroma collection
+type chromaEntry struct {
+	ID        string
+	Document  string
+	Metadata  map[string]interface{}
+	Embedding []float32
+}
+
+// fakeChroma is an httptest server implementing the parts of the Chroma v1
+// and v2 REST APIs that ChromaClient uses. v1 servers only answer the v1
+// heartbeat; v2 servers answer only the v2 endpoints and scope collections by
+// tenant and database.
+type fakeChroma struct {
+	*httptest.Server
+
+	mu          sync.Mutex
+	version     string
+	tenants     map[string]bool
+	databases   map[string]bool // tenant/database
+	collections []collection
+	scopes      map[string]string // collection ID to tenant/database
+	entries     map[string]chromaEntry
+	requests    []string
+	headers     []http.Header
+}
+
+func newFakeChroma(t *testing.T, version string) *fakeChroma {
+	t.Helper()
+	fake := &fakeChroma{
+		version:   version,
+		tenants:   map[string]bool{ChromaDefaultTenant: true},
+		databases: map[string]bool{ChromaDefaultTenant + "/" + ChromaDefaultDatabase: true},
+		scopes:    make(map[string]string),
+		entries:   make(map[string]chromaEntry),
+	}
+	fake.Server = httptest.NewServer(http.HandlerFunc(fake.handle))
+	t.Cleanup(fake.Close)
+	return fake
+}
+
+func (f *fakeChroma) handle(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.requests = append(f.requests, r.Method+" "+r.URL.RequestURI())
+	f.headers = append(f.headers, r.Header.Clone())
+
+	var body map[string]interface{}
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	prefix := "/api/" + f.version + "/"
+	path, ok := strings.CutPrefix(r.URL.Path, prefix)
+	if !ok {
+		// Chroma 1.x answers the v1 API with 410 Gone
+		http.Error(w, "unsupported API version", http.StatusGone)
+		return
+	}
+	parts := strings.Split(path, "/")
+
+	if path == "heartbeat" {
+		writeJSON(w, map[string]interface{}{"nanosecond heartbeat": 1})
+		return
+	}
+
+	var scope string
+	if f.version == ChromaAPIv2 {
+		// tenants[/{tenant}[/databases[/{database}[/collections...]]]]
+		switch {
+		case path == "tenants" && r.Method == http.MethodPost:
+			f.tenants[body["name"].(string)] = true
+			writeJSON(w, map[string]interface{}{})
+			return
+		case len(parts) == 2 && parts[0] == "tenants":
+			if !f.tenants[parts[1]] {
+				http.NotFound(w, r)
+				return
+			}
+			writeJSON(w, map[string]interface{}{"name": parts[1]})
+			return
+		case len(parts) == 3 && parts[2] == "databases" && r.Method == http.MethodPost:
+			if !f.tenants[parts[1]] {
+				http.NotFound(w, r)
+				return
+			}
+			f.databases[parts[1]+"/"+body["name"].(string)] = true
+			writeJSON(w, map[string]interface{}{})
+			return
+		case len(parts) == 4 && parts[2] == "databases":
+			if !f.databases[parts[1]+"/"+parts[3]] {
+				http.NotFound(w, r)
+				return
+			}
+			writeJSON(w, map[string]interface{}{"name": parts[3]})
+			return
+		case len(parts) >= 5 && parts[0] == "tenants" && parts[2] == "databases" && parts[4] == "collections":
+			scope = parts[1] + "/" + parts[3]
+			if !f.databases[scope] {
+				http.NotFound(w, r)
+				return
+			}
+			parts = parts[4:]
+		default:
+			http.NotFound(w, r)
+			return
+		}
+	} else {
+		scope = r.URL.Query().Get("tenant") + "/" + r.URL.Query().Get("database")
+	}
+
+	switch {
+	case len(parts) == 1 && parts[0] == "collections" && r.Method == http.MethodGet:
+		found := []collection{}
+		for _, col := range f.collections {
+			if f.scopes[col.ID] == scope {
+				found = append(found, col)
+			}
+		}
+		writeJSON(w, found)
+	case len(parts) == 1 && parts[0] == "collections" && r.Method == http.MethodPost:
+		col := collection{
+			ID:       fmt.Sprintf("col%d", len(f.collections)+1),
+			Name:     body["name"].(string),
+			Metadata: body["metadata"].(map[string]interface{}),
+		}
+		f.collections = append(f.collections, col)
+		f.scopes[col.ID] = scope
+		writeJSON(w, col)
+	case len(parts) == 3 && parts[0] == "collections":
+		f.collectionOperation(w, parts[2], body)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// collectionOperation handles upsert, query and get by ID on the single
+// collection in use
+func (f *fakeChroma) collectionOperation(w http.ResponseWriter, operation string, body map[string]interface{}) {
+	switch operation {
+	case "upsert":
+		var request struct {
+			IDs        []string                 `json:"ids"`
+			Embeddings [][]float32              `json:"embeddings"`
+			Metadatas  []map[string]interface{} `json:"metadatas"`
+			Documents  []string                 `json:"documents"`
+		}
+		data, _ := json.Marshal(body)
+		if err := json.Unmarshal(data, &request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		for i, id := range request.IDs {
+			f.entries[id] = chromaEntry{ID: id, Document: request.Documents[i], Metadata: request.Metadatas[i], Embedding: request.Embeddings[i]}
+		}
+		writeJSON(w, true)
+	case "query":
+		var query []float32
+		for _, v := range body["query_embeddings"].([]interface{})[0].([]interface{}) {
+			query = append(query, float32(v.(float64)))
+		}
+		entries := make([]chromaEntry, 0, len(f.entries))
+		for _, entry := range f.entries {
+			entries = append(entries, entry)
+		}
+		sort.Slice(entries, func(i, j int) bool {
+			return cosineDistance(query, entries[i].Embedding) < cosineDistance(query, entries[j].Embedding)
+		})
+		if limit := int(body["n_results"].(float64)); len(entries) > limit {
+			entries = entries[:limit]
+		}
+
+		ids, documents, metadatas, distances := []string{}, []string{}, []map[string]interface{}{}, []float64{}
+		for _, entry := range entries {
+			ids = append(ids, entry.ID)
+			documents = append(documents, entry.Document)
+			metadatas = append(metadatas, entry.Metadata)
+			distances = append(distances, cosineDistance(query, entry.Embedding))
+		}
+		writeJSON(w, map[string]interface{}{
+			"ids":       [][]string{ids},
+			"documents": [][]string{documents},
+			"metadatas": [][]map[string]interface{}{metadatas},
+			"distances": [][]float64{distances},
+		})
+	case "get":
+		ids, embeddings := []string{}, [][]float32{}
+		for _, id := range body["ids"].([]interface{}) {
+			if entry, ok := f.entries[id.(string)]; ok {
+				ids = append(ids, entry.ID)
+				embeddings = append(embeddings, entry.Embedding)
+			}
+		}
+		writeJSON(w, map[string]interface{}{"ids": ids, "embeddings": embeddings})
+	default:
+		http.Error(w, "unsupported operation "+operation, http.StatusNotFound)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(value)
+}
+
+// requestsWithPrefix returns the recorded requests starting with prefix
+func (f *fakeChroma) requestsWithPrefix(prefix string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var found []string
+	for _, request := range f.requests {
+		if strings.HasPrefix(request, prefix) {
+			found = append(found, request)
+		}
+	}
+	return found
+}
+
+func TestChromaDetectsAPIVersion(t *testing.T) {
+	for _, version := range []string{ChromaAPIv2, ChromaAPIv1} {
+		t.Run(version, func(t *testing.T) {
+			fake := newFakeChroma(t, version)
+			client, err := NewChromaClient(ChromaConfig{URL: fake.URL + "/", Collection: "docs"}, testLogger())
+			require.NoError(t, err)
+			assert.Equal(t, version, client.config.APIVersion)
+			assert.Equal(t, "col1", client.collectionID)
+
+			chunks := testChunks(3)
+			vectors := randomVectors(3, 4)
+			require.NoError(t, client.AddChunks(chunks, vectors))
+			results, err := client.QueryDocuments("", vectors[1], 1, nil)
+			require.NoError(t, err)
+			require.Len(t, results, 1)
+			assert.Equal(t, chunks[1].ID, results[0].ID)
+			assert.Equal(t, "doc0", results[0].Chunk.DocumentID)
+			assert.Equal(t, chunks[1].Text, results[0].Chunk.Text)
+			assert.Equal(t, MetricCosine, results[0].Metric)
+
+			if version == ChromaAPIv2 {
+				assert.Len(t, fake.requestsWithPrefix("GET /api/v1/"), 0)
+				assert.Len(t, fake.requestsWithPrefix("POST /api/v2/tenants/default_tenant/databases/default_database/collections/col1/query"), 1)
+			} else {
+				assert.Equal(t, []string{"GET /api/v2/heartbeat", "GET /api/v1/heartbeat"}, fake.requestsWithPrefix("GET /api/v")[:2])
+				assert.Equal(t, []string{"GET /api/v1/collections?database=default_database&tenant=default_tenant"}, fake.requestsWithPrefix("GET /api/v1/collections"))
+				assert.Len(t, fake.requestsWithPrefix("POST /api/v1/collections/col1/query"), 1)
+			}
+		})
+	}
+}
+
+func TestChromaConfiguredAPIVersion(t *testing.T) {
+	fake := newFakeChroma(t, ChromaAPIv1)
+	_, err := NewChromaClient(ChromaConfig{URL: fake.URL, Collection: "docs", APIVersion: ChromaAPIv1}, testLogger())
+	require.NoError(t, err)
+	assert.Empty(t, fake.requestsWithPrefix("GET /api/v2/heartbeat"), "a configured version is not detected")
+
+	_, err = NewChromaClient(ChromaConfig{URL: fake.URL, Collection: "docs", APIVersion: "v3"}, testLogger())
+	assert.Error(t, err)
+}
+
+func TestChromaDetectionFails(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(server.Close)
+
+	_, err := NewChromaClient(ChromaConfig{URL: server.URL, Collection: "docs"}, testLogger())
+	assert.ErrorContains(t, err, "failed to detect Chroma API version")
+}
+
+func TestChromaCreatesTenantAndDatabase(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func(f *fakeChroma)
+		creates []string
+	}{
+		{
+			name:    "both missing",
+			creates: []string{"POST /api/v2/tenants", "POST /api/v2/tenants/acme/databases"},
+		},
+		{
+			name:    "database missing",
+			setup:   func(f *fakeChroma) { f.tenants["acme"] = true },
+			creates: []string{"POST /api/v2/tenants/acme/databases"},
+		},
+		{
+			name: "both exist",
+			setup: func(f *fakeChroma) {
+				f.tenants["acme"] = true
+				f.databases["acme/docs"] = true
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeChroma(t, ChromaAPIv2)
+			if tt.setup != nil {
+				tt.setup(fake)
+			}
+
+			client, err := NewChromaClient(ChromaConfig{URL: fake.URL, Collection: "notes", Tenant: "acme", Database: "docs"}, testLogger())
+			require.NoError(t, err)
+			assert.True(t, fake.databases["acme/docs"])
+			assert.Equal(t, "acme/docs", fake.scopes[client.collectionID])
+
+			var creates []string
+			for _, request := range fake.requestsWithPrefix("POST /api/v2/tenants") {
+				if !strings.HasSuffix(request, "/collections") {
+					creates = append(creates, request)
+				}
+			}
+			assert.Equal(t, tt.creates, creates)
+		})
+	}
+}
+
+func TestChromaReusesCollection(t *testing.T) {
+	fake := newFakeChroma(t, ChromaAPIv2)
+	fake.collections = []collection{{ID: "existing", Name: "docs", Metadata: map[string]interface{}{"hnsw:space": MetricIP}}}
+	fake.scopes["existing"] = ChromaDefaultTenant + "/" + ChromaDefaultDatabase
+
+	client, err := NewChromaClient(ChromaConfig{URL: fake.URL, Collection: "docs"}, testLogger())
+	require.NoError(t, err)
+	assert.Equal(t, "existing", client.collectionID)
+	// The collection's own space is used to score distances
+	assert.Equal(t, MetricIP, client.config.Metric)
+	assert.Empty(t, fake.requestsWithPrefix("POST /api/v2/tenants/default_tenant/databases/default_database/collections"))
+}
+
+func TestChromaGetEmbeddings(t *testing.T) {
+	fake := newFakeChroma(t, ChromaAPIv2)
+	client, err := NewChromaClient(ChromaConfig{URL: fake.URL, Collection: "docs"}, testLogger())
+	require.NoError(t, err)
+
+	chunks := testChunks(10)
+	vectors := randomVectors(len(chunks), 8)
+	require.NoError(t, client.AddChunks(chunks, vectors))
+	assertStoredEmbeddings(t, client, chunks, vectors)
+}
+
+func TestChromaAuthHeaders(t *testing.T) {
+	tests := []struct {
+		name   string
+		config ChromaConfig
+		header string
+		want   string
+		absent string
+	}{
+		{
+			name:   "bearer token",
+			config: ChromaConfig{AuthToken: "secret"},
+			header: "Authorization",
+			want:   "Bearer secret",
+			absent: "X-Chroma-Token",
+		},
+		{
+			name:   "token header",
+			config: ChromaConfig{AuthToken: "secret", AuthHeader: "X-Chroma-Token"},
+			header: "X-Chroma-Token",
+			want:   "secret",
+			absent: "Authorization",
+		},
+		{
+			name:   "basic auth",
+			config: ChromaConfig{Username: "admin", Password: "hunter2"},
+			header: "Authorization",
+			want:   "Basic YWRtaW46aHVudGVyMg==",
+			absent: "X-Chroma-Token",
+		},
+		{
+			name:   "token wins over basic auth",
+			config: ChromaConfig{AuthToken: "secret", AuthHeader: "X-Chroma-Token", Username: "admin", Password: "hunter2"},
+			header: "X-Chroma-Token",
+			want:   "secret",
+			absent: "Authorization",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeChroma(t, ChromaAPIv2)
+			config := tt.config
+			config.URL = fake.URL
+			config.Collection = "docs"
+
+			client, err := NewChromaClient(config, testLogger())
+			require.NoError(t, err)
+			require.NoError(t, client.AddChunks([]types.Chunk{{ID: "a", DocumentID: "a"}}, [][]float32{{1, 0}}))
+
+			require.NotEmpty(t, fake.headers)
+			for _, header := range fake.headers {
+				assert.Equal(t, tt.want, header.Get(tt.header))
+				assert.Empty(t, header.Get(tt.absent))
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package vectorstore
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/robstave/gorag/internal/domain/types"
+)
+
+// Distance metrics, named as in Chroma's hnsw:space setting
+const (
+	MetricCosine = "cosine"
+	MetricL2     = "l2"
+	MetricIP     = "ip"
+)
+
+// distanceFunc returns the distance between two vectors; lower is closer
+type distanceFunc func(a, b []float32) float64
+
+// distanceFor returns the distance function for a metric. Distances match
+// Chroma's definitions: cosine is 1 - cos(a, b), l2 is the squared Euclidean
+// distance and ip is 1 - a·b.
+func distanceFor(metric string) (distanceFunc, error) {
+	switch metric {
+	case "", MetricCosine:
+		return cosineDistance, nil
+	case MetricL2:
+		return l2Distance, nil
+	case MetricIP:
+		return ipDistance, nil
+	default:
+		return nil, fmt.Errorf("unknown distance metric: %s", metric)
+	}
+}
+
+func cosineDistance(a, b []float32) float64 {
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 1
+	}
+	return 1 - dot/(math.Sqrt(normA)*math.Sqrt(normB))
+}
+
+func l2Distance(a, b []float32) float64 {
+	var sum float64
+	for i := range a {
+		d := float64(a[i]) - float64(b[i])
+		sum += d * d
+	}
+	return sum
+}
+
+func ipDistance(a, b []float32) float64 {
+	var dot float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+	}
+	return 1 - dot
+}
+
+// similarity maps a distance under metric to a similarity in [0, 1] where
+// higher is closer, so scores are comparable across stores. Cosine and inner
+// product distances span [0, 2] for normalized vectors and are rescaled
+// linearly; the unbounded squared L2 distance uses 1 / (1 + d).
+func similarity(metric string, distance float64) float64 {
+	var s float64
+	switch metric {
+	case MetricL2:
+		s = 1 / (1 + math.Max(distance, 0))
+	default:
+		s = 1 - distance/2
+	}
+	return math.Min(math.Max(s, 0), 1)
+}
+
+// scoredResult returns a search result for chunk at the given distance, with
+// its similarity score and metric filled in
+func scoredResult(chunk types.Chunk, metric string, distance float64) types.SearchResult {
+	if metric == "" {
+		metric = MetricCosine
+	}
+	return types.SearchResult{
+		ID:       chunk.ID,
+		Score:    similarity(metric, distance),
+		Distance: distance,
+		Metric:   metric,
+		Document: types.Document{ID: chunk.DocumentID},
+		Chunk:    chunk,
+	}
+}
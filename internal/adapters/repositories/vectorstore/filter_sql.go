@@ -0,0 +1,162 @@
+package vectorstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/robstave/gorag/internal/domain/types"
+)
+
+// SQL dialects a filter can be translated to
+const (
+	sqlDialectSQLite   = "sqlite"
+	sqlDialectPostgres = "postgres"
+)
+
+// sqlFilter translates a filter into a WHERE clause over a JSON metadata
+// column, using json_extract on SQLite and jsonb operators on Postgres. Field
+// names are validated and inlined; values are bound parameters.
+func sqlFilter(filter types.Filter, dialect string) (string, []interface{}, error) {
+	switch filter.Op {
+	case types.FilterAnd, types.FilterOr:
+		var clauses []string
+		var args []interface{}
+		for _, child := range filter.Filters {
+			clause, childArgs, err := sqlFilter(child, dialect)
+			if err != nil {
+				return "", nil, err
+			}
+			clauses = append(clauses, "("+clause+")")
+			args = append(args, childArgs...)
+		}
+		return strings.Join(clauses, " "+strings.ToUpper(filter.Op)+" "), args, nil
+	}
+
+	if !types.ValidMetadataKey(filter.Field) {
+		return "", nil, fmt.Errorf("invalid metadata key: %q", filter.Field)
+	}
+	if dialect == sqlDialectPostgres {
+		return postgresCondition(filter)
+	}
+	return sqliteCondition(filter)
+}
+
+// sqliteCondition translates a single comparison for SQLite. json_type checks
+// keep values of different JSON types from comparing equal, such as true and 1.
+func sqliteCondition(filter types.Filter) (string, []interface{}, error) {
+	path := "'$." + filter.Field + "'"
+	present := "json_type(metadata, " + path + ") IS NOT NULL"
+
+	equal := func(value interface{}) (string, []interface{}) {
+		switch v := value.(type) {
+		case bool:
+			return "json_type(metadata, " + path + ") = '" + fmt.Sprint(v) + "'", nil
+		case float64:
+			return "json_type(metadata, " + path + ") IN ('integer', 'real') AND json_extract(metadata, " + path + ") = ?", []interface{}{v}
+		default:
+			return "json_type(metadata, " + path + ") = 'text' AND json_extract(metadata, " + path + ") = ?", []interface{}{v}
+		}
+	}
+	anyEqual := func(values []interface{}) (string, []interface{}) {
+		var clauses []string
+		var args []interface{}
+		for _, value := range values {
+			clause, valueArgs := equal(value)
+			clauses = append(clauses, "("+clause+")")
+			args = append(args, valueArgs...)
+		}
+		return strings.Join(clauses, " OR "), args
+	}
+
+	switch filter.Op {
+	case types.FilterEq:
+		clause, args := equal(filter.Value)
+		return clause, args, nil
+	case types.FilterNe:
+		clause, args := equal(filter.Value)
+		return present + " AND NOT (" + clause + ")", args, nil
+	case types.FilterIn:
+		clause, args := anyEqual(filter.Values)
+		return clause, args, nil
+	case types.FilterNin:
+		clause, args := anyEqual(filter.Values)
+		return present + " AND NOT (" + clause + ")", args, nil
+	case types.FilterGt, types.FilterGte, types.FilterLt, types.FilterLte:
+		return "json_type(metadata, " + path + ") IN ('integer', 'real') AND json_extract(metadata, " + path + ") " + sqlComparison(filter.Op) + " ?",
+			[]interface{}{filter.Value}, nil
+	case types.FilterContains:
+		return "EXISTS (SELECT 1 FROM json_each(metadata, " + path + ") WHERE json_each.value = ?)", []interface{}{filter.Value}, nil
+	default:
+		return "", nil, fmt.Errorf("unsupported filter operator: %s", filter.Op)
+	}
+}
+
+// postgresCondition translates a single comparison for Postgres. Values are
+// compared as jsonb, so types must match as well as values.
+func postgresCondition(filter types.Filter) (string, []interface{}, error) {
+	field := "metadata->'" + filter.Field + "'"
+
+	jsonValues := func(values []interface{}) (string, []interface{}, error) {
+		placeholders := make([]string, len(values))
+		args := make([]interface{}, len(values))
+		for i, value := range values {
+			encoded, err := json.Marshal(value)
+			if err != nil {
+				return "", nil, err
+			}
+			placeholders[i] = "?::jsonb"
+			args[i] = string(encoded)
+		}
+		return strings.Join(placeholders, ", "), args, nil
+	}
+
+	switch filter.Op {
+	case types.FilterEq, types.FilterNe:
+		placeholders, args, err := jsonValues([]interface{}{filter.Value})
+		if err != nil {
+			return "", nil, err
+		}
+		op := "="
+		if filter.Op == types.FilterNe {
+			op = "<>"
+		}
+		return field + " " + op + " " + placeholders, args, nil
+	case types.FilterIn, types.FilterNin:
+		placeholders, args, err := jsonValues(filter.Values)
+		if err != nil {
+			return "", nil, err
+		}
+		op := "IN"
+		if filter.Op == types.FilterNin {
+			op = "NOT IN"
+		}
+		return field + " " + op + " (" + placeholders + ")", args, nil
+	case types.FilterGt, types.FilterGte, types.FilterLt, types.FilterLte:
+		// CASE keeps the cast from failing on entries where the field is not a number
+		return "CASE WHEN jsonb_typeof(" + field + ") = 'number' THEN (metadata->>'" + filter.Field + "')::float8 END " + sqlComparison(filter.Op) + " ?",
+			[]interface{}{filter.Value}, nil
+	case types.FilterContains:
+		encoded, err := json.Marshal([]interface{}{filter.Value})
+		if err != nil {
+			return "", nil, err
+		}
+		return field + " @> ?::jsonb", []interface{}{string(encoded)}, nil
+	default:
+		return "", nil, fmt.Errorf("unsupported filter operator: %s", filter.Op)
+	}
+}
+
+// sqlComparison returns the SQL operator for a range filter
+func sqlComparison(op string) string {
+	switch op {
+	case types.FilterGt:
+		return ">"
+	case types.FilterGte:
+		return ">="
+	case types.FilterLt:
+		return "<"
+	default:
+		return "<="
+	}
+}
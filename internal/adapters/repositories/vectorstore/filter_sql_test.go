@@ -0,0 +1,141 @@
+package vectorstore
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/robstave/gorag/internal/domain/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// filterEntries are the metadata of the entries the SQLite filter tests run
+// against, keyed by ID
+var filterEntries = map[string]string{
+	"go":     `{"lang": "go", "year": 2021, "draft": false, "tags": ["cli", "tools"]}`,
+	"rust":   `{"lang": "rust", "year": 2023.5, "draft": true, "tags": ["cli"]}`,
+	"python": `{"lang": "python", "year": "2020", "draft": 1}`,
+	"bare":   `{}`,
+}
+
+// filterCases exercise every operator, mixed JSON types and missing fields
+var filterCases = []string{
+	`{"lang": "go"}`,
+	`{"year": 2021}`,
+	`{"year": "2020"}`,
+	`{"draft": true}`,
+	`{"draft": 1}`,
+	`{"lang": {"$ne": "go"}}`,
+	`{"lang": {"$in": ["go", "python"]}}`,
+	`{"lang": {"$nin": ["go"]}}`,
+	`{"year": {"$gt": 2021}}`,
+	`{"year": {"$gte": 2021, "$lt": 2023}}`,
+	`{"year": {"$lte": 2023.5}}`,
+	`{"tags": {"$contains": "cli"}}`,
+	`{"tags": {"$contains": "tools"}}`,
+	`{"$or": [{"lang": "python"}, {"tags": {"$contains": "tools"}}]}`,
+	`{"$and": [{"tags": {"$contains": "cli"}}, {"draft": false}]}`,
+}
+
+func TestSQLFilterSQLiteMatchesFilter(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "filter.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.Exec("CREATE TABLE entries (id TEXT PRIMARY KEY, metadata TEXT NOT NULL)").Error)
+	for id, metadata := range filterEntries {
+		require.NoError(t, db.Exec("INSERT INTO entries (id, metadata) VALUES (?, ?)", id, metadata).Error)
+	}
+
+	for _, tc := range filterCases {
+		t.Run(tc, func(t *testing.T) {
+			filter, err := types.ParseFilter([]byte(tc))
+			require.NoError(t, err)
+
+			// The in-process evaluation is the reference
+			var want []string
+			for id, raw := range filterEntries {
+				var metadata map[string]interface{}
+				require.NoError(t, json.Unmarshal([]byte(raw), &metadata))
+				if filter.Match(metadata) {
+					want = append(want, id)
+				}
+			}
+			sort.Strings(want)
+
+			where, args, err := sqlFilter(*filter, sqlDialectSQLite)
+			require.NoError(t, err)
+			var got []string
+			require.NoError(t, db.Raw("SELECT id FROM entries WHERE "+where+" ORDER BY id", args...).Scan(&got).Error)
+			assert.Equal(t, want, got, where)
+		})
+	}
+}
+
+func TestSQLFilterPostgres(t *testing.T) {
+	tests := []struct {
+		filter string
+		where  string
+		args   []interface{}
+	}{
+		{
+			filter: `{"lang": "go"}`,
+			where:  `metadata->'lang' = ?::jsonb`,
+			args:   []interface{}{`"go"`},
+		},
+		{
+			filter: `{"draft": {"$ne": true}}`,
+			where:  `metadata->'draft' <> ?::jsonb`,
+			args:   []interface{}{`true`},
+		},
+		{
+			filter: `{"lang": {"$in": ["go", "rust"]}}`,
+			where:  `metadata->'lang' IN (?::jsonb, ?::jsonb)`,
+			args:   []interface{}{`"go"`, `"rust"`},
+		},
+		{
+			filter: `{"year": {"$nin": [2020]}}`,
+			where:  `metadata->'year' NOT IN (?::jsonb)`,
+			args:   []interface{}{`2020`},
+		},
+		{
+			filter: `{"year": {"$gte": 2020}}`,
+			where:  `CASE WHEN jsonb_typeof(metadata->'year') = 'number' THEN (metadata->>'year')::float8 END >= ?`,
+			args:   []interface{}{float64(2020)},
+		},
+		{
+			filter: `{"tags": {"$contains": "cli"}}`,
+			where:  `metadata->'tags' @> ?::jsonb`,
+			args:   []interface{}{`["cli"]`},
+		},
+		{
+			filter: `{"$or": [{"lang": "go"}, {"year": {"$lt": 2000}}]}`,
+			where:  `(metadata->'lang' = ?::jsonb) OR (CASE WHEN jsonb_typeof(metadata->'year') = 'number' THEN (metadata->>'year')::float8 END < ?)`,
+			args:   []interface{}{`"go"`, float64(2000)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			filter, err := types.ParseFilter([]byte(tt.filter))
+			require.NoError(t, err)
+			where, args, err := sqlFilter(*filter, sqlDialectPostgres)
+			require.NoError(t, err)
+			assert.Equal(t, tt.where, where)
+			assert.Equal(t, tt.args, args)
+		})
+	}
+}
+
+func TestSQLFilterRejectsInvalidFields(t *testing.T) {
+	for _, dialect := range []string{sqlDialectSQLite, sqlDialectPostgres} {
+		// Filters built by hand rather than parsed must not inject SQL
+		_, _, err := sqlFilter(types.Filter{Op: types.FilterEq, Field: "lang') OR 1=1 --", Value: "go"}, dialect)
+		assert.Error(t, err, dialect)
+	}
+}
@@ -0,0 +1,397 @@
+package vectorstore
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// HNSWConfig tunes the HNSW graph
+type HNSWConfig struct {
+	// M is the number of neighbours kept per node on the upper layers; layer 0
+	// keeps 2*M. Higher values improve recall at the cost of memory.
+	M int
+	// EfConstruction is the candidate list size used while inserting
+	EfConstruction int
+	// EfSearch is the candidate list size used while searching; it is raised
+	// to k when smaller
+	EfSearch int
+	// Metric is the distance metric: cosine, l2 or ip
+	Metric string
+}
+
+// DefaultHNSWConfig returns commonly used HNSW parameters
+func DefaultHNSWConfig() HNSWConfig {
+	return HNSWConfig{
+		M:              16,
+		EfConstruction: 200,
+		EfSearch:       64,
+		Metric:         MetricCosine,
+	}
+}
+
+func (c HNSWConfig) withDefaults() HNSWConfig {
+	defaults := DefaultHNSWConfig()
+	if c.M < 2 {
+		c.M = defaults.M
+	}
+	if c.EfConstruction <= 0 {
+		c.EfConstruction = defaults.EfConstruction
+	}
+	if c.EfSearch <= 0 {
+		c.EfSearch = defaults.EfSearch
+	}
+	if c.Metric == "" {
+		c.Metric = defaults.Metric
+	}
+	return c
+}
+
+// hnswNode is a vector in the graph. Fields are exported for gob.
+type hnswNode struct {
+	ID        string
+	Vector    []float32
+	Neighbors [][]int // per layer, 0 is the bottom layer
+	Deleted   bool
+}
+
+// hnswGraph is a Hierarchical Navigable Small World graph (Malkov & Yashunin).
+// Deletes are tombstones: deleted nodes still route searches but are never
+// returned. It is not safe for concurrent use.
+type hnswGraph struct {
+	config     HNSWConfig
+	distance   distanceFunc
+	levelMult  float64
+	rng        *rand.Rand
+	nodes      []hnswNode
+	byID       map[string]int
+	entryPoint int
+	maxLevel   int
+	deleted    int
+}
+
+func newHNSWGraph(config HNSWConfig) (*hnswGraph, error) {
+	config = config.withDefaults()
+	distance, err := distanceFor(config.Metric)
+	if err != nil {
+		return nil, err
+	}
+
+	return &hnswGraph{
+		config:     config,
+		distance:   distance,
+		levelMult:  1 / math.Log(float64(config.M)),
+		rng:        rand.New(rand.NewSource(42)),
+		byID:       make(map[string]int),
+		entryPoint: -1,
+		maxLevel:   -1,
+	}, nil
+}
+
+// Len returns the number of live nodes
+func (g *hnswGraph) Len() int {
+	return len(g.nodes) - g.deleted
+}
+
+// maxNeighbors returns the connection limit for a layer
+func (g *hnswGraph) maxNeighbors(layer int) int {
+	if layer == 0 {
+		return 2 * g.config.M
+	}
+	return g.config.M
+}
+
+func (g *hnswGraph) randomLevel() int {
+	return int(math.Floor(-math.Log(1-g.rng.Float64()) * g.levelMult))
+}
+
+// Dimension returns the length of the vectors in the graph, or 0 while it is empty
+func (g *hnswGraph) Dimension() int {
+	if g.entryPoint < 0 {
+		return 0
+	}
+	return len(g.nodes[g.entryPoint].Vector)
+}
+
+// checkDimension reports an error if a vector cannot be compared with the
+// vectors already in the graph
+func (g *hnswGraph) checkDimension(vector []float32) error {
+	if dim := g.Dimension(); dim > 0 && len(vector) != dim {
+		return fmt.Errorf("%w: got %d, index has %d", ErrDimensionMismatch, len(vector), dim)
+	}
+	return nil
+}
+
+// Insert adds a vector. An existing node with the same ID is replaced.
+func (g *hnswGraph) Insert(id string, vector []float32) error {
+	if len(vector) == 0 {
+		return errors.New("cannot insert an empty vector")
+	}
+	if err := g.checkDimension(vector); err != nil {
+		return err
+	}
+	g.Delete(id)
+
+	level := g.randomLevel()
+	idx := len(g.nodes)
+	g.nodes = append(g.nodes, hnswNode{
+		ID:        id,
+		Vector:    vector,
+		Neighbors: make([][]int, level+1),
+	})
+	g.byID[id] = idx
+
+	if g.entryPoint < 0 {
+		g.entryPoint = idx
+		g.maxLevel = level
+		return nil
+	}
+
+	// Greedy descent through the layers above the new node's top layer
+	ep := g.entryPoint
+	epDist := g.distance(vector, g.nodes[ep].Vector)
+	for layer := g.maxLevel; layer > level; layer-- {
+		ep, epDist = g.greedyClosest(vector, ep, epDist, layer)
+	}
+
+	for layer := min(level, g.maxLevel); layer >= 0; layer-- {
+		candidates := g.searchLayer(vector, []hnswCandidate{{idx: ep, dist: epDist}}, g.config.EfConstruction, layer)
+		neighbors := g.selectNeighbors(candidates, g.config.M)
+
+		g.nodes[idx].Neighbors[layer] = candidateIndexes(neighbors)
+		for _, n := range neighbors {
+			g.connect(n.idx, idx, layer)
+		}
+
+		// The closest candidate is the entry point for the next layer down
+		ep, epDist = candidates[0].idx, candidates[0].dist
+	}
+
+	if level > g.maxLevel {
+		g.maxLevel = level
+		g.entryPoint = idx
+	}
+	return nil
+}
+
+// connect adds a link from node to neighbor on a layer, pruning the node's
+// links with the neighbour selection heuristic when it has too many
+func (g *hnswGraph) connect(node, neighbor, layer int) {
+	links := append(g.nodes[node].Neighbors[layer], neighbor)
+	if len(links) <= g.maxNeighbors(layer) {
+		g.nodes[node].Neighbors[layer] = links
+		return
+	}
+
+	candidates := make([]hnswCandidate, len(links))
+	for i, link := range links {
+		candidates[i] = hnswCandidate{idx: link, dist: g.distance(g.nodes[node].Vector, g.nodes[link].Vector)}
+	}
+	sortCandidates(candidates)
+	g.nodes[node].Neighbors[layer] = candidateIndexes(g.selectNeighbors(candidates, g.maxNeighbors(layer)))
+}
+
+// selectNeighbors picks up to m neighbours from candidates sorted by distance
+// using the diversity heuristic: a candidate is kept only if it is closer to
+// the base than to any neighbour already kept. Remaining slots are filled
+// with the closest discarded candidates.
+func (g *hnswGraph) selectNeighbors(candidates []hnswCandidate, m int) []hnswCandidate {
+	if len(candidates) <= m {
+		return candidates
+	}
+
+	selected := make([]hnswCandidate, 0, m)
+	var discarded []hnswCandidate
+	for _, c := range candidates {
+		if len(selected) == m {
+			break
+		}
+		keep := true
+		for _, s := range selected {
+			if g.distance(g.nodes[c.idx].Vector, g.nodes[s.idx].Vector) < c.dist {
+				keep = false
+				break
+			}
+		}
+		if keep {
+			selected = append(selected, c)
+		} else {
+			discarded = append(discarded, c)
+		}
+	}
+
+	for _, c := range discarded {
+		if len(selected) == m {
+			break
+		}
+		selected = append(selected, c)
+	}
+	return selected
+}
+
+// greedyClosest walks a layer towards the query until no neighbour is closer
+func (g *hnswGraph) greedyClosest(query []float32, ep int, epDist float64, layer int) (int, float64) {
+	for changed := true; changed; {
+		changed = false
+		for _, n := range g.nodes[ep].Neighbors[layer] {
+			if d := g.distance(query, g.nodes[n].Vector); d < epDist {
+				ep, epDist = n, d
+				changed = true
+			}
+		}
+	}
+	return ep, epDist
+}
+
+// searchLayer returns up to ef nodes closest to the query on a layer, sorted
+// by distance. Deleted nodes are included so they keep routing searches.
+func (g *hnswGraph) searchLayer(query []float32, entries []hnswCandidate, ef int, layer int) []hnswCandidate {
+	visited := make(map[int]bool, ef*4)
+	candidates := &minCandidateHeap{}
+	results := &maxCandidateHeap{}
+
+	for _, e := range entries {
+		visited[e.idx] = true
+		heap.Push(candidates, e)
+		heap.Push(results, e)
+	}
+
+	for candidates.Len() > 0 {
+		current := heap.Pop(candidates).(hnswCandidate)
+		if results.Len() >= ef && current.dist > (*results)[0].dist {
+			break
+		}
+
+		for _, n := range g.nodes[current.idx].Neighbors[layer] {
+			if visited[n] {
+				continue
+			}
+			visited[n] = true
+
+			d := g.distance(query, g.nodes[n].Vector)
+			if results.Len() < ef || d < (*results)[0].dist {
+				heap.Push(candidates, hnswCandidate{idx: n, dist: d})
+				heap.Push(results, hnswCandidate{idx: n, dist: d})
+				if results.Len() > ef {
+					heap.Pop(results)
+				}
+			}
+		}
+	}
+
+	found := []hnswCandidate(*results)
+	sortCandidates(found)
+	return found
+}
+
+// Search returns up to k live nodes closest to the query, sorted by distance
+func (g *hnswGraph) Search(query []float32, k int) ([]hnswCandidate, error) {
+	if g.entryPoint < 0 || k <= 0 {
+		return nil, nil
+	}
+	if err := g.checkDimension(query); err != nil {
+		return nil, err
+	}
+
+	ep := g.entryPoint
+	epDist := g.distance(query, g.nodes[ep].Vector)
+	for layer := g.maxLevel; layer > 0; layer-- {
+		ep, epDist = g.greedyClosest(query, ep, epDist, layer)
+	}
+
+	// Widen the search to make up for tombstones that will be filtered out
+	ef := max(g.config.EfSearch, k) + min(g.deleted, k)
+	found := g.searchLayer(query, []hnswCandidate{{idx: ep, dist: epDist}}, ef, 0)
+
+	results := make([]hnswCandidate, 0, min(k, len(found)))
+	for _, c := range found {
+		if g.nodes[c.idx].Deleted {
+			continue
+		}
+		results = append(results, c)
+		if len(results) == k {
+			break
+		}
+	}
+	return results, nil
+}
+
+// Delete tombstones the node with the given ID
+func (g *hnswGraph) Delete(id string) bool {
+	idx, ok := g.byID[id]
+	if !ok {
+		return false
+	}
+	delete(g.byID, id)
+	g.nodes[idx].Deleted = true
+	g.nodes[idx].ID = ""
+	g.deleted++
+	return true
+}
+
+// NeedsCompaction reports whether tombstones make up a quarter of the graph
+func (g *hnswGraph) NeedsCompaction() bool {
+	return g.deleted > 0 && g.deleted*4 >= len(g.nodes)
+}
+
+// Compact rebuilds the graph from its live nodes, dropping tombstones
+func (g *hnswGraph) Compact() *hnswGraph {
+	rebuilt, _ := newHNSWGraph(g.config)
+	for _, node := range g.nodes {
+		if !node.Deleted {
+			// Every live node has the graph's dimension, so this cannot fail
+			_ = rebuilt.Insert(node.ID, node.Vector)
+		}
+	}
+	return rebuilt
+}
+
+type hnswCandidate struct {
+	idx  int
+	dist float64
+}
+
+func candidateIndexes(candidates []hnswCandidate) []int {
+	indexes := make([]int, len(candidates))
+	for i, c := range candidates {
+		indexes[i] = c.idx
+	}
+	return indexes
+}
+
+func sortCandidates(candidates []hnswCandidate) {
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].dist < candidates[j].dist
+	})
+}
+
+// minCandidateHeap pops the closest candidate first
+type minCandidateHeap []hnswCandidate
+
+func (h minCandidateHeap) Len() int           { return len(h) }
+func (h minCandidateHeap) Less(i, j int) bool { return h[i].dist < h[j].dist }
+func (h minCandidateHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *minCandidateHeap) Push(x any)        { *h = append(*h, x.(hnswCandidate)) }
+func (h *minCandidateHeap) Pop() any {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
+
+// maxCandidateHeap pops the farthest candidate first
+type maxCandidateHeap []hnswCandidate
+
+func (h maxCandidateHeap) Len() int           { return len(h) }
+func (h maxCandidateHeap) Less(i, j int) bool { return h[i].dist > h[j].dist }
+func (h maxCandidateHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *maxCandidateHeap) Push(x any)        { *h = append(*h, x.(hnswCandidate)) }
+func (h *maxCandidateHeap) Pop() any {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
@@ -0,0 +1,304 @@
+package vectorstore
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/robstave/gorag/internal/domain/types"
+)
+
+// HNSWStore is an in-process VectorStore backed by an HNSW approximate nearest
+// neighbour index. Inserts and deletes are incremental; deletes leave
+// tombstones that are compacted away once they make up a quarter of the
+// graph. When a snapshot path is set, the graph is loaded from it on startup
+// and rewritten after every change, so it does not need to be rebuilt.
+type HNSWStore struct {
+	mu           sync.RWMutex
+	graph        *hnswGraph
+	chunks       map[string]types.Chunk
+	snapshotPath string
+	logger       *slog.Logger
+}
+
+// hnswSnapshot is the on-disk format of an HNSWStore
+type hnswSnapshot struct {
+	Config     HNSWConfig
+	Nodes      []hnswNode
+	EntryPoint int
+	MaxLevel   int
+	Chunks     []types.Chunk
+}
+
+// NewHNSWStore creates an HNSW vector store. If snapshotPath is not empty and
+// the file exists, the index is loaded from it.
+func NewHNSWStore(config HNSWConfig, snapshotPath string, logger *slog.Logger) (*HNSWStore, error) {
+	graph, err := newHNSWGraph(config)
+	if err != nil {
+		return nil, err
+	}
+
+	store := &HNSWStore{
+		graph:        graph,
+		chunks:       make(map[string]types.Chunk),
+		snapshotPath: snapshotPath,
+		logger:       logger,
+	}
+
+	if snapshotPath != "" {
+		if err := store.load(); err != nil {
+			return nil, err
+		}
+	}
+
+	return store, nil
+}
+
+// SetEfSearch changes the search candidate list size, trading speed for recall
+func (h *HNSWStore) SetEfSearch(ef int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if ef > 0 {
+		h.graph.config.EfSearch = ef
+	}
+}
+
+// AddChunks inserts chunks into the index, replacing existing entries with the same IDs
+func (h *HNSWStore) AddChunks(chunks []types.Chunk, embeddings [][]float32) error {
+	if len(chunks) != len(embeddings) {
+		return fmt.Errorf("got %d chunks but %d embeddings", len(chunks), len(embeddings))
+	}
+
+	// Check every embedding first so a bad batch leaves the index unchanged
+	for i, embedding := range embeddings {
+		if len(embedding) != len(embeddings[0]) {
+			return fmt.Errorf("%w: chunk %s has %d dimensions, chunk %s has %d", ErrDimensionMismatch, chunks[i].ID, len(embedding), chunks[0].ID, len(embeddings[0]))
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(embeddings) > 0 {
+		if err := h.graph.checkDimension(embeddings[0]); err != nil {
+			return err
+		}
+	}
+	for i, chunk := range chunks {
+		if err := h.graph.Insert(chunk.ID, embeddings[i]); err != nil {
+			return err
+		}
+		h.chunks[chunk.ID] = chunk
+	}
+
+	return h.saveLocked()
+}
+
+// QueryDocuments returns approximately the limit chunks closest to the query
+// embedding. With a filter, the graph is searched for progressively more
+// candidates until enough of them match or the whole graph has been visited.
+func (h *HNSWStore) QueryDocuments(query string, embedding []float32, limit int, filter *types.Filter) ([]types.SearchResult, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	size := h.graph.Len()
+	if limit <= 0 || limit > size {
+		limit = size
+	}
+
+	k := limit
+	if filter != nil {
+		k = 4 * limit
+	}
+	for {
+		found, err := h.graph.Search(embedding, k)
+		if err != nil {
+			return nil, err
+		}
+		results := make([]types.SearchResult, 0, limit)
+		for _, c := range found {
+			chunk := h.chunks[h.graph.nodes[c.idx].ID]
+			if filter != nil && !filter.Match(chunkMetadata(chunk)) {
+				continue
+			}
+			results = append(results, scoredResult(chunk, h.graph.config.Metric, c.dist))
+			if len(results) == limit {
+				break
+			}
+		}
+
+		if len(results) == limit || k >= size {
+			return results, nil
+		}
+		k *= 2
+	}
+}
+
+// DeleteDocument removes every chunk of a document
+func (h *HNSWStore) DeleteDocument(documentID string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	changed := false
+	for id, chunk := range h.chunks {
+		if chunk.DocumentID == documentID || id == documentID {
+			h.graph.Delete(id)
+			delete(h.chunks, id)
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	if h.graph.NeedsCompaction() {
+		h.logger.Info("Compacting HNSW index", "nodes", len(h.graph.nodes), "deleted", h.graph.deleted)
+		h.graph = h.graph.Compact()
+	}
+
+	return h.saveLocked()
+}
+
+// DeleteStaleChunks removes the chunks of a document whose IDs are not in keep
+func (h *HNSWStore) DeleteStaleChunks(documentID string, keep []string) error {
+	kept := idSet(keep)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	changed := false
+	for id, chunk := range h.chunks {
+		if (chunk.DocumentID == documentID || id == documentID) && !kept[id] {
+			h.graph.Delete(id)
+			delete(h.chunks, id)
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	if h.graph.NeedsCompaction() {
+		h.logger.Info("Compacting HNSW index", "nodes", len(h.graph.nodes), "deleted", h.graph.deleted)
+		h.graph = h.graph.Compact()
+	}
+
+	return h.saveLocked()
+}
+
+// ListDocuments returns the ID, parent document and content hash of every entry
+func (h *HNSWStore) ListDocuments() ([]types.IndexedDocument, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	indexed := make([]types.IndexedDocument, 0, len(h.chunks))
+	for id, chunk := range h.chunks {
+		indexed = append(indexed, types.IndexedDocument{
+			ID:          id,
+			DocumentID:  chunk.DocumentID,
+			ContentHash: chunk.ContentHash,
+		})
+	}
+	return indexed, nil
+}
+
+// GetEmbeddings returns the stored embeddings of the given chunks
+func (h *HNSWStore) GetEmbeddings(ids []string) (map[string][]float32, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	embeddings := make(map[string][]float32, len(ids))
+	for _, id := range ids {
+		if idx, ok := h.graph.byID[id]; ok {
+			embeddings[id] = h.graph.nodes[idx].Vector
+		}
+	}
+	return embeddings, nil
+}
+
+// load reads the snapshot file if it exists
+func (h *HNSWStore) load() error {
+	file, err := os.Open(h.snapshotPath)
+	if errors.Is(err, os.ErrNotExist) {
+		h.logger.Info("No HNSW snapshot found, starting empty", "path", h.snapshotPath)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var snapshot hnswSnapshot
+	if err := gob.NewDecoder(file).Decode(&snapshot); err != nil {
+		return fmt.Errorf("failed to read HNSW snapshot %s: %w", h.snapshotPath, err)
+	}
+	if snapshot.Config.Metric != h.graph.config.Metric {
+		return fmt.Errorf("HNSW snapshot %s uses metric %s, configured metric is %s", h.snapshotPath, snapshot.Config.Metric, h.graph.config.Metric)
+	}
+
+	// Keep the graph layout from the snapshot; only the search-time
+	// parameters of the current configuration apply to it. New nodes must
+	// draw their levels from the distribution the graph was built with.
+	if snapshot.Config.M != h.graph.config.M {
+		h.logger.Warn("HNSW snapshot was built with a different M, keeping the snapshot's", "path", h.snapshotPath, "snapshot", snapshot.Config.M, "configured", h.graph.config.M)
+	}
+	h.graph.nodes = snapshot.Nodes
+	h.graph.entryPoint = snapshot.EntryPoint
+	h.graph.maxLevel = snapshot.MaxLevel
+	h.graph.config.M = snapshot.Config.M
+	h.graph.levelMult = 1 / math.Log(float64(snapshot.Config.M))
+	for idx, node := range h.graph.nodes {
+		if node.Deleted {
+			h.graph.deleted++
+			continue
+		}
+		h.graph.byID[node.ID] = idx
+	}
+	for _, chunk := range snapshot.Chunks {
+		h.chunks[chunk.ID] = chunk
+	}
+
+	h.logger.Info("Loaded HNSW snapshot", "path", h.snapshotPath, "entries", h.graph.Len(), "deleted", h.graph.deleted)
+	return nil
+}
+
+// saveLocked writes the snapshot atomically. The caller must hold the lock.
+func (h *HNSWStore) saveLocked() error {
+	if h.snapshotPath == "" {
+		return nil
+	}
+
+	snapshot := hnswSnapshot{
+		Config:     h.graph.config,
+		Nodes:      h.graph.nodes,
+		EntryPoint: h.graph.entryPoint,
+		MaxLevel:   h.graph.maxLevel,
+		Chunks:     make([]types.Chunk, 0, len(h.chunks)),
+	}
+	for _, chunk := range h.chunks {
+		snapshot.Chunks = append(snapshot.Chunks, chunk)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(h.snapshotPath), filepath.Base(h.snapshotPath)+".tmp*")
+	if err != nil {
+		h.logger.Error("Failed to create HNSW snapshot", "path", h.snapshotPath, "error", err)
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := gob.NewEncoder(tmp).Encode(&snapshot); err != nil {
+		tmp.Close()
+		h.logger.Error("Failed to write HNSW snapshot", "path", h.snapshotPath, "error", err)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), h.snapshotPath)
+}
@@ -0,0 +1,284 @@
+package vectorstore
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"math/rand"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/robstave/gorag/internal/domain/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func randomVectors(n, dim int) [][]float32 {
+	rng := rand.New(rand.NewSource(1))
+	vectors := make([][]float32, n)
+	for i := range vectors {
+		vectors[i] = make([]float32, dim)
+		for j := range vectors[i] {
+			vectors[i][j] = rng.Float32()*2 - 1
+		}
+	}
+	return vectors
+}
+
+// testChunks returns n chunks spread over documents of ten chunks each, with
+// the document number in their metadata
+func testChunks(n int) []types.Chunk {
+	chunks := make([]types.Chunk, n)
+	for i := range chunks {
+		documentID := fmt.Sprintf("doc%d", i/10)
+		chunks[i] = types.Chunk{
+			ID:         fmt.Sprintf("%s:%d", documentID, i%10),
+			DocumentID: documentID,
+			Text:       fmt.Sprintf("chunk %d", i),
+			Metadata:   map[string]interface{}{"group": float64(i % 3)},
+		}
+	}
+	return chunks
+}
+
+// assertStoredEmbeddings checks that a store returns the embeddings it was
+// given for a few of the chunks and leaves out unknown IDs
+func assertStoredEmbeddings(t *testing.T, store EmbeddingReader, chunks []types.Chunk, vectors [][]float32) {
+	t.Helper()
+	embeddings, err := store.GetEmbeddings([]string{chunks[3].ID, chunks[7].ID, "missing"})
+	require.NoError(t, err)
+	require.Len(t, embeddings, 2)
+	assert.InDeltaSlice(t, vectors[3], embeddings[chunks[3].ID], 1e-6)
+	assert.InDeltaSlice(t, vectors[7], embeddings[chunks[7].ID], 1e-6)
+
+	embeddings, err = store.GetEmbeddings(nil)
+	require.NoError(t, err)
+	assert.Empty(t, embeddings)
+}
+
+func newTestHNSWStore(t *testing.T, snapshotPath string) *HNSWStore {
+	t.Helper()
+	store, err := NewHNSWStore(HNSWConfig{Metric: MetricCosine}, snapshotPath, testLogger())
+	require.NoError(t, err)
+	return store
+}
+
+func TestHNSWGraphRecall(t *testing.T) {
+	for _, metric := range []string{MetricCosine, MetricL2, MetricIP} {
+		t.Run(metric, func(t *testing.T) {
+			graph, err := newHNSWGraph(HNSWConfig{Metric: metric})
+			require.NoError(t, err)
+
+			vectors := randomVectors(500, 16)
+			for i, vector := range vectors {
+				require.NoError(t, graph.Insert(fmt.Sprint(i), vector))
+			}
+
+			// Compare the top 10 with an exact search for a few queries
+			const k = 10
+			hits := 0
+			queries := randomVectors(20, 16)
+			for _, query := range queries {
+				exact := make([]hnswCandidate, len(vectors))
+				for i, vector := range vectors {
+					exact[i] = hnswCandidate{idx: i, dist: graph.distance(query, vector)}
+				}
+				sort.Slice(exact, func(i, j int) bool { return exact[i].dist < exact[j].dist })
+				want := make(map[int]bool, k)
+				for _, c := range exact[:k] {
+					want[c.idx] = true
+				}
+
+				found, err := graph.Search(query, k)
+				require.NoError(t, err)
+				require.Len(t, found, k)
+				for _, c := range found {
+					if want[c.idx] {
+						hits++
+					}
+				}
+			}
+			assert.GreaterOrEqual(t, float64(hits)/float64(k*len(queries)), 0.9)
+		})
+	}
+}
+
+func TestHNSWGraphDimensionMismatch(t *testing.T) {
+	graph, err := newHNSWGraph(HNSWConfig{})
+	require.NoError(t, err)
+
+	require.NoError(t, graph.Insert("a", []float32{1, 0, 0}))
+	assert.ErrorIs(t, graph.Insert("b", []float32{1, 0}), ErrDimensionMismatch)
+	assert.Error(t, graph.Insert("c", nil))
+
+	_, err = graph.Search([]float32{1, 0, 0, 0}, 1)
+	assert.ErrorIs(t, err, ErrDimensionMismatch)
+	assert.Equal(t, 1, graph.Len())
+}
+
+func TestHNSWGraphDeleteAndCompact(t *testing.T) {
+	graph, err := newHNSWGraph(HNSWConfig{})
+	require.NoError(t, err)
+
+	vectors := randomVectors(40, 8)
+	for i, vector := range vectors {
+		require.NoError(t, graph.Insert(fmt.Sprint(i), vector))
+	}
+	for i := 0; i < 10; i++ {
+		assert.True(t, graph.Delete(fmt.Sprint(i)))
+	}
+	assert.False(t, graph.Delete("0"))
+	assert.Equal(t, 30, graph.Len())
+	assert.True(t, graph.NeedsCompaction())
+
+	// Tombstones are never returned
+	found, err := graph.Search(vectors[0], 40)
+	require.NoError(t, err)
+	assert.Len(t, found, 30)
+	for _, c := range found {
+		assert.False(t, graph.nodes[c.idx].Deleted)
+	}
+
+	compacted := graph.Compact()
+	assert.Equal(t, 30, compacted.Len())
+	assert.Len(t, compacted.nodes, 30)
+	assert.False(t, compacted.NeedsCompaction())
+}
+
+func TestHNSWStoreQueryDocuments(t *testing.T) {
+	store := newTestHNSWStore(t, "")
+	chunks := testChunks(100)
+	vectors := randomVectors(len(chunks), 8)
+	require.NoError(t, store.AddChunks(chunks, vectors))
+
+	results, err := store.QueryDocuments("", vectors[42], 5, nil)
+	require.NoError(t, err)
+	require.Len(t, results, 5)
+	assert.Equal(t, chunks[42].ID, results[0].Chunk.ID)
+	for i := 1; i < len(results); i++ {
+		assert.GreaterOrEqual(t, results[i-1].Score, results[i].Score)
+	}
+
+	// A limit far above the number of entries returns everything
+	results, err = store.QueryDocuments("", vectors[0], math.MaxInt, nil)
+	require.NoError(t, err)
+	assert.Len(t, results, len(chunks))
+
+	// A selective filter searches wider until enough entries match
+	filter, err := types.ParseFilter([]byte(`{"group": 2}`))
+	require.NoError(t, err)
+	results, err = store.QueryDocuments("", vectors[0], 20, filter)
+	require.NoError(t, err)
+	assert.Len(t, results, 20)
+	for _, result := range results {
+		assert.Equal(t, float64(2), result.Chunk.Metadata["group"])
+	}
+}
+
+func TestHNSWStoreGetEmbeddings(t *testing.T) {
+	store := newTestHNSWStore(t, "")
+	chunks := testChunks(20)
+	vectors := randomVectors(len(chunks), 8)
+	require.NoError(t, store.AddChunks(chunks, vectors))
+	assertStoredEmbeddings(t, store, chunks, vectors)
+
+	require.NoError(t, store.DeleteDocument("doc0"))
+	embeddings, err := store.GetEmbeddings([]string{chunks[3].ID, chunks[13].ID})
+	require.NoError(t, err)
+	assert.Len(t, embeddings, 1)
+	assert.Contains(t, embeddings, chunks[13].ID)
+}
+
+func TestHNSWStoreDimensionMismatch(t *testing.T) {
+	store := newTestHNSWStore(t, "")
+	chunks := testChunks(3)
+	require.NoError(t, store.AddChunks(chunks[:1], [][]float32{{1, 0, 0}}))
+
+	err := store.AddChunks(chunks[1:], [][]float32{{0, 1, 0}, {0, 1}})
+	assert.ErrorIs(t, err, ErrDimensionMismatch)
+	err = store.AddChunks(chunks[1:], [][]float32{{0, 1}, {1, 0}})
+	assert.ErrorIs(t, err, ErrDimensionMismatch)
+
+	// Rejected batches leave the index unchanged
+	indexed, err := store.ListDocuments()
+	require.NoError(t, err)
+	assert.Len(t, indexed, 1)
+
+	_, err = store.QueryDocuments("", []float32{1, 0}, 5, nil)
+	assert.ErrorIs(t, err, ErrDimensionMismatch)
+}
+
+func TestHNSWStoreDeletes(t *testing.T) {
+	store := newTestHNSWStore(t, "")
+	chunks := testChunks(30)
+	vectors := randomVectors(len(chunks), 8)
+	require.NoError(t, store.AddChunks(chunks, vectors))
+
+	require.NoError(t, store.DeleteDocument("doc1"))
+	require.NoError(t, store.DeleteStaleChunks("doc2", []string{"doc2:0", "doc2:1"}))
+
+	indexed, err := store.ListDocuments()
+	require.NoError(t, err)
+	counts := make(map[string]int)
+	for _, entry := range indexed {
+		counts[entry.DocumentID]++
+	}
+	assert.Equal(t, map[string]int{"doc0": 10, "doc2": 2}, counts)
+
+	results, err := store.QueryDocuments("", vectors[0], 0, nil)
+	require.NoError(t, err)
+	assert.Len(t, results, 12)
+}
+
+func TestHNSWStoreSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hnsw.gob")
+	store := newTestHNSWStore(t, path)
+	chunks := testChunks(20)
+	vectors := randomVectors(len(chunks), 8)
+	require.NoError(t, store.AddChunks(chunks, vectors))
+	require.NoError(t, store.DeleteDocument("doc1"))
+
+	loaded := newTestHNSWStore(t, path)
+	results, err := loaded.QueryDocuments("", vectors[3], 1, nil)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, chunks[3].ID, results[0].Chunk.ID)
+	assert.Equal(t, 10, loaded.graph.Len())
+
+	_, err = NewHNSWStore(HNSWConfig{Metric: MetricL2}, path, testLogger())
+	assert.Error(t, err)
+}
+
+func TestHNSWStoreSnapshotKeepsM(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hnsw.gob")
+	store, err := NewHNSWStore(HNSWConfig{M: 4, Metric: MetricCosine}, path, testLogger())
+	require.NoError(t, err)
+	chunks := testChunks(60)
+	vectors := randomVectors(len(chunks), 8)
+	require.NoError(t, store.AddChunks(chunks[:30], vectors[:30]))
+
+	// Reloading with another M keeps the layout the graph was built with,
+	// including the level distribution of new nodes
+	loaded, err := NewHNSWStore(HNSWConfig{M: 32, Metric: MetricCosine}, path, testLogger())
+	require.NoError(t, err)
+	assert.Equal(t, 4, loaded.graph.config.M)
+	assert.Equal(t, store.graph.levelMult, loaded.graph.levelMult)
+	assert.InDelta(t, 1/math.Log(4), loaded.graph.levelMult, 1e-12)
+
+	require.NoError(t, loaded.AddChunks(chunks[30:], vectors[30:]))
+	for _, node := range loaded.graph.nodes {
+		for layer, neighbors := range node.Neighbors {
+			assert.LessOrEqual(t, len(neighbors), loaded.graph.maxNeighbors(layer))
+		}
+	}
+	results, err := loaded.QueryDocuments("", vectors[45], 1, nil)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, chunks[45].ID, results[0].ID)
+}
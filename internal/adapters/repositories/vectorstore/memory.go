@@ -0,0 +1,300 @@
+package vectorstore
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/robstave/gorag/internal/domain/types"
+)
+
+// memorySnapshotDelay is how long changes are batched before the snapshot is
+// rewritten, so a bulk load writes it a few times instead of once per chunk
+const memorySnapshotDelay = 2 * time.Second
+
+// MemoryStore is an in-process VectorStore that scores every entry by brute
+// force. When a snapshot path is set, the store is loaded from it on startup
+// and rewritten shortly after changes; Close writes any pending changes.
+type MemoryStore struct {
+	mu           sync.RWMutex
+	entries      map[string]memoryEntry
+	metric       string
+	distance     distanceFunc
+	snapshotPath string
+	logger       *slog.Logger
+
+	// dirty and saveTimer are guarded by mu; saveMu serialises snapshot writes
+	dirty     bool
+	saveTimer *time.Timer
+	saveMu    sync.Mutex
+}
+
+// memoryEntry is a stored chunk and its embedding. Fields are exported for gob.
+type memoryEntry struct {
+	Chunk     types.Chunk
+	Embedding []float32
+}
+
+// memorySnapshot is the on-disk format of a MemoryStore
+type memorySnapshot struct {
+	Metric  string
+	Entries []memoryEntry
+}
+
+// NewMemoryStore creates an in-memory vector store using the given distance
+// metric. If snapshotPath is not empty and the file exists, it is loaded.
+func NewMemoryStore(metric string, snapshotPath string, logger *slog.Logger) (*MemoryStore, error) {
+	if metric == "" {
+		metric = MetricCosine
+	}
+	distance, err := distanceFor(metric)
+	if err != nil {
+		return nil, err
+	}
+
+	store := &MemoryStore{
+		entries:      make(map[string]memoryEntry),
+		metric:       metric,
+		distance:     distance,
+		snapshotPath: snapshotPath,
+		logger:       logger,
+	}
+
+	if snapshotPath != "" {
+		if err := store.load(); err != nil {
+			return nil, err
+		}
+	}
+
+	return store, nil
+}
+
+// AddChunks adds chunks to the store, replacing any existing entries with the same IDs
+func (m *MemoryStore) AddChunks(chunks []types.Chunk, embeddings [][]float32) error {
+	if len(chunks) != len(embeddings) {
+		return fmt.Errorf("got %d chunks but %d embeddings", len(chunks), len(embeddings))
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, chunk := range chunks {
+		m.entries[chunk.ID] = memoryEntry{Chunk: chunk, Embedding: embeddings[i]}
+	}
+
+	m.scheduleSaveLocked()
+	return nil
+}
+
+// QueryDocuments returns the limit chunks closest to the query embedding,
+// evaluating the filter against each entry's metadata
+func (m *MemoryStore) QueryDocuments(query string, embedding []float32, limit int, filter *types.Filter) ([]types.SearchResult, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	results := make([]types.SearchResult, 0, len(m.entries))
+	for id, entry := range m.entries {
+		if filter != nil && !filter.Match(chunkMetadata(entry.Chunk)) {
+			continue
+		}
+		if len(entry.Embedding) != len(embedding) {
+			m.logger.Warn("Skipping entry with mismatched embedding dimension", "id", id, "expected", len(embedding), "actual", len(entry.Embedding))
+			continue
+		}
+		results = append(results, scoredResult(entry.Chunk, m.metric, m.distance(embedding, entry.Embedding)))
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Distance != results[j].Distance {
+			return results[i].Distance < results[j].Distance
+		}
+		return results[i].ID < results[j].ID
+	})
+
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// DeleteDocument removes every chunk of a document
+func (m *MemoryStore) DeleteDocument(documentID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	changed := false
+	for id, entry := range m.entries {
+		if entry.Chunk.DocumentID == documentID || id == documentID {
+			delete(m.entries, id)
+			changed = true
+		}
+	}
+
+	if changed {
+		m.scheduleSaveLocked()
+	}
+	return nil
+}
+
+// DeleteStaleChunks removes the chunks of a document whose IDs are not in keep
+func (m *MemoryStore) DeleteStaleChunks(documentID string, keep []string) error {
+	kept := idSet(keep)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	changed := false
+	for id, entry := range m.entries {
+		if (entry.Chunk.DocumentID == documentID || id == documentID) && !kept[id] {
+			delete(m.entries, id)
+			changed = true
+		}
+	}
+
+	if changed {
+		m.scheduleSaveLocked()
+	}
+	return nil
+}
+
+// ListDocuments returns the ID, parent document and content hash of every entry
+func (m *MemoryStore) ListDocuments() ([]types.IndexedDocument, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	indexed := make([]types.IndexedDocument, 0, len(m.entries))
+	for id, entry := range m.entries {
+		indexed = append(indexed, types.IndexedDocument{
+			ID:          id,
+			DocumentID:  entry.Chunk.DocumentID,
+			ContentHash: entry.Chunk.ContentHash,
+		})
+	}
+	return indexed, nil
+}
+
+// GetEmbeddings returns the stored embeddings of the given chunks
+func (m *MemoryStore) GetEmbeddings(ids []string) (map[string][]float32, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	embeddings := make(map[string][]float32, len(ids))
+	for _, id := range ids {
+		if entry, ok := m.entries[id]; ok {
+			embeddings[id] = entry.Embedding
+		}
+	}
+	return embeddings, nil
+}
+
+// load reads the snapshot file if it exists
+func (m *MemoryStore) load() error {
+	file, err := os.Open(m.snapshotPath)
+	if errors.Is(err, os.ErrNotExist) {
+		m.logger.Info("No vector snapshot found, starting empty", "path", m.snapshotPath)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var snapshot memorySnapshot
+	if err := gob.NewDecoder(file).Decode(&snapshot); err != nil {
+		return fmt.Errorf("failed to read vector snapshot %s: %w", m.snapshotPath, err)
+	}
+	if snapshot.Metric != m.metric {
+		return fmt.Errorf("vector snapshot %s uses metric %s, configured metric is %s", m.snapshotPath, snapshot.Metric, m.metric)
+	}
+
+	for _, entry := range snapshot.Entries {
+		m.entries[entry.Chunk.ID] = entry
+	}
+
+	m.logger.Info("Loaded vector snapshot", "path", m.snapshotPath, "entries", len(m.entries))
+	return nil
+}
+
+// scheduleSaveLocked marks the store as changed and starts the timer that
+// writes the snapshot. The caller must hold the write lock.
+func (m *MemoryStore) scheduleSaveLocked() {
+	if m.snapshotPath == "" {
+		return
+	}
+	m.dirty = true
+	if m.saveTimer == nil {
+		m.saveTimer = time.AfterFunc(memorySnapshotDelay, func() {
+			if err := m.Flush(); err != nil {
+				m.logger.Warn("Failed to save vector snapshot, retrying on the next change", "path", m.snapshotPath, "error", err)
+			}
+		})
+	}
+}
+
+// Flush writes pending changes to the snapshot. Entries are copied under the
+// lock and encoded after releasing it, so queries and writes are not blocked
+// while the file is written.
+func (m *MemoryStore) Flush() error {
+	m.saveMu.Lock()
+	defer m.saveMu.Unlock()
+
+	m.mu.Lock()
+	if m.saveTimer != nil {
+		m.saveTimer.Stop()
+		m.saveTimer = nil
+	}
+	if !m.dirty {
+		m.mu.Unlock()
+		return nil
+	}
+	m.dirty = false
+	snapshot := memorySnapshot{
+		Metric:  m.metric,
+		Entries: make([]memoryEntry, 0, len(m.entries)),
+	}
+	for _, entry := range m.entries {
+		snapshot.Entries = append(snapshot.Entries, entry)
+	}
+	m.mu.Unlock()
+
+	if err := m.save(&snapshot); err != nil {
+		// Keep the changes pending so the next change or Close retries
+		m.mu.Lock()
+		m.dirty = true
+		m.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Close writes any pending changes to the snapshot
+func (m *MemoryStore) Close() error {
+	return m.Flush()
+}
+
+// save writes the snapshot atomically
+func (m *MemoryStore) save(snapshot *memorySnapshot) error {
+	tmp, err := os.CreateTemp(filepath.Dir(m.snapshotPath), filepath.Base(m.snapshotPath)+".tmp*")
+	if err != nil {
+		m.logger.Error("Failed to create vector snapshot", "path", m.snapshotPath, "error", err)
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := gob.NewEncoder(tmp).Encode(snapshot); err != nil {
+		tmp.Close()
+		m.logger.Error("Failed to write vector snapshot", "path", m.snapshotPath, "error", err)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), m.snapshotPath)
+}
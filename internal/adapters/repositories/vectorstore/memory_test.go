@@ -0,0 +1,134 @@
+package vectorstore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/robstave/gorag/internal/domain/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStoreMetrics(t *testing.T) {
+	chunks := []types.Chunk{
+		{ID: "a", DocumentID: "a"},
+		{ID: "b", DocumentID: "b"},
+		{ID: "c", DocumentID: "c"},
+	}
+	embeddings := [][]float32{{1, 0}, {0.6, 0.8}, {-1, 0}}
+	query := []float32{1, 0}
+
+	tests := []struct {
+		metric    string
+		distances []float64
+		scores    []float64
+	}{
+		{MetricCosine, []float64{0, 0.4, 2}, []float64{1, 0.8, 0}},
+		{MetricL2, []float64{0, 0.8, 4}, []float64{1, 1 / 1.8, 0.2}},
+		{MetricIP, []float64{0, 0.4, 2}, []float64{1, 0.8, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.metric, func(t *testing.T) {
+			store, err := NewMemoryStore(tt.metric, "", testLogger())
+			require.NoError(t, err)
+			require.NoError(t, store.AddChunks(chunks, embeddings))
+
+			results, err := store.QueryDocuments("", query, 0, nil)
+			require.NoError(t, err)
+			require.Len(t, results, 3)
+			for i, result := range results {
+				assert.Equal(t, chunks[i].ID, result.ID)
+				assert.Equal(t, tt.metric, result.Metric)
+				assert.InDelta(t, tt.distances[i], result.Distance, 1e-6)
+				assert.InDelta(t, tt.scores[i], result.Score, 1e-6)
+			}
+		})
+	}
+
+	_, err := NewMemoryStore("manhattan", "", testLogger())
+	assert.Error(t, err)
+}
+
+func TestMemoryStoreQueryAndDelete(t *testing.T) {
+	store, err := NewMemoryStore(MetricCosine, "", testLogger())
+	require.NoError(t, err)
+	chunks := testChunks(30)
+	embeddings := randomVectors(30, 8)
+	require.NoError(t, store.AddChunks(chunks, embeddings))
+
+	results, err := store.QueryDocuments("", embeddings[12], 5, nil)
+	require.NoError(t, err)
+	require.Len(t, results, 5)
+	assert.Equal(t, "doc1:2", results[0].ID)
+
+	filter := &types.Filter{Field: "group", Op: types.FilterEq, Value: float64(1)}
+	results, err = store.QueryDocuments("", embeddings[0], 0, filter)
+	require.NoError(t, err)
+	assert.Len(t, results, 10)
+	for _, result := range results {
+		assert.Equal(t, float64(1), result.Chunk.Metadata["group"])
+	}
+
+	require.NoError(t, store.DeleteStaleChunks("doc0", []string{"doc0:0", "doc0:1"}))
+	require.NoError(t, store.DeleteDocument("doc2"))
+	indexed, err := store.ListDocuments()
+	require.NoError(t, err)
+	assert.Len(t, indexed, 12)
+
+	// Entries of another dimension are skipped rather than scored
+	require.NoError(t, store.AddChunks([]types.Chunk{{ID: "odd", DocumentID: "odd"}}, [][]float32{{1, 2, 3}}))
+	results, err = store.QueryDocuments("", embeddings[0], 0, nil)
+	require.NoError(t, err)
+	assert.Len(t, results, 12)
+}
+
+func TestMemoryStoreGetEmbeddings(t *testing.T) {
+	store, err := NewMemoryStore(MetricCosine, "", testLogger())
+	require.NoError(t, err)
+	chunks := testChunks(10)
+	vectors := randomVectors(len(chunks), 8)
+	require.NoError(t, store.AddChunks(chunks, vectors))
+	assertStoredEmbeddings(t, store, chunks, vectors)
+}
+
+func TestMemoryStoreSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vectors.gob")
+	store, err := NewMemoryStore(MetricL2, path, testLogger())
+	require.NoError(t, err)
+
+	chunks := testChunks(20)
+	embeddings := randomVectors(20, 8)
+	for i := range chunks {
+		require.NoError(t, store.AddChunks(chunks[i:i+1], embeddings[i:i+1]))
+	}
+	require.NoError(t, store.DeleteDocument("doc1"))
+
+	// Changes are batched rather than written one by one
+	_, err = os.Stat(path)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	require.NoError(t, store.Close())
+	reloaded, err := NewMemoryStore(MetricL2, path, testLogger())
+	require.NoError(t, err)
+
+	want, err := store.QueryDocuments("", embeddings[3], 5, nil)
+	require.NoError(t, err)
+	got, err := reloaded.QueryDocuments("", embeddings[3], 5, nil)
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+	indexed, err := reloaded.ListDocuments()
+	require.NoError(t, err)
+	assert.Len(t, indexed, 10)
+
+	// Closing without pending changes leaves the snapshot alone
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.NoError(t, store.Close())
+	again, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, info.ModTime(), again.ModTime())
+
+	_, err = NewMemoryStore(MetricCosine, path, testLogger())
+	assert.Error(t, err, "a snapshot written with another metric is rejected")
+}
@@ -0,0 +1,488 @@
+package vectorstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/robstave/gorag/internal/domain/types"
+	"gorm.io/gorm"
+)
+
+// pgvector index types
+const (
+	PgVectorIndexHNSW    = "hnsw"
+	PgVectorIndexIVFFlat = "ivfflat"
+	PgVectorIndexNone    = "none"
+)
+
+// pgVectorTable holds chunks and their embeddings
+const pgVectorTable = "chunk_embeddings"
+
+// Dimension limits of pgvector: the vector type stores up to 16000
+// dimensions, but its hnsw and ivfflat indexes only accept up to 2000
+const (
+	pgVectorMaxDimension      = 16000
+	pgVectorMaxIndexDimension = 2000
+)
+
+// PgVectorConfig configures a PgVectorStore
+type PgVectorConfig struct {
+	// Metric is the distance metric: cosine, l2 or ip (default cosine)
+	Metric string
+	// Dimension is the embedding dimension. When 0 the table is created from
+	// the first batch of embeddings written.
+	Dimension int
+	// Index is the approximate index type: hnsw, ivfflat or none (default hnsw)
+	Index string
+	// M and EfConstruction tune the HNSW index; EfSearch sets hnsw.ef_search
+	// for queries. 0 keeps the pgvector defaults.
+	M              int
+	EfConstruction int
+	EfSearch       int
+	// Lists is the number of ivfflat lists (default 100); Probes sets
+	// ivfflat.probes for queries
+	Lists  int
+	Probes int
+}
+
+// PgVectorStore is a VectorStore on Postgres with the pgvector extension.
+// Embeddings live in the chunk_embeddings table next to the documents and are
+// searched with pgvector's distance operators and an HNSW or ivfflat index.
+type PgVectorStore struct {
+	db     *gorm.DB
+	config PgVectorConfig
+	logger *slog.Logger
+
+	// ready is set once the table exists; dimension is the size of its
+	// vector column. Both are guarded by mu.
+	mu        sync.Mutex
+	ready     bool
+	dimension int
+}
+
+// pgVectorRow is a row of the chunk_embeddings table without its embedding
+type pgVectorRow struct {
+	ID          string
+	DocumentID  string
+	ChunkIndex  int
+	StartOffset int
+	EndOffset   int
+	Text        string
+	ContentHash string
+	Distance    float64
+}
+
+// NewPgVectorStore creates a pgvector store on a Postgres connection, enabling
+// the extension and creating the table and index if the dimension is known
+func NewPgVectorStore(db *gorm.DB, config PgVectorConfig, logger *slog.Logger) (*PgVectorStore, error) {
+	if name := db.Dialector.Name(); name != "postgres" {
+		return nil, fmt.Errorf("pgvector requires a postgres database, got %s", name)
+	}
+	if config.Metric == "" {
+		config.Metric = MetricCosine
+	}
+	if _, err := pgVectorOps(config.Metric); err != nil {
+		return nil, err
+	}
+	if config.Index == "" {
+		config.Index = PgVectorIndexHNSW
+	}
+	switch config.Index {
+	case PgVectorIndexHNSW, PgVectorIndexIVFFlat, PgVectorIndexNone:
+	default:
+		return nil, fmt.Errorf("unknown pgvector index: %s", config.Index)
+	}
+	if config.Lists <= 0 {
+		config.Lists = 100
+	}
+
+	if err := db.Exec("CREATE EXTENSION IF NOT EXISTS vector").Error; err != nil {
+		logger.Error("Failed to enable pgvector extension", "error", err)
+		return nil, err
+	}
+
+	store := &PgVectorStore{
+		db:     db,
+		config: config,
+		logger: logger,
+	}
+	if db.Migrator().HasTable(pgVectorTable) {
+		dimension, err := store.tableDimension()
+		if err != nil {
+			return nil, err
+		}
+		if config.Dimension > 0 && dimension != config.Dimension {
+			return nil, fmt.Errorf("%w: table %s stores %d-dimensional vectors, embeddings have %d", ErrDimensionMismatch, pgVectorTable, dimension, config.Dimension)
+		}
+		store.ready = true
+		store.dimension = dimension
+	} else if config.Dimension > 0 {
+		if err := store.ensureTable(config.Dimension); err != nil {
+			return nil, err
+		}
+	}
+
+	return store, nil
+}
+
+// tableDimension reads the dimension of the existing table's vector column
+func (s *PgVectorStore) tableDimension() (int, error) {
+	var columnType string
+	err := s.db.Raw(`SELECT format_type(atttypid, atttypmod) FROM pg_attribute
+		WHERE attrelid = to_regclass(?) AND attname = 'embedding' AND NOT attisdropped`, pgVectorTable).Scan(&columnType).Error
+	if err != nil {
+		s.logger.Error("Failed to read pgvector column type", "error", err)
+		return 0, err
+	}
+	return parsePgVectorType(columnType)
+}
+
+// parsePgVectorType returns the dimension of a column type such as vector(1536)
+func parsePgVectorType(columnType string) (int, error) {
+	inner, ok := strings.CutPrefix(columnType, "vector(")
+	if ok {
+		inner, ok = strings.CutSuffix(inner, ")")
+	}
+	if !ok {
+		return 0, fmt.Errorf("table %s has embedding column of type %q, expected vector(n)", pgVectorTable, columnType)
+	}
+	dimension, err := strconv.Atoi(inner)
+	if err != nil || dimension <= 0 {
+		return 0, fmt.Errorf("table %s has embedding column of type %q, expected vector(n)", pgVectorTable, columnType)
+	}
+	return dimension, nil
+}
+
+// checkDimension reports an error if vectors of the given dimension cannot be
+// stored in or compared with the table
+func (s *PgVectorStore) checkDimension(dimension int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ready && dimension != s.dimension {
+		return fmt.Errorf("%w: got %d, table %s has %d", ErrDimensionMismatch, dimension, pgVectorTable, s.dimension)
+	}
+	return nil
+}
+
+// ensureTable creates the table and its indexes for the given dimension
+// unless they already exist. Dimensions above pgVectorMaxIndexDimension get
+// no vector index, since creating it would fail and roll back the table.
+func (s *PgVectorStore) ensureTable(dimension int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ready {
+		return nil
+	}
+	if dimension > pgVectorMaxDimension {
+		return fmt.Errorf("pgvector supports at most %d dimensions, embeddings have %d", pgVectorMaxDimension, dimension)
+	}
+	index := s.config.Index
+	if index != PgVectorIndexNone && dimension > pgVectorMaxIndexDimension {
+		s.logger.Warn("Embedding dimension is too large for a pgvector index, searches will scan the whole table",
+			"dimension", dimension, "maxIndexDimension", pgVectorMaxIndexDimension, "index", index)
+		index = PgVectorIndexNone
+	}
+
+	statements := []string{
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			id TEXT PRIMARY KEY,
+			document_id TEXT NOT NULL,
+			chunk_index INTEGER NOT NULL DEFAULT 0,
+			start_offset INTEGER NOT NULL DEFAULT 0,
+			end_offset INTEGER NOT NULL DEFAULT 0,
+			text TEXT NOT NULL DEFAULT '',
+			content_hash VARCHAR(64) NOT NULL DEFAULT '',
+			metadata JSONB NOT NULL DEFAULT '{}',
+			embedding vector(%d) NOT NULL
+		)`, pgVectorTable, dimension),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_%s_document_id ON %s (document_id)", pgVectorTable, pgVectorTable),
+	}
+
+	ops, _ := pgVectorOps(s.config.Metric)
+	switch index {
+	case PgVectorIndexHNSW:
+		var with []string
+		if s.config.M > 0 {
+			with = append(with, fmt.Sprintf("m = %d", s.config.M))
+		}
+		if s.config.EfConstruction > 0 {
+			with = append(with, fmt.Sprintf("ef_construction = %d", s.config.EfConstruction))
+		}
+		statement := fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_%s_embedding ON %s USING hnsw (embedding %s)", pgVectorTable, pgVectorTable, ops.class)
+		if len(with) > 0 {
+			statement += " WITH (" + strings.Join(with, ", ") + ")"
+		}
+		statements = append(statements, statement)
+	case PgVectorIndexIVFFlat:
+		statements = append(statements, fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_%s_embedding ON %s USING ivfflat (embedding %s) WITH (lists = %d)",
+			pgVectorTable, pgVectorTable, ops.class, s.config.Lists))
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		for _, statement := range statements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		s.logger.Error("Failed to create pgvector table", "error", err)
+		return err
+	}
+
+	s.logger.Info("Created pgvector table", "table", pgVectorTable, "dimension", dimension, "index", index, "metric", s.config.Metric)
+	s.ready = true
+	s.dimension = dimension
+	return nil
+}
+
+// tableReady reports whether the table exists without creating it
+func (s *PgVectorStore) tableReady() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ready
+}
+
+// AddChunks upserts chunks, replacing any existing entries with the same IDs
+func (s *PgVectorStore) AddChunks(chunks []types.Chunk, embeddings [][]float32) error {
+	if len(chunks) != len(embeddings) {
+		return fmt.Errorf("got %d chunks but %d embeddings", len(chunks), len(embeddings))
+	}
+	if len(chunks) == 0 {
+		return nil
+	}
+
+	// Check every embedding first so a bad batch fails before touching the table
+	for i, embedding := range embeddings {
+		if len(embedding) != len(embeddings[0]) {
+			return fmt.Errorf("%w: chunk %s has %d dimensions, chunk %s has %d", ErrDimensionMismatch, chunks[i].ID, len(embedding), chunks[0].ID, len(embeddings[0]))
+		}
+	}
+	if err := s.ensureTable(len(embeddings[0])); err != nil {
+		return err
+	}
+	if err := s.checkDimension(len(embeddings[0])); err != nil {
+		return err
+	}
+
+	statement := fmt.Sprintf(`INSERT INTO %s
+		(id, document_id, chunk_index, start_offset, end_offset, text, content_hash, metadata, embedding)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?::jsonb, ?::vector)
+		ON CONFLICT (id) DO UPDATE SET
+			document_id = EXCLUDED.document_id,
+			chunk_index = EXCLUDED.chunk_index,
+			start_offset = EXCLUDED.start_offset,
+			end_offset = EXCLUDED.end_offset,
+			text = EXCLUDED.text,
+			content_hash = EXCLUDED.content_hash,
+			metadata = EXCLUDED.metadata,
+			embedding = EXCLUDED.embedding`, pgVectorTable)
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		for i, chunk := range chunks {
+			metadata, err := json.Marshal(chunkMetadata(chunk))
+			if err != nil {
+				return err
+			}
+			if err := tx.Exec(statement,
+				chunk.ID, chunk.DocumentID, chunk.Index, chunk.Start, chunk.End,
+				chunk.Text, chunk.ContentHash, string(metadata), pgVectorLiteral(embeddings[i]),
+			).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		s.logger.Error("Failed to upsert pgvector entries", "error", err)
+	}
+	return err
+}
+
+// QueryDocuments returns the limit chunks closest to the query embedding
+// whose metadata matches filter. Filtering happens in SQL alongside the
+// index scan.
+func (s *PgVectorStore) QueryDocuments(query string, embedding []float32, limit int, filter *types.Filter) ([]types.SearchResult, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+	if !s.tableReady() {
+		// Nothing has been written yet
+		return []types.SearchResult{}, nil
+	}
+	if err := s.checkDimension(len(embedding)); err != nil {
+		return nil, err
+	}
+
+	ops, _ := pgVectorOps(s.config.Metric)
+	vector := pgVectorLiteral(embedding)
+
+	args := []interface{}{vector}
+	where := ""
+	if filter != nil {
+		clause, filterArgs, err := sqlFilter(*filter, sqlDialectPostgres)
+		if err != nil {
+			return nil, err
+		}
+		where = " WHERE " + clause
+		args = append(args, filterArgs...)
+	}
+	args = append(args, vector, limit)
+
+	// Order by the bare operator so the index is used; the reported distance
+	// is converted to match the other stores
+	statement := fmt.Sprintf(`SELECT id, document_id, chunk_index, start_offset, end_offset, text, content_hash,
+		%s AS distance FROM %s`, fmt.Sprintf(ops.distance, "embedding "+ops.operator+" ?::vector"), pgVectorTable)
+	statement += where + " ORDER BY embedding " + ops.operator + " ?::vector LIMIT ?"
+
+	var rows []pgVectorRow
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if s.config.EfSearch > 0 {
+			if err := tx.Exec("SET LOCAL hnsw.ef_search = " + strconv.Itoa(s.config.EfSearch)).Error; err != nil {
+				return err
+			}
+		}
+		if s.config.Probes > 0 {
+			if err := tx.Exec("SET LOCAL ivfflat.probes = " + strconv.Itoa(s.config.Probes)).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Raw(statement, args...).Scan(&rows).Error
+	})
+	if err != nil {
+		s.logger.Error("Failed to query pgvector", "error", err)
+		return nil, err
+	}
+
+	results := make([]types.SearchResult, len(rows))
+	for i, row := range rows {
+		results[i] = scoredResult(types.Chunk{
+			ID:          row.ID,
+			DocumentID:  row.DocumentID,
+			Index:       row.ChunkIndex,
+			Start:       row.StartOffset,
+			End:         row.EndOffset,
+			Text:        row.Text,
+			ContentHash: row.ContentHash,
+		}, s.config.Metric, row.Distance)
+	}
+	return results, nil
+}
+
+// DeleteDocument removes every chunk of a document
+func (s *PgVectorStore) DeleteDocument(documentID string) error {
+	if !s.tableReady() {
+		return nil
+	}
+	return s.db.Exec(fmt.Sprintf("DELETE FROM %s WHERE document_id = ? OR id = ?", pgVectorTable), documentID, documentID).Error
+}
+
+// DeleteStaleChunks removes the chunks of a document whose IDs are not in keep
+func (s *PgVectorStore) DeleteStaleChunks(documentID string, keep []string) error {
+	if len(keep) == 0 {
+		return s.DeleteDocument(documentID)
+	}
+	if !s.tableReady() {
+		return nil
+	}
+	return s.db.Exec(fmt.Sprintf("DELETE FROM %s WHERE (document_id = ? OR id = ?) AND id NOT IN ?", pgVectorTable), documentID, documentID, keep).Error
+}
+
+// ListDocuments returns the ID, parent document and content hash of every entry
+func (s *PgVectorStore) ListDocuments() ([]types.IndexedDocument, error) {
+	if !s.tableReady() {
+		return nil, nil
+	}
+
+	var indexed []types.IndexedDocument
+	result := s.db.Table(pgVectorTable).
+		Select("id, document_id, content_hash").
+		Scan(&indexed)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return indexed, nil
+}
+
+// GetEmbeddings returns the stored embeddings of the given chunks
+func (s *PgVectorStore) GetEmbeddings(ids []string) (map[string][]float32, error) {
+	embeddings := make(map[string][]float32, len(ids))
+	if len(ids) == 0 || !s.tableReady() {
+		return embeddings, nil
+	}
+
+	var rows []struct {
+		ID        string
+		Embedding string
+	}
+	statement := fmt.Sprintf("SELECT id, embedding::text AS embedding FROM %s WHERE id IN ?", pgVectorTable)
+	if err := s.db.Raw(statement, ids).Scan(&rows).Error; err != nil {
+		s.logger.Error("Failed to read pgvector embeddings", "error", err)
+		return nil, err
+	}
+	for _, row := range rows {
+		embedding, err := parsePgVectorLiteral(row.Embedding)
+		if err != nil {
+			return nil, fmt.Errorf("invalid embedding for %s: %w", row.ID, err)
+		}
+		embeddings[row.ID] = embedding
+	}
+	return embeddings, nil
+}
+
+// pgVectorOperators describes how a metric maps onto pgvector
+type pgVectorOperators struct {
+	// operator is the distance operator
+	operator string
+	// class is the operator class used by the index
+	class string
+	// distance wraps the operator expression to match Chroma's distances
+	distance string
+}
+
+// pgVectorOps returns the pgvector operators for a metric. <=> is already
+// 1 - cos; <-> is the Euclidean distance, squared to match l2; <#> is the
+// negated inner product, so 1 + it is 1 - a·b.
+func pgVectorOps(metric string) (pgVectorOperators, error) {
+	switch metric {
+	case "", MetricCosine:
+		return pgVectorOperators{operator: "<=>", class: "vector_cosine_ops", distance: "(%s)"}, nil
+	case MetricL2:
+		return pgVectorOperators{operator: "<->", class: "vector_l2_ops", distance: "power(%s, 2)"}, nil
+	case MetricIP:
+		return pgVectorOperators{operator: "<#>", class: "vector_ip_ops", distance: "1 + (%s)"}, nil
+	default:
+		return pgVectorOperators{}, fmt.Errorf("unknown distance metric: %s", metric)
+	}
+}
+
+// pgVectorLiteral formats an embedding as a pgvector text literal
+func pgVectorLiteral(embedding []float32) string {
+	var b strings.Builder
+	b.Grow(len(embedding) * 10)
+	b.WriteByte('[')
+	for i, v := range embedding {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.FormatFloat(float64(v), 'g', -1, 32))
+	}
+	b.WriteByte(']')
+	return b.String()
+}
+
+// parsePgVectorLiteral parses the text form of a pgvector value, such as
+// [1,-0.5,0.25]
+func parsePgVectorLiteral(literal string) ([]float32, error) {
+	var embedding []float32
+	if err := json.Unmarshal([]byte(literal), &embedding); err != nil {
+		return nil, err
+	}
+	return embedding, nil
+}
@@ -0,0 +1,64 @@
+package vectorstore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePgVectorType(t *testing.T) {
+	tests := []struct {
+		columnType string
+		dimension  int
+		wantErr    bool
+	}{
+		{columnType: "vector(1536)", dimension: 1536},
+		{columnType: "vector(3)", dimension: 3},
+		{columnType: "vector", wantErr: true},
+		{columnType: "vector()", wantErr: true},
+		{columnType: "vector(0)", wantErr: true},
+		{columnType: "halfvec(1536)", wantErr: true},
+		{columnType: "bytea", wantErr: true},
+		{columnType: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.columnType, func(t *testing.T) {
+			dimension, err := parsePgVectorType(tt.columnType)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.dimension, dimension)
+		})
+	}
+}
+
+func TestPgVectorCheckDimension(t *testing.T) {
+	store := &PgVectorStore{logger: testLogger()}
+	assert.NoError(t, store.checkDimension(8), "any dimension fits before the table exists")
+
+	store.ready = true
+	store.dimension = 4
+	assert.NoError(t, store.checkDimension(4))
+	assert.ErrorIs(t, store.checkDimension(8), ErrDimensionMismatch)
+}
+
+func TestPgVectorLiteral(t *testing.T) {
+	assert.Equal(t, "[1,-0.5,0.25]", pgVectorLiteral([]float32{1, -0.5, 0.25}))
+	assert.Equal(t, "[]", pgVectorLiteral(nil))
+}
+
+func TestParsePgVectorLiteral(t *testing.T) {
+	embedding, err := parsePgVectorLiteral("[1,-0.5,0.25]")
+	require.NoError(t, err)
+	assert.Equal(t, []float32{1, -0.5, 0.25}, embedding)
+
+	roundTrip, err := parsePgVectorLiteral(pgVectorLiteral([]float32{0.1, 2e-7}))
+	require.NoError(t, err)
+	assert.Equal(t, []float32{0.1, 2e-7}, roundTrip)
+
+	_, err = parsePgVectorLiteral("vector")
+	assert.Error(t, err)
+}
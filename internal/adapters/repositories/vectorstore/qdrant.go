@@ -0,0 +1,540 @@
+package vectorstore
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/robstave/gorag/internal/domain/types"
+)
+
+// qdrantPointNamespace derives Qdrant point IDs, which must be UUIDs or
+// integers, from chunk IDs
+var qdrantPointNamespace = uuid.MustParse("6f1c2a52-8d4e-4b7a-9a57-1d0c3e7b9f21")
+
+// qdrantScrollPageSize is the number of points fetched per page when listing a collection
+const qdrantScrollPageSize = 500
+
+// QdrantConfig configures a QdrantClient
+type QdrantConfig struct {
+	// URL is the base URL of Qdrant's REST API
+	URL string
+	// Collection is the collection name
+	Collection string
+	// APIKey is sent in the api-key header when set
+	APIKey string
+	// Metric is the distance metric: cosine, l2 or ip (default cosine)
+	Metric string
+	// Dimension is the vector size. When 0 the collection is created from the
+	// first batch of embeddings written.
+	Dimension int
+}
+
+// QdrantClient is a VectorStore backed by Qdrant's REST API. Chunks are
+// stored as points whose payload holds the chunk text and metadata.
+type QdrantClient struct {
+	baseURL string
+	config  QdrantConfig
+	client  *http.Client
+	logger  *slog.Logger
+
+	mu     sync.Mutex
+	exists bool
+}
+
+// NewQdrantClient creates a Qdrant client, creating the collection if it does
+// not exist and the dimension is known. An existing collection must have been
+// created with the configured metric and dimension.
+func NewQdrantClient(config QdrantConfig, logger *slog.Logger) (*QdrantClient, error) {
+	if config.Metric == "" {
+		config.Metric = MetricCosine
+	}
+	if _, err := qdrantDistance(config.Metric); err != nil {
+		return nil, err
+	}
+
+	q := &QdrantClient{
+		baseURL: strings.TrimSuffix(config.URL, "/"),
+		config:  config,
+		client: &http.Client{
+			Timeout: time.Second * 30,
+		},
+		logger: logger,
+	}
+
+	vectors, err := q.collectionVectors()
+	if err != nil {
+		return nil, err
+	}
+	if vectors != nil {
+		if err := q.checkCollection(*vectors); err != nil {
+			return nil, err
+		}
+		q.exists = true
+	} else if config.Dimension > 0 {
+		if err := q.ensureCollection(config.Dimension); err != nil {
+			return nil, err
+		}
+	}
+
+	return q, nil
+}
+
+// collectionURL returns the URL of a path under the collection
+func (q *QdrantClient) collectionURL(path string) string {
+	return fmt.Sprintf("%s/collections/%s%s", q.baseURL, url.PathEscape(q.config.Collection), path)
+}
+
+// do sends a request with an optional JSON body and decodes the "result"
+// field of the response into out when it is not nil
+func (q *QdrantClient) do(method string, url string, reqBody interface{}, out interface{}) (int, error) {
+	var body io.Reader
+	if reqBody != nil {
+		jsonData, err := json.Marshal(reqBody)
+		if err != nil {
+			q.logger.Error("Failed to marshal Qdrant request", "error", err)
+			return 0, err
+		}
+		body = bytes.NewReader(jsonData)
+	}
+
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return 0, err
+	}
+	if reqBody != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if q.config.APIKey != "" {
+		req.Header.Set("api-key", q.config.APIKey)
+	}
+
+	resp, err := q.client.Do(req)
+	if err != nil {
+		q.logger.Error("Qdrant request failed", "error", err)
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != http.StatusNotFound {
+			q.logger.Error("Qdrant API error", "status", resp.Status, "body", string(respBody))
+		}
+		return resp.StatusCode, fmt.Errorf("qdrant API error: %s", resp.Status)
+	}
+
+	if out != nil {
+		envelope := struct {
+			Result interface{} `json:"result"`
+		}{Result: out}
+		if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+			q.logger.Error("Failed to decode Qdrant response", "error", err)
+			return resp.StatusCode, err
+		}
+	}
+	return resp.StatusCode, nil
+}
+
+// qdrantVectorParams describes the vectors of a collection
+type qdrantVectorParams struct {
+	Size     int    `json:"size"`
+	Distance string `json:"distance"`
+}
+
+// collectionVectors returns the vector parameters of the collection, or nil
+// if it has not been created
+func (q *QdrantClient) collectionVectors() (*qdrantVectorParams, error) {
+	var info struct {
+		Config struct {
+			Params struct {
+				Vectors json.RawMessage `json:"vectors"`
+			} `json:"params"`
+		} `json:"config"`
+	}
+	status, err := q.do(http.MethodGet, q.collectionURL(""), nil, &info)
+	if status == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Named vectors are a map of names to parameters, so they have no size
+	var vectors qdrantVectorParams
+	if err := json.Unmarshal(info.Config.Params.Vectors, &vectors); err != nil || vectors.Size == 0 {
+		return nil, fmt.Errorf("qdrant collection %s does not have a single unnamed vector", q.config.Collection)
+	}
+	return &vectors, nil
+}
+
+// checkCollection reports an error if an existing collection was created
+// with a different distance or, when one is configured, vector size
+func (q *QdrantClient) checkCollection(vectors qdrantVectorParams) error {
+	distance, _ := qdrantDistance(q.config.Metric)
+	if vectors.Distance != distance {
+		return fmt.Errorf("qdrant collection %s uses %s distance, metric %s needs %s", q.config.Collection, vectors.Distance, q.config.Metric, distance)
+	}
+	if q.config.Dimension > 0 && vectors.Size != q.config.Dimension {
+		return fmt.Errorf("%w: qdrant collection %s has %d dimensions, configured dimension is %d", ErrDimensionMismatch, q.config.Collection, vectors.Size, q.config.Dimension)
+	}
+	return nil
+}
+
+// ensureCollection creates the collection with the given vector size unless
+// it already exists, along with a payload index on document_id for filtered
+// deletes
+func (q *QdrantClient) ensureCollection(dimension int) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.exists {
+		return nil
+	}
+
+	distance, _ := qdrantDistance(q.config.Metric)
+	reqBody := map[string]interface{}{
+		"vectors": map[string]interface{}{
+			"size":     dimension,
+			"distance": distance,
+		},
+	}
+	if _, err := q.do(http.MethodPut, q.collectionURL(""), reqBody, nil); err != nil {
+		return fmt.Errorf("failed to create collection: %w", err)
+	}
+
+	indexBody := map[string]interface{}{
+		"field_name":   "document_id",
+		"field_schema": "keyword",
+	}
+	if _, err := q.do(http.MethodPut, q.collectionURL("/index?wait=true"), indexBody, nil); err != nil {
+		return fmt.Errorf("failed to create payload index: %w", err)
+	}
+
+	q.logger.Info("Created Qdrant collection", "collection", q.config.Collection, "dimension", dimension, "distance", distance)
+	q.exists = true
+	return nil
+}
+
+// collectionCreated reports whether the collection exists without creating it
+func (q *QdrantClient) collectionCreated() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.exists
+}
+
+type qdrantPoint struct {
+	ID      string                 `json:"id"`
+	Vector  []float32              `json:"vector,omitempty"`
+	Payload map[string]interface{} `json:"payload"`
+}
+
+type qdrantScoredPoint struct {
+	ID      string                 `json:"id"`
+	Score   float64                `json:"score"`
+	Payload map[string]interface{} `json:"payload"`
+}
+
+// AddChunks upserts chunks as points, replacing any existing points with the same IDs
+func (q *QdrantClient) AddChunks(chunks []types.Chunk, embeddings [][]float32) error {
+	if len(chunks) != len(embeddings) {
+		return fmt.Errorf("got %d chunks but %d embeddings", len(chunks), len(embeddings))
+	}
+	if len(chunks) == 0 {
+		return nil
+	}
+
+	if err := q.ensureCollection(len(embeddings[0])); err != nil {
+		return err
+	}
+
+	points := make([]qdrantPoint, len(chunks))
+	for i, chunk := range chunks {
+		payload := chunkMetadata(chunk)
+		payload["chunk_id"] = chunk.ID
+		payload["text"] = chunk.Text
+		points[i] = qdrantPoint{
+			ID:      qdrantPointID(chunk.ID),
+			Vector:  embeddings[i],
+			Payload: payload,
+		}
+	}
+
+	reqBody := map[string]interface{}{"points": points}
+	if _, err := q.do(http.MethodPut, q.collectionURL("/points?wait=true"), reqBody, nil); err != nil {
+		return fmt.Errorf("failed to upsert points: %w", err)
+	}
+	return nil
+}
+
+// QueryDocuments returns the limit chunks closest to the query embedding
+// whose payload matches filter. Filtering happens in Qdrant during the search.
+func (q *QdrantClient) QueryDocuments(query string, embedding []float32, limit int, filter *types.Filter) ([]types.SearchResult, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+	if !q.collectionCreated() {
+		// Nothing has been written yet
+		return []types.SearchResult{}, nil
+	}
+
+	reqBody := map[string]interface{}{
+		"vector":       embedding,
+		"limit":        limit,
+		"with_payload": true,
+	}
+	if filter != nil {
+		reqBody["filter"] = map[string]interface{}{"must": []interface{}{qdrantFilter(*filter)}}
+	}
+
+	var points []qdrantScoredPoint
+	if _, err := q.do(http.MethodPost, q.collectionURL("/points/search"), reqBody, &points); err != nil {
+		return nil, fmt.Errorf("failed to search points: %w", err)
+	}
+
+	results := make([]types.SearchResult, len(points))
+	for i, point := range points {
+		chunk := qdrantPayloadChunk(point.ID, point.Payload)
+		results[i] = scoredResult(chunk, q.config.Metric, q.scoreToDistance(point.Score))
+	}
+	return results, nil
+}
+
+// scoreToDistance converts a Qdrant score to the distance the other stores
+// report: Qdrant returns similarities for cosine and dot and the plain
+// Euclidean distance for l2
+func (q *QdrantClient) scoreToDistance(score float64) float64 {
+	switch q.config.Metric {
+	case MetricL2:
+		return score * score
+	default:
+		return 1 - score
+	}
+}
+
+// DeleteDocument removes every chunk of a document
+func (q *QdrantClient) DeleteDocument(documentID string) error {
+	if !q.collectionCreated() {
+		return nil
+	}
+
+	reqBody := map[string]interface{}{
+		"filter": map[string]interface{}{
+			"should": []interface{}{
+				qdrantMatch("document_id", documentID),
+				qdrantMatch("chunk_id", documentID),
+			},
+		},
+	}
+	if _, err := q.do(http.MethodPost, q.collectionURL("/points/delete?wait=true"), reqBody, nil); err != nil {
+		return fmt.Errorf("failed to delete document: %w", err)
+	}
+	return nil
+}
+
+// DeleteStaleChunks removes the chunks of a document whose IDs are not in keep
+func (q *QdrantClient) DeleteStaleChunks(documentID string, keep []string) error {
+	if !q.collectionCreated() {
+		return nil
+	}
+
+	pointIDs := make([]string, len(keep))
+	for i, id := range keep {
+		pointIDs[i] = qdrantPointID(id)
+	}
+	filter := map[string]interface{}{
+		"should": []interface{}{
+			qdrantMatch("document_id", documentID),
+			qdrantMatch("chunk_id", documentID),
+		},
+	}
+	if len(pointIDs) > 0 {
+		filter["must_not"] = []interface{}{map[string]interface{}{"has_id": pointIDs}}
+	}
+
+	reqBody := map[string]interface{}{"filter": filter}
+	if _, err := q.do(http.MethodPost, q.collectionURL("/points/delete?wait=true"), reqBody, nil); err != nil {
+		return fmt.Errorf("failed to delete stale chunks: %w", err)
+	}
+	return nil
+}
+
+// ListDocuments returns the ID, parent document and content hash of every point
+func (q *QdrantClient) ListDocuments() ([]types.IndexedDocument, error) {
+	if !q.collectionCreated() {
+		return nil, nil
+	}
+
+	var indexed []types.IndexedDocument
+	var offset interface{}
+	for {
+		reqBody := map[string]interface{}{
+			"limit":        qdrantScrollPageSize,
+			"with_payload": []string{"chunk_id", "document_id", "content_hash"},
+			"with_vector":  false,
+		}
+		if offset != nil {
+			reqBody["offset"] = offset
+		}
+
+		var page struct {
+			Points         []qdrantPoint `json:"points"`
+			NextPageOffset interface{}   `json:"next_page_offset"`
+		}
+		if _, err := q.do(http.MethodPost, q.collectionURL("/points/scroll"), reqBody, &page); err != nil {
+			return nil, fmt.Errorf("failed to list documents: %w", err)
+		}
+
+		for _, point := range page.Points {
+			chunk := qdrantPayloadChunk(point.ID, point.Payload)
+			indexed = append(indexed, types.IndexedDocument{
+				ID:          chunk.ID,
+				DocumentID:  chunk.DocumentID,
+				ContentHash: chunk.ContentHash,
+			})
+		}
+
+		if page.NextPageOffset == nil {
+			break
+		}
+		offset = page.NextPageOffset
+	}
+	return indexed, nil
+}
+
+// GetEmbeddings returns the stored embeddings of the given chunks
+func (q *QdrantClient) GetEmbeddings(ids []string) (map[string][]float32, error) {
+	embeddings := make(map[string][]float32, len(ids))
+	if len(ids) == 0 || !q.collectionCreated() {
+		return embeddings, nil
+	}
+
+	pointIDs := make([]string, len(ids))
+	for i, id := range ids {
+		pointIDs[i] = qdrantPointID(id)
+	}
+	reqBody := map[string]interface{}{
+		"ids":          pointIDs,
+		"with_payload": []string{"chunk_id"},
+		"with_vector":  true,
+	}
+
+	var points []qdrantPoint
+	if _, err := q.do(http.MethodPost, q.collectionURL("/points"), reqBody, &points); err != nil {
+		return nil, fmt.Errorf("failed to get points: %w", err)
+	}
+	for _, point := range points {
+		chunk := qdrantPayloadChunk(point.ID, point.Payload)
+		embeddings[chunk.ID] = point.Vector
+	}
+	return embeddings, nil
+}
+
+// qdrantPointID returns the UUID point ID for a chunk ID
+func qdrantPointID(chunkID string) string {
+	return uuid.NewSHA1(qdrantPointNamespace, []byte(chunkID)).String()
+}
+
+// qdrantPayloadChunk builds a chunk from a point payload
+func qdrantPayloadChunk(pointID string, payload map[string]interface{}) types.Chunk {
+	chunk := types.Chunk{ID: pointID, DocumentID: pointID}
+	if chunkID, ok := payload["chunk_id"].(string); ok {
+		chunk.ID = chunkID
+		chunk.DocumentID = chunkID
+	}
+	if text, ok := payload["text"].(string); ok {
+		chunk.Text = text
+	}
+	applyChunkMetadata(&chunk, payload)
+	return chunk
+}
+
+// qdrantFilter translates a filter into a Qdrant condition. Negations are
+// nested filters that also exclude points without the field.
+func qdrantFilter(filter types.Filter) map[string]interface{} {
+	switch filter.Op {
+	case types.FilterAnd, types.FilterOr:
+		conditions := make([]interface{}, len(filter.Filters))
+		for i, child := range filter.Filters {
+			conditions[i] = qdrantFilter(child)
+		}
+		key := "must"
+		if filter.Op == types.FilterOr {
+			key = "should"
+		}
+		return map[string]interface{}{key: conditions}
+	case types.FilterEq, types.FilterContains:
+		return qdrantEqual(filter.Field, filter.Value)
+	case types.FilterNe:
+		return qdrantExclude(filter.Field, []interface{}{filter.Value})
+	case types.FilterIn:
+		conditions := make([]interface{}, len(filter.Values))
+		for i, value := range filter.Values {
+			conditions[i] = qdrantEqual(filter.Field, value)
+		}
+		return map[string]interface{}{"should": conditions}
+	case types.FilterNin:
+		return qdrantExclude(filter.Field, filter.Values)
+	default:
+		return map[string]interface{}{
+			"key":   filter.Field,
+			"range": map[string]interface{}{filter.Op: filter.Value},
+		}
+	}
+}
+
+// qdrantEqual returns a condition matching a payload key exactly. Match only
+// supports keywords, integers and booleans, so numbers use a closed range.
+func qdrantEqual(key string, value interface{}) map[string]interface{} {
+	if number, ok := value.(float64); ok {
+		return map[string]interface{}{
+			"key":   key,
+			"range": map[string]interface{}{"gte": number, "lte": number},
+		}
+	}
+	return map[string]interface{}{
+		"key":   key,
+		"match": map[string]interface{}{"value": value},
+	}
+}
+
+// qdrantExclude returns a filter matching points that have key set to none of values
+func qdrantExclude(key string, values []interface{}) map[string]interface{} {
+	conditions := []interface{}{
+		map[string]interface{}{"is_empty": map[string]interface{}{"key": key}},
+	}
+	for _, value := range values {
+		conditions = append(conditions, qdrantEqual(key, value))
+	}
+	return map[string]interface{}{"must_not": conditions}
+}
+
+// qdrantMatch returns a condition matching a payload key exactly
+func qdrantMatch(key, value string) map[string]interface{} {
+	return map[string]interface{}{
+		"key":   key,
+		"match": map[string]interface{}{"value": value},
+	}
+}
+
+// qdrantDistance maps a metric name to Qdrant's distance name
+func qdrantDistance(metric string) (string, error) {
+	switch metric {
+	case "", MetricCosine:
+		return "Cosine", nil
+	case MetricL2:
+		return "Euclid", nil
+	case MetricIP:
+		return "Dot", nil
+	default:
+		return "", fmt.Errorf("unknown distance metric: %s", metric)
+	}
+}
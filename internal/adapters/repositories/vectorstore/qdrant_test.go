@@ -0,0 +1,357 @@
+package vectorstore
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/robstave/gorag/internal/domain/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeQdrant is an httptest server implementing the parts of the Qdrant REST
+// API that QdrantClient uses, for a single collection named "test"
+type fakeQdrant struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	vectors  interface{} // collection vector params, nil until created
+	indexes  []string
+	points   map[string]qdrantPoint
+	searches []map[string]interface{}
+	deletes  []map[string]interface{}
+	pageSize int
+	apiKeys  []string
+}
+
+func newFakeQdrant(t *testing.T) *fakeQdrant {
+	t.Helper()
+	fake := &fakeQdrant{points: make(map[string]qdrantPoint), pageSize: 3}
+	fake.Server = httptest.NewServer(http.HandlerFunc(fake.handle))
+	t.Cleanup(fake.Close)
+	return fake
+}
+
+func (f *fakeQdrant) handle(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.apiKeys = append(f.apiKeys, r.Header.Get("api-key"))
+	path, ok := strings.CutPrefix(r.URL.Path, "/collections/test")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	var body map[string]interface{}
+	if r.Body != nil && r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	route := r.Method + " " + path
+	if f.vectors == nil && route != "GET " && route != "PUT " {
+		http.NotFound(w, r)
+		return
+	}
+
+	var result interface{} = true
+	switch route {
+	case "GET ":
+		if f.vectors == nil {
+			http.NotFound(w, r)
+			return
+		}
+		result = map[string]interface{}{
+			"config": map[string]interface{}{"params": map[string]interface{}{"vectors": f.vectors}},
+		}
+	case "PUT ":
+		f.vectors = body["vectors"]
+	case "PUT /index":
+		f.indexes = append(f.indexes, body["field_name"].(string))
+	case "PUT /points":
+		var request struct {
+			Points []qdrantPoint `json:"points"`
+		}
+		data, _ := json.Marshal(body)
+		if err := json.Unmarshal(data, &request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		for _, point := range request.Points {
+			f.points[point.ID] = point
+		}
+	case "POST /points/search":
+		f.searches = append(f.searches, body)
+		// Score every point by cosine similarity; filters are not evaluated
+		var query []float32
+		for _, v := range body["vector"].([]interface{}) {
+			query = append(query, float32(v.(float64)))
+		}
+		scored := make([]qdrantScoredPoint, 0, len(f.points))
+		for _, point := range f.points {
+			scored = append(scored, qdrantScoredPoint{ID: point.ID, Score: 1 - cosineDistance(query, point.Vector), Payload: point.Payload})
+		}
+		sort.Slice(scored, func(i, j int) bool { return scored[i].Score > scored[j].Score })
+		if limit := int(body["limit"].(float64)); len(scored) > limit {
+			scored = scored[:limit]
+		}
+		result = scored
+	case "POST /points":
+		points := []qdrantPoint{}
+		for _, id := range body["ids"].([]interface{}) {
+			if point, ok := f.points[id.(string)]; ok {
+				points = append(points, point)
+			}
+		}
+		result = points
+	case "POST /points/delete":
+		f.deletes = append(f.deletes, body)
+	case "POST /points/scroll":
+		ids := make([]string, 0, len(f.points))
+		for id := range f.points {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+
+		start := 0
+		if offset, ok := body["offset"].(string); ok {
+			start = sort.SearchStrings(ids, offset)
+		}
+		end := min(start+f.pageSize, len(ids))
+		page := map[string]interface{}{"next_page_offset": nil}
+		points := make([]qdrantPoint, 0, end-start)
+		for _, id := range ids[start:end] {
+			points = append(points, qdrantPoint{ID: id, Payload: f.points[id].Payload})
+		}
+		page["points"] = points
+		if end < len(ids) {
+			page["next_page_offset"] = ids[end]
+		}
+		result = page
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"result": result, "status": "ok"})
+}
+
+func newTestQdrantClient(t *testing.T, server *fakeQdrant, config QdrantConfig) *QdrantClient {
+	t.Helper()
+	config.URL = server.URL
+	config.Collection = "test"
+	client, err := NewQdrantClient(config, testLogger())
+	require.NoError(t, err)
+	return client
+}
+
+func TestQdrantClientCreatesCollection(t *testing.T) {
+	t.Run("on startup with a dimension", func(t *testing.T) {
+		server := newFakeQdrant(t)
+		newTestQdrantClient(t, server, QdrantConfig{Dimension: 4, Metric: MetricIP, APIKey: "secret"})
+
+		assert.Equal(t, map[string]interface{}{"size": float64(4), "distance": "Dot"}, server.vectors)
+		assert.Equal(t, []string{"document_id"}, server.indexes)
+		for _, key := range server.apiKeys {
+			assert.Equal(t, "secret", key)
+		}
+	})
+
+	t.Run("on the first write without a dimension", func(t *testing.T) {
+		server := newFakeQdrant(t)
+		client := newTestQdrantClient(t, server, QdrantConfig{})
+		assert.Nil(t, server.vectors)
+
+		results, err := client.QueryDocuments("", []float32{1, 0}, 5, nil)
+		require.NoError(t, err)
+		assert.Empty(t, results)
+
+		require.NoError(t, client.AddChunks(testChunks(1), [][]float32{{1, 0}}))
+		assert.Equal(t, map[string]interface{}{"size": float64(2), "distance": "Cosine"}, server.vectors)
+	})
+
+	t.Run("existing collection is checked", func(t *testing.T) {
+		server := newFakeQdrant(t)
+		server.vectors = map[string]interface{}{"size": 4, "distance": "Euclid"}
+
+		client := newTestQdrantClient(t, server, QdrantConfig{Dimension: 4, Metric: MetricL2})
+		assert.True(t, client.collectionCreated())
+		assert.Empty(t, server.indexes)
+
+		_, err := NewQdrantClient(QdrantConfig{URL: server.URL, Collection: "test", Dimension: 8, Metric: MetricL2}, testLogger())
+		assert.ErrorIs(t, err, ErrDimensionMismatch)
+		_, err = NewQdrantClient(QdrantConfig{URL: server.URL, Collection: "test", Dimension: 4}, testLogger())
+		assert.ErrorContains(t, err, "Euclid")
+
+		server.vectors = map[string]interface{}{"dense": map[string]interface{}{"size": 4, "distance": "Cosine"}}
+		_, err = NewQdrantClient(QdrantConfig{URL: server.URL, Collection: "test"}, testLogger())
+		assert.ErrorContains(t, err, "unnamed vector")
+	})
+}
+
+func TestQdrantClientUpsertAndSearch(t *testing.T) {
+	server := newFakeQdrant(t)
+	client := newTestQdrantClient(t, server, QdrantConfig{Dimension: 8})
+
+	chunks := testChunks(12)
+	vectors := randomVectors(len(chunks), 8)
+	require.NoError(t, client.AddChunks(chunks, vectors))
+	require.Len(t, server.points, len(chunks))
+
+	point := server.points[qdrantPointID(chunks[4].ID)]
+	assert.Equal(t, chunks[4].ID, point.Payload["chunk_id"])
+	assert.Equal(t, chunks[4].Text, point.Payload["text"])
+	assert.Equal(t, chunks[4].DocumentID, point.Payload["document_id"])
+
+	filter, err := types.ParseFilter([]byte(`{"group": 1}`))
+	require.NoError(t, err)
+	results, err := client.QueryDocuments("", vectors[4], 3, filter)
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	assert.Equal(t, chunks[4].ID, results[0].Chunk.ID)
+	assert.Equal(t, chunks[4].DocumentID, results[0].Chunk.DocumentID)
+	assert.Equal(t, chunks[4].Text, results[0].Chunk.Text)
+	assert.InDelta(t, 1, results[0].Score, 1e-6)
+
+	search := server.searches[0]
+	assert.Equal(t, float64(3), search["limit"])
+	assert.Equal(t, map[string]interface{}{
+		"must": []interface{}{
+			map[string]interface{}{"key": "group", "range": map[string]interface{}{"gte": float64(1), "lte": float64(1)}},
+		},
+	}, search["filter"])
+}
+
+func TestQdrantClientGetEmbeddings(t *testing.T) {
+	server := newFakeQdrant(t)
+	client := newTestQdrantClient(t, server, QdrantConfig{Dimension: 8})
+	chunks := testChunks(10)
+	vectors := randomVectors(len(chunks), 8)
+	require.NoError(t, client.AddChunks(chunks, vectors))
+	assertStoredEmbeddings(t, client, chunks, vectors)
+}
+
+func TestQdrantClientListDocumentsPages(t *testing.T) {
+	server := newFakeQdrant(t)
+	client := newTestQdrantClient(t, server, QdrantConfig{Dimension: 4})
+
+	chunks := testChunks(11)
+	require.NoError(t, client.AddChunks(chunks, randomVectors(len(chunks), 4)))
+
+	indexed, err := client.ListDocuments()
+	require.NoError(t, err)
+	require.Len(t, indexed, len(chunks))
+
+	ids := make([]string, len(indexed))
+	for i, entry := range indexed {
+		ids[i] = entry.ID
+		assert.Equal(t, strings.Split(entry.ID, ":")[0], entry.DocumentID)
+	}
+	sort.Strings(ids)
+	want := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		want[i] = chunk.ID
+	}
+	sort.Strings(want)
+	assert.Equal(t, want, ids)
+}
+
+func TestQdrantClientDeletes(t *testing.T) {
+	server := newFakeQdrant(t)
+	client := newTestQdrantClient(t, server, QdrantConfig{Dimension: 4})
+
+	require.NoError(t, client.DeleteDocument("doc0"))
+	require.NoError(t, client.DeleteStaleChunks("doc1", []string{"doc1:0"}))
+	require.NoError(t, client.DeleteStaleChunks("doc2", nil))
+	require.Len(t, server.deletes, 3)
+
+	byDocument := func(documentID string) []interface{} {
+		return []interface{}{
+			map[string]interface{}{"key": "document_id", "match": map[string]interface{}{"value": documentID}},
+			map[string]interface{}{"key": "chunk_id", "match": map[string]interface{}{"value": documentID}},
+		}
+	}
+	assert.Equal(t, map[string]interface{}{"should": byDocument("doc0")}, server.deletes[0]["filter"])
+	assert.Equal(t, map[string]interface{}{
+		"should":   byDocument("doc1"),
+		"must_not": []interface{}{map[string]interface{}{"has_id": []interface{}{qdrantPointID("doc1:0")}}},
+	}, server.deletes[1]["filter"])
+	assert.Equal(t, map[string]interface{}{"should": byDocument("doc2")}, server.deletes[2]["filter"])
+}
+
+func TestQdrantFilter(t *testing.T) {
+	tests := []struct {
+		filter string
+		want   string
+	}{
+		{
+			filter: `{"lang": "go"}`,
+			want:   `{"key":"lang","match":{"value":"go"}}`,
+		},
+		{
+			filter: `{"year": 2020}`,
+			want:   `{"key":"year","range":{"gte":2020,"lte":2020}}`,
+		},
+		{
+			filter: `{"year": {"$gte": 2020, "$lt": 2024}}`,
+			want:   `{"must":[{"key":"year","range":{"gte":2020}},{"key":"year","range":{"lt":2024}}]}`,
+		},
+		{
+			filter: `{"lang": {"$ne": "go"}}`,
+			want:   `{"must_not":[{"is_empty":{"key":"lang"}},{"key":"lang","match":{"value":"go"}}]}`,
+		},
+		{
+			filter: `{"lang": {"$in": ["go", "rust"]}}`,
+			want:   `{"should":[{"key":"lang","match":{"value":"go"}},{"key":"lang","match":{"value":"rust"}}]}`,
+		},
+		{
+			filter: `{"lang": {"$nin": ["go", "rust"]}}`,
+			want:   `{"must_not":[{"is_empty":{"key":"lang"}},{"key":"lang","match":{"value":"go"}},{"key":"lang","match":{"value":"rust"}}]}`,
+		},
+		{
+			filter: `{"$or": [{"draft": true}, {"tags": {"$contains": "cli"}}]}`,
+			want:   `{"should":[{"key":"draft","match":{"value":true}},{"key":"tags","match":{"value":"cli"}}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			filter, err := types.ParseFilter([]byte(tt.filter))
+			require.NoError(t, err)
+			got, err := json.Marshal(qdrantFilter(*filter))
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestQdrantScoreToDistance(t *testing.T) {
+	tests := []struct {
+		metric string
+		score  float64
+		want   float64
+	}{
+		{metric: MetricCosine, score: 1, want: 0},
+		{metric: MetricCosine, score: 0.25, want: 0.75},
+		{metric: MetricIP, score: 2, want: -1},
+		{metric: MetricL2, score: 0, want: 0},
+		{metric: MetricL2, score: 3, want: 9},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.metric+"/"+strconv.FormatFloat(tt.score, 'f', -1, 64), func(t *testing.T) {
+			client := &QdrantClient{config: QdrantConfig{Metric: tt.metric}}
+			assert.InDelta(t, tt.want, client.scoreToDistance(tt.score), 1e-9)
+		})
+	}
+}
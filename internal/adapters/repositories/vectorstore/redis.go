@@ -0,0 +1,551 @@
+package vectorstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/robstave/gorag/internal/domain/types"
+)
+
+// Redis vector index algorithms
+const (
+	RedisAlgorithmHNSW = "HNSW"
+	RedisAlgorithmFlat = "FLAT"
+)
+
+// redisScanCount is the number of keys requested per SCAN and FT.SEARCH page
+const redisScanCount = 500
+
+// redisMaxSearchResults is the most results one FT.SEARCH may return under
+// the default MAXSEARCHRESULTS setting, which bounds the KNN window of
+// filtered queries
+const redisMaxSearchResults = 10000
+
+// redisScoreField is the alias FT.SEARCH returns the KNN distance under
+const redisScoreField = "vector_score"
+
+// RedisConfig configures a RedisStore
+type RedisConfig struct {
+	// URL is a redis:// or rediss:// connection URL
+	URL string
+	// Index is the RediSearch index name (default gorag)
+	Index string
+	// Prefix is the key prefix of indexed hashes (default "<index>:")
+	Prefix string
+	// Algorithm is the vector index algorithm: HNSW or FLAT (default HNSW)
+	Algorithm string
+	// Metric is the distance metric: cosine, l2 or ip (default cosine)
+	Metric string
+	// Dimension is the embedding dimension. When 0 the index is created from
+	// the first batch of embeddings written.
+	Dimension int
+	// M, EfConstruction and EfRuntime tune the HNSW index; 0 keeps the Redis defaults
+	M              int
+	EfConstruction int
+	EfRuntime      int
+}
+
+// RedisStore is a VectorStore backed by Redis with the RediSearch module.
+// Every chunk is a hash holding its text, chunk metadata and embedding, and
+// is indexed by a RediSearch vector field so KNN queries run on the server.
+type RedisStore struct {
+	client *redis.Client
+	config RedisConfig
+	logger *slog.Logger
+
+	mu      sync.Mutex
+	indexed bool
+}
+
+// NewRedisStore connects to Redis and creates the vector index if it does not
+// exist. The index can only be created once the dimension is known.
+func NewRedisStore(config RedisConfig, logger *slog.Logger) (*RedisStore, error) {
+	if config.Index == "" {
+		config.Index = "gorag"
+	}
+	if config.Prefix == "" {
+		config.Prefix = config.Index + ":"
+	}
+	config.Algorithm = strings.ToUpper(config.Algorithm)
+	if config.Algorithm == "" {
+		config.Algorithm = RedisAlgorithmHNSW
+	}
+	if config.Algorithm != RedisAlgorithmHNSW && config.Algorithm != RedisAlgorithmFlat {
+		return nil, fmt.Errorf("unknown redis vector algorithm: %s", config.Algorithm)
+	}
+	if config.Metric == "" {
+		config.Metric = MetricCosine
+	}
+	if _, err := redisMetric(config.Metric); err != nil {
+		return nil, err
+	}
+
+	options, err := redis.ParseURL(config.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redis URL: %w", err)
+	}
+	// RESP2 replies from the search commands are plain arrays
+	options.Protocol = 2
+
+	store := &RedisStore{
+		client: redis.NewClient(options),
+		config: config,
+		logger: logger,
+	}
+
+	ctx := context.Background()
+	if err := store.client.Ping(ctx).Err(); err != nil {
+		logger.Error("Failed to connect to Redis", "error", err)
+		return nil, err
+	}
+
+	exists, err := store.indexExists(ctx)
+	if err != nil {
+		return nil, err
+	}
+	store.indexed = exists
+	if !exists && config.Dimension > 0 {
+		if err := store.ensureIndex(ctx, config.Dimension); err != nil {
+			return nil, err
+		}
+	}
+
+	return store, nil
+}
+
+// indexExists reports whether the RediSearch index has been created
+func (r *RedisStore) indexExists(ctx context.Context) (bool, error) {
+	err := r.client.Do(ctx, "FT.INFO", r.config.Index).Err()
+	if err == nil {
+		return true, nil
+	}
+
+	msg := strings.ToLower(err.Error())
+	if strings.Contains(msg, "unknown index") || strings.Contains(msg, "no such index") {
+		return false, nil
+	}
+	r.logger.Error("Failed to read Redis index info", "index", r.config.Index, "error", err)
+	return false, err
+}
+
+// ensureIndex creates the RediSearch index for the given dimension unless it
+// already exists
+func (r *RedisStore) ensureIndex(ctx context.Context, dimension int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.indexed {
+		return nil
+	}
+
+	metric, _ := redisMetric(r.config.Metric)
+	vectorArgs := []interface{}{"TYPE", "FLOAT32", "DIM", dimension, "DISTANCE_METRIC", metric}
+	if r.config.Algorithm == RedisAlgorithmHNSW {
+		if r.config.M > 0 {
+			vectorArgs = append(vectorArgs, "M", r.config.M)
+		}
+		if r.config.EfConstruction > 0 {
+			vectorArgs = append(vectorArgs, "EF_CONSTRUCTION", r.config.EfConstruction)
+		}
+		if r.config.EfRuntime > 0 {
+			vectorArgs = append(vectorArgs, "EF_RUNTIME", r.config.EfRuntime)
+		}
+	}
+
+	args := []interface{}{
+		"FT.CREATE", r.config.Index,
+		"ON", "HASH",
+		"PREFIX", 1, r.config.Prefix,
+		"SCHEMA",
+		"document_id", "TAG",
+		"content_hash", "TAG",
+		"text", "TEXT",
+		"embedding", "VECTOR", r.config.Algorithm, len(vectorArgs),
+	}
+	args = append(args, vectorArgs...)
+
+	if err := r.client.Do(ctx, args...).Err(); err != nil {
+		if !strings.Contains(strings.ToLower(err.Error()), "index already exists") {
+			r.logger.Error("Failed to create Redis vector index", "index", r.config.Index, "error", err)
+			return err
+		}
+	} else {
+		r.logger.Info("Created Redis vector index", "index", r.config.Index, "algorithm", r.config.Algorithm, "dimension", dimension, "metric", metric)
+	}
+
+	r.indexed = true
+	return nil
+}
+
+// AddChunks writes each chunk as a hash, replacing any existing entries with the same IDs
+func (r *RedisStore) AddChunks(chunks []types.Chunk, embeddings [][]float32) error {
+	if len(chunks) != len(embeddings) {
+		return fmt.Errorf("got %d chunks but %d embeddings", len(chunks), len(embeddings))
+	}
+	if len(chunks) == 0 {
+		return nil
+	}
+
+	ctx := context.Background()
+	if err := r.ensureIndex(ctx, len(embeddings[0])); err != nil {
+		return err
+	}
+
+	pipe := r.client.TxPipeline()
+	for i, chunk := range chunks {
+		metadata, err := json.Marshal(chunkMetadata(chunk))
+		if err != nil {
+			return fmt.Errorf("failed to encode metadata for %s: %w", chunk.ID, err)
+		}
+
+		key := r.config.Prefix + chunk.ID
+		pipe.Del(ctx, key)
+		pipe.HSet(ctx, key,
+			"document_id", chunk.DocumentID,
+			"chunk_index", chunk.Index,
+			"start", chunk.Start,
+			"end", chunk.End,
+			"content_hash", chunk.ContentHash,
+			"text", chunk.Text,
+			"metadata", metadata,
+			"embedding", encodeEmbedding(embeddings[i]),
+		)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		r.logger.Error("Failed to write chunks to Redis", "error", err)
+		return err
+	}
+	return nil
+}
+
+// QueryDocuments runs a KNN query for the limit chunks closest to the query
+// embedding. Metadata is stored as an unindexed JSON field, so filters are
+// applied after the search: a filtered query widens the KNN window until
+// enough chunks match, up to redisMaxSearchResults neighbours. A selective
+// filter on a larger index can therefore return fewer than limit chunks.
+func (r *RedisStore) QueryDocuments(query string, embedding []float32, limit int, filter *types.Filter) ([]types.SearchResult, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+	limit = min(limit, redisMaxSearchResults)
+
+	r.mu.Lock()
+	indexed := r.indexed
+	r.mu.Unlock()
+	if !indexed {
+		// Nothing has been written yet
+		return []types.SearchResult{}, nil
+	}
+
+	k := limit
+	if filter != nil {
+		k = min(4*limit, redisMaxSearchResults)
+	}
+	for {
+		hits, err := r.knn(embedding, k)
+		if err != nil {
+			return nil, err
+		}
+
+		results := make([]types.SearchResult, 0, min(limit, len(hits)))
+		for _, hit := range hits {
+			if filter != nil {
+				var metadata map[string]interface{}
+				if err := json.Unmarshal([]byte(hit.fields["metadata"]), &metadata); err != nil || !filter.Match(metadata) {
+					continue
+				}
+			}
+
+			chunk := r.hashToChunk(hit.key, hit.fields)
+			distance, err := strconv.ParseFloat(hit.fields[redisScoreField], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid score for %s: %w", hit.key, err)
+			}
+			results = append(results, scoredResult(chunk, r.config.Metric, distance))
+			if len(results) == limit {
+				break
+			}
+		}
+
+		// Stop once enough chunks match or the index has no more to offer
+		if len(results) == limit || len(hits) < k || k == redisMaxSearchResults {
+			if len(results) < limit && len(hits) == k {
+				r.logger.Warn("Filtered Redis query returned fewer results than requested", "limit", limit, "results", len(results), "searched", k)
+			}
+			return results, nil
+		}
+		k = min(2*k, redisMaxSearchResults)
+	}
+}
+
+// knn returns the k hashes closest to embedding, nearest first
+func (r *RedisStore) knn(embedding []float32, k int) ([]redisHit, error) {
+	reply, err := r.client.Do(context.Background(),
+		"FT.SEARCH", r.config.Index,
+		fmt.Sprintf("*=>[KNN %d @embedding $vec AS %s]", k, redisScoreField),
+		"PARAMS", 2, "vec", encodeEmbedding(embedding),
+		"SORTBY", redisScoreField,
+		"RETURN", 8, "document_id", "chunk_index", "start", "end", "content_hash", "text", "metadata", redisScoreField,
+		"LIMIT", 0, k,
+		"DIALECT", 2,
+	).Slice()
+	if err != nil {
+		r.logger.Error("Failed to query Redis", "error", err)
+		return nil, err
+	}
+	return parseRedisSearch(reply)
+}
+
+// DeleteDocument removes every chunk of a document
+func (r *RedisStore) DeleteDocument(documentID string) error {
+	ctx := context.Background()
+
+	// Entries stored under the document ID itself
+	if err := r.client.Del(ctx, r.config.Prefix+documentID).Err(); err != nil {
+		r.logger.Error("Failed to delete from Redis", "documentID", documentID, "error", err)
+		return err
+	}
+
+	r.mu.Lock()
+	indexed := r.indexed
+	r.mu.Unlock()
+	if !indexed {
+		return nil
+	}
+
+	// Deleted hashes leave the index, so the first page is fetched until empty
+	query := fmt.Sprintf("@document_id:{%s}", escapeRedisTag(documentID))
+	for {
+		reply, err := r.client.Do(ctx,
+			"FT.SEARCH", r.config.Index, query,
+			"NOCONTENT",
+			"LIMIT", 0, redisScanCount,
+			"DIALECT", 2,
+		).Slice()
+		if err != nil {
+			r.logger.Error("Failed to find document chunks in Redis", "documentID", documentID, "error", err)
+			return err
+		}
+		if len(reply) < 2 {
+			return nil
+		}
+
+		keys := make([]string, 0, len(reply)-1)
+		for _, key := range reply[1:] {
+			if s, ok := key.(string); ok {
+				keys = append(keys, s)
+			}
+		}
+		deleted, err := r.client.Del(ctx, keys...).Result()
+		if err != nil {
+			r.logger.Error("Failed to delete from Redis", "documentID", documentID, "error", err)
+			return err
+		}
+		if deleted == 0 {
+			return nil
+		}
+	}
+}
+
+// DeleteStaleChunks removes the chunks of a document whose IDs are not in keep
+func (r *RedisStore) DeleteStaleChunks(documentID string, keep []string) error {
+	ctx := context.Background()
+	stale := []string{r.config.Prefix + documentID}
+
+	r.mu.Lock()
+	indexed := r.indexed
+	r.mu.Unlock()
+	if indexed {
+		kept := idSet(keep)
+		query := fmt.Sprintf("@document_id:{%s}", escapeRedisTag(documentID))
+		for offset := 0; ; offset += redisScanCount {
+			reply, err := r.client.Do(ctx,
+				"FT.SEARCH", r.config.Index, query,
+				"NOCONTENT",
+				"LIMIT", offset, redisScanCount,
+				"DIALECT", 2,
+			).Slice()
+			if err != nil {
+				r.logger.Error("Failed to find document chunks in Redis", "documentID", documentID, "error", err)
+				return err
+			}
+			if len(reply) < 2 {
+				break
+			}
+
+			for _, key := range reply[1:] {
+				if s, ok := key.(string); ok && !kept[strings.TrimPrefix(s, r.config.Prefix)] {
+					stale = append(stale, s)
+				}
+			}
+			if len(reply)-1 < redisScanCount {
+				break
+			}
+		}
+	}
+
+	if err := r.client.Del(ctx, stale...).Err(); err != nil {
+		r.logger.Error("Failed to delete from Redis", "documentID", documentID, "error", err)
+		return err
+	}
+	return nil
+}
+
+// ListDocuments returns the ID, parent document and content hash of every entry
+func (r *RedisStore) ListDocuments() ([]types.IndexedDocument, error) {
+	ctx := context.Background()
+
+	var indexed []types.IndexedDocument
+	var cursor uint64
+	for {
+		keys, next, err := r.client.Scan(ctx, cursor, r.config.Prefix+"*", redisScanCount).Result()
+		if err != nil {
+			r.logger.Error("Failed to scan Redis keys", "error", err)
+			return nil, err
+		}
+
+		pipe := r.client.Pipeline()
+		cmds := make([]*redis.SliceCmd, len(keys))
+		for i, key := range keys {
+			cmds[i] = pipe.HMGet(ctx, key, "document_id", "content_hash")
+		}
+		if len(keys) > 0 {
+			if _, err := pipe.Exec(ctx); err != nil {
+				r.logger.Error("Failed to read Redis entries", "error", err)
+				return nil, err
+			}
+		}
+
+		for i, key := range keys {
+			id := strings.TrimPrefix(key, r.config.Prefix)
+			entry := types.IndexedDocument{ID: id, DocumentID: id}
+			values := cmds[i].Val()
+			if documentID, ok := values[0].(string); ok && documentID != "" {
+				entry.DocumentID = documentID
+			}
+			if hash, ok := values[1].(string); ok {
+				entry.ContentHash = hash
+			}
+			indexed = append(indexed, entry)
+		}
+
+		cursor = next
+		if cursor == 0 {
+			break
+		}
+	}
+	return indexed, nil
+}
+
+// GetEmbeddings returns the stored embeddings of the given chunks
+func (r *RedisStore) GetEmbeddings(ids []string) (map[string][]float32, error) {
+	embeddings := make(map[string][]float32, len(ids))
+	if len(ids) == 0 {
+		return embeddings, nil
+	}
+
+	ctx := context.Background()
+	pipe := r.client.Pipeline()
+	cmds := make([]*redis.SliceCmd, len(ids))
+	for i, id := range ids {
+		cmds[i] = pipe.HMGet(ctx, r.config.Prefix+id, "embedding")
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		r.logger.Error("Failed to read Redis embeddings", "error", err)
+		return nil, err
+	}
+
+	for i, id := range ids {
+		if encoded, ok := cmds[i].Val()[0].(string); ok && encoded != "" {
+			embeddings[id] = decodeEmbedding([]byte(encoded))
+		}
+	}
+	return embeddings, nil
+}
+
+// hashToChunk builds a chunk from the fields of a stored hash
+func (r *RedisStore) hashToChunk(key string, fields map[string]string) types.Chunk {
+	id := strings.TrimPrefix(key, r.config.Prefix)
+	chunk := types.Chunk{
+		ID:          id,
+		DocumentID:  id,
+		Text:        fields["text"],
+		ContentHash: fields["content_hash"],
+	}
+	if documentID := fields["document_id"]; documentID != "" {
+		chunk.DocumentID = documentID
+	}
+	chunk.Index, _ = strconv.Atoi(fields["chunk_index"])
+	chunk.Start, _ = strconv.Atoi(fields["start"])
+	chunk.End, _ = strconv.Atoi(fields["end"])
+	return chunk
+}
+
+// redisHit is a single document in an FT.SEARCH reply
+type redisHit struct {
+	key    string
+	fields map[string]string
+}
+
+// parseRedisSearch parses a RESP2 FT.SEARCH reply: the total count followed
+// by each key and its flat list of field/value pairs
+func parseRedisSearch(reply []interface{}) ([]redisHit, error) {
+	if len(reply) == 0 {
+		return nil, errors.New("empty search reply from Redis")
+	}
+
+	var hits []redisHit
+	for i := 1; i+1 < len(reply); i += 2 {
+		key, ok := reply[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("unexpected key in search reply: %v", reply[i])
+		}
+		pairs, ok := reply[i+1].([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("unexpected fields in search reply for %s", key)
+		}
+
+		fields := make(map[string]string, len(pairs)/2)
+		for j := 0; j+1 < len(pairs); j += 2 {
+			name, _ := pairs[j].(string)
+			value, _ := pairs[j+1].(string)
+			fields[name] = value
+		}
+		hits = append(hits, redisHit{key: key, fields: fields})
+	}
+	return hits, nil
+}
+
+// redisMetric maps a metric name to its RediSearch DISTANCE_METRIC. RediSearch
+// distances match the in-process ones: 1 - cos, squared L2 and 1 - a·b.
+func redisMetric(metric string) (string, error) {
+	switch metric {
+	case "", MetricCosine:
+		return "COSINE", nil
+	case MetricL2:
+		return "L2", nil
+	case MetricIP:
+		return "IP", nil
+	default:
+		return "", fmt.Errorf("unknown distance metric: %s", metric)
+	}
+}
+
+// escapeRedisTag escapes punctuation and spaces in a TAG query value
+func escapeRedisTag(value string) string {
+	var b strings.Builder
+	for _, r := range value {
+		if !(r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r > 127) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,480 @@
+package vectorstore
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/robstave/gorag/internal/domain/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRedis is a RESP2 server implementing the commands RedisStore sends,
+// with a single RediSearch index that answers KNN and document_id queries
+type fakeRedis struct {
+	listener net.Listener
+
+	mu       sync.Mutex
+	hashes   map[string]map[string]string
+	index    []string // FT.CREATE arguments, nil until the index exists
+	searches []string // FT.SEARCH queries
+}
+
+// redisStatus is a simple string reply
+type redisStatus string
+
+// redisError is an error reply
+type redisError string
+
+var knnPattern = regexp.MustCompile(`^\*=>\[KNN (\d+) @embedding \$vec AS (\w+)\]$`)
+
+func newFakeRedis(t *testing.T) *fakeRedis {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := &fakeRedis{listener: listener, hashes: make(map[string]map[string]string)}
+	t.Cleanup(func() { listener.Close() })
+	go server.serve()
+	return server
+}
+
+func (f *fakeRedis) URL() string {
+	return "redis://" + f.listener.Addr().String()
+}
+
+func (f *fakeRedis) serve() {
+	for {
+		conn, err := f.listener.Accept()
+		if err != nil {
+			return
+		}
+		go f.handle(conn)
+	}
+}
+
+func (f *fakeRedis) handle(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	writer := bufio.NewWriter(conn)
+
+	var queued [][]string
+	inMulti := false
+	for {
+		args, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+
+		var reply interface{}
+		switch name := strings.ToUpper(args[0]); {
+		case name == "MULTI":
+			inMulti, queued = true, nil
+			reply = redisStatus("OK")
+		case name == "EXEC":
+			replies := make([]interface{}, len(queued))
+			for i, command := range queued {
+				replies[i] = f.execute(command)
+			}
+			inMulti = false
+			reply = replies
+		case inMulti:
+			queued = append(queued, args)
+			reply = redisStatus("QUEUED")
+		default:
+			reply = f.execute(args)
+		}
+
+		writeReply(writer, reply)
+		if err := writer.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func (f *fakeRedis) execute(args []string) interface{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch strings.ToUpper(args[0]) {
+	case "PING":
+		return redisStatus("PONG")
+	case "CLIENT":
+		return redisStatus("OK")
+	case "DEL":
+		var deleted int64
+		for _, key := range args[1:] {
+			if _, ok := f.hashes[key]; ok {
+				delete(f.hashes, key)
+				deleted++
+			}
+		}
+		return deleted
+	case "HSET":
+		hash := f.hashes[args[1]]
+		if hash == nil {
+			hash = make(map[string]string)
+			f.hashes[args[1]] = hash
+		}
+		for i := 2; i+1 < len(args); i += 2 {
+			hash[args[i]] = args[i+1]
+		}
+		return int64((len(args) - 2) / 2)
+	case "HMGET":
+		values := make([]interface{}, 0, len(args)-2)
+		for _, field := range args[2:] {
+			if value, ok := f.hashes[args[1]][field]; ok {
+				values = append(values, value)
+			} else {
+				values = append(values, nil)
+			}
+		}
+		return values
+	case "SCAN":
+		// Everything in one page
+		prefix := strings.TrimSuffix(args[3], "*")
+		keys := make([]interface{}, 0)
+		for _, key := range f.sortedKeys() {
+			if strings.HasPrefix(key, prefix) {
+				keys = append(keys, key)
+			}
+		}
+		return []interface{}{"0", keys}
+	case "FT.INFO":
+		if f.index == nil {
+			return redisError("Unknown index name")
+		}
+		return []interface{}{"index_name", args[1]}
+	case "FT.CREATE":
+		if f.index != nil {
+			return redisError("Index already exists")
+		}
+		f.index = args[1:]
+		return redisStatus("OK")
+	case "FT.SEARCH":
+		if f.index == nil {
+			return redisError("no such index")
+		}
+		f.searches = append(f.searches, args[2])
+		return f.search(args[2:])
+	default:
+		return redisError(fmt.Sprintf("ERR unknown command '%s'", args[0]))
+	}
+}
+
+// search answers a KNN query or a @document_id:{...} tag query
+func (f *fakeRedis) search(args []string) interface{} {
+	query := args[0]
+	options := make(map[string][]string)
+	for i := 1; i < len(args); i++ {
+		switch strings.ToUpper(args[i]) {
+		case "SORTBY", "DIALECT":
+			i++
+		case "LIMIT":
+			options["LIMIT"] = args[i+1 : i+3]
+			i += 2
+		case "PARAMS", "RETURN":
+			// Counted lists: PARAMS 2 vec <blob>, RETURN 2 text metadata
+			n, _ := strconv.Atoi(args[i+1])
+			options[strings.ToUpper(args[i])] = args[i+2 : i+2+n]
+			i += n + 1
+		case "NOCONTENT":
+			options["NOCONTENT"] = nil
+		}
+	}
+	offset, _ := strconv.Atoi(options["LIMIT"][0])
+	count, _ := strconv.Atoi(options["LIMIT"][1])
+
+	// FT.CREATE <index> ON HASH PREFIX 1 <prefix> ...
+	prefix := f.index[5]
+	type match struct {
+		key   string
+		score float64
+	}
+	var matches []match
+	if knn := knnPattern.FindStringSubmatch(query); knn != nil {
+		k, _ := strconv.Atoi(knn[1])
+		vector := decodeEmbedding([]byte(options["PARAMS"][1]))
+		for _, key := range f.sortedKeys() {
+			if strings.HasPrefix(key, prefix) {
+				stored := decodeEmbedding([]byte(f.hashes[key]["embedding"]))
+				matches = append(matches, match{key: key, score: cosineDistance(vector, stored)})
+			}
+		}
+		sort.SliceStable(matches, func(i, j int) bool { return matches[i].score < matches[j].score })
+		if len(matches) > k {
+			matches = matches[:k]
+		}
+	} else if tag, ok := strings.CutPrefix(query, "@document_id:{"); ok {
+		documentID := strings.ReplaceAll(strings.TrimSuffix(tag, "}"), `\`, "")
+		for _, key := range f.sortedKeys() {
+			if strings.HasPrefix(key, prefix) && f.hashes[key]["document_id"] == documentID {
+				matches = append(matches, match{key: key})
+			}
+		}
+	} else {
+		return redisError("Syntax error in query " + query)
+	}
+
+	reply := []interface{}{int64(len(matches))}
+	for i := offset; i < len(matches) && i < offset+count; i++ {
+		reply = append(reply, matches[i].key)
+		if _, ok := options["NOCONTENT"]; ok {
+			continue
+		}
+		var fields []interface{}
+		for _, name := range options["RETURN"] {
+			if name == redisScoreField {
+				fields = append(fields, name, strconv.FormatFloat(matches[i].score, 'f', -1, 64))
+			} else if value, ok := f.hashes[matches[i].key][name]; ok {
+				fields = append(fields, name, value)
+			}
+		}
+		reply = append(reply, fields)
+	}
+	return reply
+}
+
+func (f *fakeRedis) sortedKeys() []string {
+	keys := make([]string, 0, len(f.hashes))
+	for key := range f.hashes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// readCommand reads a command sent as an array of bulk strings
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "*") {
+		return nil, errors.New("expected an array")
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, n)
+	for i := range args {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func writeReply(writer *bufio.Writer, reply interface{}) {
+	switch v := reply.(type) {
+	case nil:
+		writer.WriteString("$-1\r\n")
+	case redisStatus:
+		fmt.Fprintf(writer, "+%s\r\n", v)
+	case redisError:
+		fmt.Fprintf(writer, "-%s\r\n", v)
+	case int64:
+		fmt.Fprintf(writer, ":%d\r\n", v)
+	case string:
+		fmt.Fprintf(writer, "$%d\r\n%s\r\n", len(v), v)
+	case []interface{}:
+		fmt.Fprintf(writer, "*%d\r\n", len(v))
+		for _, item := range v {
+			writeReply(writer, item)
+		}
+	}
+}
+
+func newTestRedisStore(t *testing.T, server *fakeRedis, config RedisConfig) *RedisStore {
+	t.Helper()
+	config.URL = server.URL()
+	store, err := NewRedisStore(config, testLogger())
+	require.NoError(t, err)
+	t.Cleanup(func() { store.client.Close() })
+	return store
+}
+
+func TestRedisStoreCreatesIndex(t *testing.T) {
+	t.Run("on startup with a dimension", func(t *testing.T) {
+		server := newFakeRedis(t)
+		newTestRedisStore(t, server, RedisConfig{Dimension: 3, Metric: MetricL2, M: 8, EfRuntime: 20})
+
+		assert.Equal(t, []string{
+			"gorag", "ON", "HASH", "PREFIX", "1", "gorag:", "SCHEMA",
+			"document_id", "TAG", "content_hash", "TAG", "text", "TEXT",
+			"embedding", "VECTOR", "HNSW", "10",
+			"TYPE", "FLOAT32", "DIM", "3", "DISTANCE_METRIC", "L2", "M", "8", "EF_RUNTIME", "20",
+		}, server.index)
+	})
+
+	t.Run("on the first write without a dimension", func(t *testing.T) {
+		server := newFakeRedis(t)
+		store := newTestRedisStore(t, server, RedisConfig{Index: "chunks", Algorithm: "flat"})
+		assert.Nil(t, server.index)
+
+		results, err := store.QueryDocuments("", []float32{1, 0}, 5, nil)
+		require.NoError(t, err)
+		assert.Empty(t, results)
+
+		require.NoError(t, store.AddChunks(testChunks(1), [][]float32{{1, 0}}))
+		assert.Equal(t, []string{
+			"chunks", "ON", "HASH", "PREFIX", "1", "chunks:", "SCHEMA",
+			"document_id", "TAG", "content_hash", "TAG", "text", "TEXT",
+			"embedding", "VECTOR", "FLAT", "6",
+			"TYPE", "FLOAT32", "DIM", "2", "DISTANCE_METRIC", "COSINE",
+		}, server.index)
+	})
+
+	t.Run("existing index is reused", func(t *testing.T) {
+		server := newFakeRedis(t)
+		server.index = []string{"gorag"}
+		store := newTestRedisStore(t, server, RedisConfig{Dimension: 3})
+		assert.True(t, store.indexed)
+		assert.Equal(t, []string{"gorag"}, server.index)
+	})
+
+	t.Run("invalid config", func(t *testing.T) {
+		_, err := NewRedisStore(RedisConfig{URL: "redis://localhost", Algorithm: "ivf"}, testLogger())
+		assert.Error(t, err)
+		_, err = NewRedisStore(RedisConfig{URL: "redis://localhost", Metric: "hamming"}, testLogger())
+		assert.Error(t, err)
+	})
+}
+
+func TestRedisStoreQueryDocuments(t *testing.T) {
+	server := newFakeRedis(t)
+	store := newTestRedisStore(t, server, RedisConfig{Dimension: 8})
+
+	chunks := testChunks(60)
+	chunks[7].Metadata["lang"] = "go"
+	vectors := randomVectors(len(chunks), 8)
+	require.NoError(t, store.AddChunks(chunks, vectors))
+
+	results, err := store.QueryDocuments("", vectors[12], 3, nil)
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	assert.Equal(t, chunks[12].ID, results[0].Chunk.ID)
+	assert.Equal(t, chunks[12].DocumentID, results[0].Chunk.DocumentID)
+	assert.Equal(t, chunks[12].Text, results[0].Chunk.Text)
+	assert.InDelta(t, 1, results[0].Score, 1e-6)
+
+	// A filter matching a single chunk widens the window until it is found
+	server.searches = nil
+	filter, err := types.ParseFilter([]byte(`{"lang": "go"}`))
+	require.NoError(t, err)
+	results, err = store.QueryDocuments("", vectors[0], 1, filter)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, chunks[7].ID, results[0].Chunk.ID)
+
+	// When fewer chunks match than requested, all of them are returned
+	filter, err = types.ParseFilter([]byte(`{"group": 1, "document_id": "doc0"}`))
+	require.NoError(t, err)
+	results, err = store.QueryDocuments("", vectors[0], 10, filter)
+	require.NoError(t, err)
+	assert.Len(t, results, 3)
+	for _, result := range results {
+		assert.Equal(t, "doc0", result.Chunk.DocumentID)
+	}
+}
+
+func TestRedisStoreDeletes(t *testing.T) {
+	server := newFakeRedis(t)
+	store := newTestRedisStore(t, server, RedisConfig{Dimension: 4})
+
+	chunks := testChunks(30)
+	chunks[25].DocumentID = "doc 2/b"
+	require.NoError(t, store.AddChunks(chunks, randomVectors(len(chunks), 4)))
+	// An entry stored under the document ID itself
+	require.NoError(t, store.AddChunks([]types.Chunk{{ID: "doc0", DocumentID: "doc0"}}, [][]float32{{1, 0, 0, 0}}))
+
+	require.NoError(t, store.DeleteDocument("doc0"))
+	require.NoError(t, store.DeleteDocument("doc 2/b"))
+	assert.Contains(t, server.searches, `@document_id:{doc\ 2\/b}`)
+	require.NoError(t, store.DeleteStaleChunks("doc1", []string{"doc1:3", "doc1:4"}))
+
+	indexed, err := store.ListDocuments()
+	require.NoError(t, err)
+	counts := make(map[string]int)
+	for _, entry := range indexed {
+		counts[entry.DocumentID]++
+	}
+	assert.Equal(t, map[string]int{"doc1": 2, "doc2": 9}, counts)
+}
+
+func TestRedisStoreGetEmbeddings(t *testing.T) {
+	server := newFakeRedis(t)
+	store := newTestRedisStore(t, server, RedisConfig{Dimension: 8})
+	chunks := testChunks(10)
+	vectors := randomVectors(len(chunks), 8)
+	require.NoError(t, store.AddChunks(chunks, vectors))
+	assertStoredEmbeddings(t, store, chunks, vectors)
+}
+
+func TestParseRedisSearch(t *testing.T) {
+	tests := []struct {
+		name    string
+		reply   []interface{}
+		want    []redisHit
+		wantErr bool
+	}{
+		{
+			name:  "no hits",
+			reply: []interface{}{int64(0)},
+		},
+		{
+			name: "hits with fields",
+			reply: []interface{}{
+				int64(2),
+				"gorag:a:0", []interface{}{"text", "alpha", "vector_score", "0.1"},
+				"gorag:b:0", []interface{}{"text", "beta"},
+			},
+			want: []redisHit{
+				{key: "gorag:a:0", fields: map[string]string{"text": "alpha", "vector_score": "0.1"}},
+				{key: "gorag:b:0", fields: map[string]string{"text": "beta"}},
+			},
+		},
+		{name: "empty reply", reply: []interface{}{}, wantErr: true},
+		{name: "key is not a string", reply: []interface{}{int64(1), int64(5), []interface{}{}}, wantErr: true},
+		{name: "fields are not a list", reply: []interface{}{int64(1), "gorag:a:0", "text"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hits, err := parseRedisSearch(tt.reply)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, hits)
+		})
+	}
+}
+
+func TestEscapeRedisTag(t *testing.T) {
+	assert.Equal(t, "doc_1", escapeRedisTag("doc_1"))
+	assert.Equal(t, `a\-b\ c\:d\{e\}`, escapeRedisTag("a-b c:d{e}"))
+	assert.Equal(t, "café", escapeRedisTag("café"))
+}
@@ -0,0 +1,256 @@
+package vectorstore
+
+import (
+	"container/heap"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+
+	"github.com/robstave/gorag/internal/domain/types"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// sqliteVector is a chunk and its embedding stored in the vector_entries table
+type sqliteVector struct {
+	ID          string `gorm:"primaryKey"`
+	DocumentID  string `gorm:"index;not null"`
+	ChunkIndex  int
+	Start       int
+	End         int
+	Text        string `gorm:"type:text"`
+	ContentHash string `gorm:"size:64"`
+	Metadata    string `gorm:"type:text"`
+	Embedding   []byte `gorm:"not null"`
+}
+
+func (sqliteVector) TableName() string {
+	return "vector_entries"
+}
+
+// SQLiteStore is a VectorStore that keeps embeddings as BLOBs in the same
+// SQLite database as the documents and scores them in process. Candidates can
+// be prefiltered by metadata in SQL before scoring.
+type SQLiteStore struct {
+	db       *gorm.DB
+	metric   string
+	distance distanceFunc
+	logger   *slog.Logger
+}
+
+// NewSQLiteStore creates a SQLite vector store, creating its table if needed.
+// db must be a SQLite database; use the pgvector store with Postgres.
+func NewSQLiteStore(db *gorm.DB, metric string, logger *slog.Logger) (*SQLiteStore, error) {
+	if name := db.Dialector.Name(); name != sqlDialectSQLite {
+		return nil, fmt.Errorf("the sqlite vector store needs a SQLite database, got %s; use the pgvector store with Postgres", name)
+	}
+	if metric == "" {
+		metric = MetricCosine
+	}
+	distance, err := distanceFor(metric)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.AutoMigrate(&sqliteVector{}); err != nil {
+		logger.Error("Failed to migrate vector table", "error", err)
+		return nil, err
+	}
+
+	return &SQLiteStore{
+		db:       db,
+		metric:   metric,
+		distance: distance,
+		logger:   logger,
+	}, nil
+}
+
+// AddChunks upserts chunks, replacing any existing entries with the same IDs
+func (s *SQLiteStore) AddChunks(chunks []types.Chunk, embeddings [][]float32) error {
+	if len(chunks) != len(embeddings) {
+		return fmt.Errorf("got %d chunks but %d embeddings", len(chunks), len(embeddings))
+	}
+	if len(chunks) == 0 {
+		return nil
+	}
+
+	rows := make([]sqliteVector, len(chunks))
+	for i, chunk := range chunks {
+		metadata, err := json.Marshal(chunkMetadata(chunk))
+		if err != nil {
+			return err
+		}
+		rows[i] = sqliteVector{
+			ID:          chunk.ID,
+			DocumentID:  chunk.DocumentID,
+			ChunkIndex:  chunk.Index,
+			Start:       chunk.Start,
+			End:         chunk.End,
+			Text:        chunk.Text,
+			ContentHash: chunk.ContentHash,
+			Metadata:    string(metadata),
+			Embedding:   encodeEmbedding(embeddings[i]),
+		}
+	}
+
+	err := s.db.Clauses(clause.OnConflict{UpdateAll: true}).CreateInBatches(rows, 100).Error
+	if err != nil {
+		s.logger.Error("Failed to upsert vector entries", "error", err)
+	}
+	return err
+}
+
+// QueryDocuments returns the limit chunks closest to the query embedding
+// whose metadata matches filter. Filtering happens in SQL so only matching
+// rows are scored.
+func (s *SQLiteStore) QueryDocuments(query string, embedding []float32, limit int, filter *types.Filter) ([]types.SearchResult, error) {
+	statement := s.db.Model(&sqliteVector{})
+	if filter != nil {
+		where, args, err := sqlFilter(*filter, sqlDialectSQLite)
+		if err != nil {
+			return nil, err
+		}
+		statement = statement.Where(where, args...)
+	}
+
+	rows, err := statement.Rows()
+	if err != nil {
+		s.logger.Error("Failed to query vector entries", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	if limit <= 0 {
+		limit = math.MaxInt
+	}
+	top := &resultHeap{}
+	for rows.Next() {
+		var row sqliteVector
+		if err := s.db.ScanRows(rows, &row); err != nil {
+			return nil, err
+		}
+
+		vector := decodeEmbedding(row.Embedding)
+		if len(vector) != len(embedding) {
+			s.logger.Warn("Skipping entry with mismatched embedding dimension", "id", row.ID, "expected", len(embedding), "actual", len(vector))
+			continue
+		}
+
+		distance := s.distance(embedding, vector)
+		if top.Len() == limit && distance >= (*top)[0].Distance {
+			continue
+		}
+
+		heap.Push(top, scoredResult(row.toChunk(), s.metric, distance))
+		if top.Len() > limit {
+			heap.Pop(top)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	results := []types.SearchResult(*top)
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Distance != results[j].Distance {
+			return results[i].Distance < results[j].Distance
+		}
+		return results[i].ID < results[j].ID
+	})
+	return results, nil
+}
+
+// DeleteDocument removes every chunk of a document
+func (s *SQLiteStore) DeleteDocument(documentID string) error {
+	return s.db.Where("document_id = ? OR id = ?", documentID, documentID).Delete(&sqliteVector{}).Error
+}
+
+// DeleteStaleChunks removes the chunks of a document whose IDs are not in keep
+func (s *SQLiteStore) DeleteStaleChunks(documentID string, keep []string) error {
+	if len(keep) == 0 {
+		return s.DeleteDocument(documentID)
+	}
+	return s.db.Where("(document_id = ? OR id = ?) AND id NOT IN ?", documentID, documentID, keep).Delete(&sqliteVector{}).Error
+}
+
+// ListDocuments returns the ID, parent document and content hash of every entry
+func (s *SQLiteStore) ListDocuments() ([]types.IndexedDocument, error) {
+	var indexed []types.IndexedDocument
+	result := s.db.Model(&sqliteVector{}).
+		Select("id, document_id, content_hash").
+		Scan(&indexed)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return indexed, nil
+}
+
+// GetEmbeddings returns the stored embeddings of the given chunks
+func (s *SQLiteStore) GetEmbeddings(ids []string) (map[string][]float32, error) {
+	embeddings := make(map[string][]float32, len(ids))
+	if len(ids) == 0 {
+		return embeddings, nil
+	}
+
+	var rows []sqliteVector
+	if err := s.db.Select("id, embedding").Where("id IN ?", ids).Find(&rows).Error; err != nil {
+		s.logger.Error("Failed to read vector entries", "error", err)
+		return nil, err
+	}
+	for _, row := range rows {
+		embeddings[row.ID] = decodeEmbedding(row.Embedding)
+	}
+	return embeddings, nil
+}
+
+func (row sqliteVector) toChunk() types.Chunk {
+	return types.Chunk{
+		ID:          row.ID,
+		DocumentID:  row.DocumentID,
+		Index:       row.ChunkIndex,
+		Start:       row.Start,
+		End:         row.End,
+		Text:        row.Text,
+		ContentHash: row.ContentHash,
+	}
+}
+
+// resultHeap is a max-heap on distance used to keep the closest results
+type resultHeap []types.SearchResult
+
+func (h resultHeap) Len() int           { return len(h) }
+func (h resultHeap) Less(i, j int) bool { return h[i].Distance > h[j].Distance }
+func (h resultHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *resultHeap) Push(x any) {
+	*h = append(*h, x.(types.SearchResult))
+}
+
+func (h *resultHeap) Pop() any {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+	return item
+}
+
+// encodeEmbedding packs an embedding as little-endian float32s
+func encodeEmbedding(embedding []float32) []byte {
+	buf := make([]byte, 4*len(embedding))
+	for i, v := range embedding {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(v))
+	}
+	return buf
+}
+
+// decodeEmbedding unpacks an embedding written by encodeEmbedding
+func decodeEmbedding(buf []byte) []float32 {
+	embedding := make([]float32, len(buf)/4)
+	for i := range embedding {
+		embedding[i] = math.Float32frombits(binary.LittleEndian.Uint32(buf[4*i:]))
+	}
+	return embedding
+}
@@ -0,0 +1,121 @@
+package vectorstore
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/robstave/gorag/internal/domain/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestSQLiteStore(t *testing.T, metric string) *SQLiteStore {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "vectors.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	store, err := NewSQLiteStore(db, metric, testLogger())
+	require.NoError(t, err)
+	return store
+}
+
+func TestSQLiteStoreQuery(t *testing.T) {
+	store := newTestSQLiteStore(t, MetricCosine)
+	memory, err := NewMemoryStore(MetricCosine, "", testLogger())
+	require.NoError(t, err)
+
+	chunks := testChunks(50)
+	embeddings := randomVectors(50, 16)
+	require.NoError(t, store.AddChunks(chunks, embeddings))
+	require.NoError(t, memory.AddChunks(chunks, embeddings))
+
+	// Brute force over the table gives the same results as the memory store
+	for _, query := range embeddings[:5] {
+		want, err := memory.QueryDocuments("", query, 7, nil)
+		require.NoError(t, err)
+		got, err := store.QueryDocuments("", query, 7, nil)
+		require.NoError(t, err)
+		require.Len(t, got, 7)
+		for i := range want {
+			assert.Equal(t, want[i].ID, got[i].ID)
+			assert.InDelta(t, want[i].Distance, got[i].Distance, 1e-6)
+			assert.Equal(t, want[i].Chunk.Text, got[i].Chunk.Text)
+		}
+	}
+
+	// Re-adding a chunk replaces it
+	require.NoError(t, store.AddChunks(chunks[:1], embeddings[1:2]))
+	results, err := store.QueryDocuments("", embeddings[1], 2, nil)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"doc0:0", "doc0:1"}, []string{results[0].ID, results[1].ID})
+	assert.InDelta(t, 1, results[0].Score, 1e-6)
+
+	assert.Error(t, store.AddChunks(chunks[:2], embeddings[:1]))
+}
+
+func TestSQLiteStoreFilter(t *testing.T) {
+	store := newTestSQLiteStore(t, MetricL2)
+	chunks := testChunks(30)
+	embeddings := randomVectors(30, 8)
+	require.NoError(t, store.AddChunks(chunks, embeddings))
+
+	filter, err := types.ParseFilter([]byte(`{"$and": [{"group": 2}, {"document_id": {"$in": ["doc0", "doc2"]}}]}`))
+	require.NoError(t, err)
+	results, err := store.QueryDocuments("", embeddings[0], 0, filter)
+	require.NoError(t, err)
+
+	var ids []string
+	for _, result := range results {
+		ids = append(ids, result.ID)
+		assert.Equal(t, MetricL2, result.Metric)
+	}
+	assert.ElementsMatch(t, []string{"doc0:2", "doc0:5", "doc0:8", "doc2:0", "doc2:3", "doc2:6", "doc2:9"}, ids)
+
+	invalid := &types.Filter{Field: "bad field", Op: types.FilterEq, Value: "x"}
+	_, err = store.QueryDocuments("", embeddings[0], 0, invalid)
+	assert.Error(t, err)
+}
+
+func TestSQLiteStoreDelete(t *testing.T) {
+	store := newTestSQLiteStore(t, MetricCosine)
+	chunks := testChunks(30)
+	require.NoError(t, store.AddChunks(chunks, randomVectors(30, 8)))
+	// An entry stored under the document ID itself, as before chunking
+	require.NoError(t, store.AddChunks([]types.Chunk{{ID: "doc1", DocumentID: "doc1"}}, randomVectors(1, 8)))
+
+	require.NoError(t, store.DeleteStaleChunks("doc1", []string{"doc1:0", "doc1:1"}))
+	require.NoError(t, store.DeleteDocument("doc2"))
+	require.NoError(t, store.DeleteStaleChunks("missing", nil))
+
+	indexed, err := store.ListDocuments()
+	require.NoError(t, err)
+	counts := map[string]int{}
+	for _, entry := range indexed {
+		counts[entry.DocumentID]++
+	}
+	assert.Equal(t, map[string]int{"doc0": 10, "doc1": 2}, counts)
+}
+
+func TestSQLiteStoreGetEmbeddings(t *testing.T) {
+	store := newTestSQLiteStore(t, MetricCosine)
+	chunks := testChunks(10)
+	vectors := randomVectors(len(chunks), 8)
+	require.NoError(t, store.AddChunks(chunks, vectors))
+	assertStoredEmbeddings(t, store, chunks, vectors)
+}
+
+func TestSQLiteStoreRejectsPostgres(t *testing.T) {
+	db, err := gorm.Open(postgres.Open("host=localhost dbname=gorag"), &gorm.Config{
+		DisableAutomaticPing: true,
+		Logger:               logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+
+	_, err = NewSQLiteStore(db, MetricCosine, testLogger())
+	assert.ErrorContains(t, err, "pgvector")
+}
@@ -1,17 +1,67 @@
 package vectorstore
 
 import (
+	"errors"
+
 	"github.com/robstave/gorag/internal/domain/types"
 )
 
-// VectorStore represents a repository for storing and querying document embeddings
+// ErrDimensionMismatch is returned when an embedding does not have the
+// dimension of the vectors already in the store
+var ErrDimensionMismatch = errors.New("embedding dimension mismatch")
+
+// VectorStore represents a repository for storing and querying document chunk embeddings
 type VectorStore interface {
-	// AddDocument adds a document and its embedding to the vector store
-	AddDocument(doc types.Document, embedding []float32) error
+	// AddChunks adds chunks and their embeddings to the vector store,
+	// replacing any existing entries with the same IDs
+	AddChunks(chunks []types.Chunk, embeddings [][]float32) error
+
+	// QueryDocuments finds the chunks most similar to the query embedding. When
+	// filter is not nil only chunks whose metadata matches it are returned.
+	QueryDocuments(query string, embedding []float32, limit int, filter *types.Filter) ([]types.SearchResult, error)
+
+	// DeleteDocument removes every chunk of a document from the vector store
+	DeleteDocument(documentID string) error
+
+	// DeleteStaleChunks removes the chunks of a document whose IDs are not in
+	// keep, including an entry stored under the document ID itself. It is
+	// called after AddChunks so the document stays searchable while it is
+	// re-indexed.
+	DeleteStaleChunks(documentID string, keep []string) error
 
-	// QueryDocuments finds similar documents based on the query embedding
-	QueryDocuments(query string, embedding []float32, limit int) ([]types.SearchResult, error)
+	// ListDocuments returns the ID, parent document and content hash of every stored entry
+	ListDocuments() ([]types.IndexedDocument, error)
+}
+
+// EmbeddingReader is implemented by vector stores that can return the
+// embeddings they hold, so stored chunks do not have to be embedded again
+type EmbeddingReader interface {
+	// GetEmbeddings returns the stored embeddings of the chunks with the
+	// given IDs. IDs that are not in the store are left out of the map.
+	GetEmbeddings(ids []string) (map[string][]float32, error)
+}
+
+// idSet returns the IDs as a set
+func idSet(ids []string) map[string]bool {
+	set := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
 
-	// DeleteDocument removes a document from the vector store
-	DeleteDocument(id string) error
+// chunkMetadata returns the metadata stored alongside a chunk by backends that
+// keep free-form metadata: the document's search metadata plus the chunk's
+// own fields, which take precedence
+func chunkMetadata(chunk types.Chunk) map[string]interface{} {
+	metadata := make(map[string]interface{}, len(chunk.Metadata)+5)
+	for key, value := range chunk.Metadata {
+		metadata[key] = value
+	}
+	metadata["document_id"] = chunk.DocumentID
+	metadata["chunk_index"] = chunk.Index
+	metadata["start"] = chunk.Start
+	metadata["end"] = chunk.End
+	metadata["content_hash"] = chunk.ContentHash
+	return metadata
 }
@@ -0,0 +1,341 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/robstave/gorag/internal/domain/llm"
+	"github.com/robstave/gorag/internal/domain/prompt"
+	"github.com/robstave/gorag/internal/domain/types"
+)
+
+// defaultAskContextTokens is the token budget for sources when the request
+// does not set one
+const defaultAskContextTokens = 3000
+
+// noSourcesAnswer is returned without calling the model when retrieval finds nothing
+const noSourcesAnswer = "I couldn't find any documents relevant to the question."
+
+// citationMarker matches [1] and [1, 2] style markers in an answer
+var citationMarker = regexp.MustCompile(`\[(\d+(?:\s*,\s*\d+)*)\]`)
+
+// spaceBeforePunctuation matches the space a removed marker leaves before punctuation
+var spaceBeforePunctuation = regexp.MustCompile(` ([.,;:!?])`)
+
+// leadingMarkers matches citation markers at the start of a sentence
+var leadingMarkers = regexp.MustCompile(`^\s*(?:\[\d+(?:\s*,\s*\d+)*\]\s*)+`)
+
+// Ask retrieves the chunks most relevant to the question, asks the chat
+// model to answer from them and maps the answer's citation markers back to
+// the documents and spans they refer to
+func (s *Service) Ask(ctx context.Context, request types.AskRequest) (*types.AskResponse, error) {
+	s.logger.Info("Answering question", "question", request.Question)
+
+	if s.chatModel == nil {
+		s.logger.Error("Question asked but no chat model is configured")
+		return nil, types.ErrLLMUnavailable
+	}
+
+	sources, messages, err := s.prepareAsk(ctx, request, request.Question, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &types.AskResponse{
+		Question:  request.Question,
+		Citations: []types.Citation{},
+		Sources:   sources,
+		Model:     s.chatModel.ModelID(),
+	}
+	if len(sources) == 0 {
+		response.Answer = noSourcesAnswer
+		return response, nil
+	}
+
+	reply, err := s.chatModel.Chat(ctx, messages)
+	if err != nil {
+		s.logger.Error("Failed to generate answer", "model", s.chatModel.ModelID(), "error", err)
+		return nil, err
+	}
+
+	response.Answer = reply.Content
+	response.Citations = citeAnswer(reply.Content, sources)
+	response.Usage = reply.Usage
+	return response, nil
+}
+
+// AskStream answers like Ask but reports progress through emit: the sources
+// once retrieval is done, each piece of the answer as the model generates it,
+// then the citations and a done event. An error from emit, such as a client
+// that has gone away, stops generation. Errors before the first event are
+// returned without emitting anything.
+func (s *Service) AskStream(ctx context.Context, request types.AskRequest, emit func(types.AskEvent) error) error {
+	s.logger.Info("Streaming answer to question", "question", request.Question)
+
+	if s.chatModel == nil {
+		s.logger.Error("Question asked but no chat model is configured")
+		return types.ErrLLMUnavailable
+	}
+
+	sources, messages, err := s.prepareAsk(ctx, request, request.Question, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := emit(types.AskEvent{Type: types.AskEventSources, Data: sources}); err != nil {
+		return err
+	}
+
+	if len(sources) == 0 {
+		if err := emit(types.AskEvent{Type: types.AskEventDelta, Data: types.AskDelta{Content: noSourcesAnswer}}); err != nil {
+			return err
+		}
+		if err := emit(types.AskEvent{Type: types.AskEventCitations, Data: []types.Citation{}}); err != nil {
+			return err
+		}
+		return emit(types.AskEvent{Type: types.AskEventDone, Data: types.AskDone{
+			Answer: noSourcesAnswer,
+			Model:  s.chatModel.ModelID(),
+		}})
+	}
+
+	reply, err := s.chatModel.ChatStream(ctx, messages, func(delta string) error {
+		return emit(types.AskEvent{Type: types.AskEventDelta, Data: types.AskDelta{Content: delta}})
+	})
+	if err != nil {
+		s.logger.Error("Failed to stream answer", "model", s.chatModel.ModelID(), "error", err)
+		return err
+	}
+
+	if err := emit(types.AskEvent{Type: types.AskEventCitations, Data: citeAnswer(reply.Content, sources)}); err != nil {
+		return err
+	}
+	return emit(types.AskEvent{Type: types.AskEventDone, Data: types.AskDone{
+		Answer: reply.Content,
+		Model:  s.chatModel.ModelID(),
+		Usage:  reply.Usage,
+	}})
+}
+
+// prepareAsk retrieves sources for query and builds the prompt, placing any
+// earlier conversation messages in history before the question. It returns
+// no messages when nothing relevant was found.
+func (s *Service) prepareAsk(ctx context.Context, request types.AskRequest, query string, history []llm.Message) ([]types.AskSource, []llm.Message, error) {
+	if !s.prompts.Has(request.Template) {
+		return nil, nil, fmt.Errorf("%w: %q", types.ErrUnknownPromptTemplate, request.Template)
+	}
+
+	results, err := s.SearchDocuments(ctx, types.SearchQuery{
+		Query:    query,
+		Limit:    request.Limit,
+		Mode:     request.Mode,
+		MinScore: request.MinScore,
+		Filter:   request.Filter,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	budget := request.MaxContextTokens
+	if budget <= 0 {
+		budget = defaultAskContextTokens
+	}
+	sources, err := s.selectSources(results, budget, request.Template, request.Question)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(sources) == 0 {
+		return sources, nil, nil
+	}
+
+	data := prompt.AskData{Question: request.Question, Sources: sources}
+	system, err := s.prompts.Render(request.Template, prompt.System, data)
+	if err != nil {
+		s.logger.Error("Failed to render system prompt", "template", request.Template, "error", err)
+		return nil, nil, err
+	}
+	userPrompt, err := s.prompts.Render(request.Template, prompt.Context, data)
+	if err != nil {
+		s.logger.Error("Failed to render context prompt", "template", request.Template, "error", err)
+		return nil, nil, err
+	}
+
+	messages := []llm.Message{{Role: llm.RoleSystem, Content: system}}
+	messages = append(messages, history...)
+	messages = append(messages, llm.Message{Role: llm.RoleUser, Content: userPrompt})
+	return sources, messages, nil
+}
+
+// selectSources numbers results in rank order and keeps as many as fit in
+// the token budget. Each source costs what it adds to the context prompt
+// rendered with the request's template, so templates that format sources
+// differently are measured correctly. The best result is always kept,
+// truncated if need be.
+func (s *Service) selectSources(results []types.SearchResult, budget int, template string, question string) ([]types.AskSource, error) {
+	contextTokens := func(sources []types.AskSource) (int, error) {
+		rendered, err := s.prompts.Render(template, prompt.Context, prompt.AskData{Question: question, Sources: sources})
+		if err != nil {
+			s.logger.Error("Failed to render context prompt", "template", template, "error", err)
+			return 0, err
+		}
+		return estimateTokens(rendered), nil
+	}
+
+	sources := []types.AskSource{}
+	base, err := contextTokens(sources)
+	if err != nil {
+		return nil, err
+	}
+	used := 0
+	for _, result := range results {
+		source := types.AskSource{
+			Number:       len(sources) + 1,
+			DocumentID:   result.Chunk.DocumentID,
+			DocumentName: result.Document.Name,
+			ChunkID:      result.Chunk.ID,
+			Start:        result.Chunk.Start,
+			End:          result.Chunk.End,
+			Score:        result.Score,
+			Text:         result.Chunk.Text,
+		}
+
+		// A full slice expression keeps the candidate from writing into sources
+		total, err := contextTokens(append(sources[:len(sources):len(sources)], source))
+		if err != nil {
+			return nil, err
+		}
+		cost := total - base - used
+		if used+cost > budget {
+			if len(sources) > 0 {
+				break
+			}
+			// Cut the best source to what the budget leaves after its heading
+			heading := source
+			heading.Text = ""
+			headingTokens, err := contextTokens([]types.AskSource{heading})
+			if err != nil {
+				return nil, err
+			}
+			source.Text = truncateToTokens(source.Text, budget-(headingTokens-base))
+			cost = budget
+		}
+
+		sources = append(sources, source)
+		used += cost
+	}
+	return sources, nil
+}
+
+// estimateTokens approximates the token count of text at four characters per
+// token, which is close for English with the common BPE tokenizers
+func estimateTokens(text string) int {
+	return (len([]rune(text)) + 3) / 4
+}
+
+// truncateToTokens cuts text to roughly tokens tokens
+func truncateToTokens(text string, tokens int) string {
+	runes := []rune(text)
+	if limit := max(tokens, 0) * 4; len(runes) > limit {
+		return string(runes[:limit])
+	}
+	return text
+}
+
+// citeAnswer maps the markers in an answer to the sources they number. Each
+// citation lists the sentences that carry its marker; markers that open a
+// sentence, as in "Go is typed. [1] It compiles fast.", count for the one
+// before. Markers that do not number a source are ignored.
+func citeAnswer(answer string, sources []types.AskSource) []types.Citation {
+	citations := make(map[int]*types.Citation)
+	cite := func(markers string, claim string) {
+		for _, match := range citationMarker.FindAllStringSubmatch(markers, -1) {
+			for _, field := range strings.Split(match[1], ",") {
+				number, err := strconv.Atoi(strings.TrimSpace(field))
+				if err != nil || number < 1 || number > len(sources) {
+					continue
+				}
+
+				citation, ok := citations[number]
+				if !ok {
+					source := sources[number-1]
+					citation = &types.Citation{
+						Number:     number,
+						DocumentID: source.DocumentID,
+						ChunkID:    source.ChunkID,
+						Start:      source.Start,
+						End:        source.End,
+						Claims:     []string{},
+					}
+					citations[number] = citation
+				}
+				if claim != "" && !containsString(citation.Claims, claim) {
+					citation.Claims = append(citation.Claims, claim)
+				}
+			}
+		}
+	}
+
+	claim := ""
+	for _, sentence := range splitSentences(answer) {
+		if leading := leadingMarkers.FindString(sentence); leading != "" && claim != "" {
+			cite(leading, claim)
+			sentence = sentence[len(leading):]
+		}
+
+		text := stripCitations(sentence)
+		if text != "" {
+			claim = text
+		}
+		cite(sentence, claim)
+	}
+
+	result := make([]types.Citation, 0, len(citations))
+	for _, citation := range citations {
+		result = append(result, *citation)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Number < result[j].Number
+	})
+	return result
+}
+
+// stripCitations removes citation markers from text and tidies the spacing
+// they leave behind
+func stripCitations(text string) string {
+	text = strings.Join(strings.Fields(citationMarker.ReplaceAllString(text, "")), " ")
+	return spaceBeforePunctuation.ReplaceAllString(text, "$1")
+}
+
+// splitSentences splits text after sentence-ending punctuation followed by
+// whitespace, and at line breaks
+func splitSentences(text string) []string {
+	var sentences []string
+	runes := []rune(text)
+	start := 0
+	for i, r := range runes {
+		end := r == '\n' ||
+			(strings.ContainsRune(".!?", r) && i+1 < len(runes) && unicode.IsSpace(runes[i+1]))
+		if end {
+			sentences = append(sentences, string(runes[start:i+1]))
+			start = i + 1
+		}
+	}
+	if start < len(runes) {
+		sentences = append(sentences, string(runes[start:]))
+	}
+	return sentences
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
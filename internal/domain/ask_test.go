@@ -0,0 +1,186 @@
+package domain
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/robstave/gorag/internal/domain/prompt"
+	"github.com/robstave/gorag/internal/domain/types"
+	"github.com/robstave/gorag/prompts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCiteAnswer(t *testing.T) {
+	sources := []types.AskSource{
+		{Number: 1, DocumentID: "go", ChunkID: "go:0", Start: 0, End: 40},
+		{Number: 2, DocumentID: "rust", ChunkID: "rust:3", Start: 120, End: 200},
+	}
+	citation := func(number int, claims ...string) types.Citation {
+		source := sources[number-1]
+		if claims == nil {
+			claims = []string{}
+		}
+		return types.Citation{
+			Number:     number,
+			DocumentID: source.DocumentID,
+			ChunkID:    source.ChunkID,
+			Start:      source.Start,
+			End:        source.End,
+			Claims:     claims,
+		}
+	}
+
+	tests := []struct {
+		name   string
+		answer string
+		want   []types.Citation
+	}{
+		{
+			name:   "no markers",
+			answer: "I don't know.",
+			want:   []types.Citation{},
+		},
+		{
+			name:   "one marker per sentence",
+			answer: "Go is typed [1]. Rust has no garbage collector [2].",
+			want: []types.Citation{
+				citation(1, "Go is typed."),
+				citation(2, "Rust has no garbage collector."),
+			},
+		},
+		{
+			name:   "several sources in one marker",
+			answer: "Both compile to native code [2, 1].",
+			want: []types.Citation{
+				citation(1, "Both compile to native code."),
+				citation(2, "Both compile to native code."),
+			},
+		},
+		{
+			name:   "leading marker cites the sentence before",
+			answer: "Go is typed. [1] It compiles fast.",
+			want:   []types.Citation{citation(1, "Go is typed.")},
+		},
+		{
+			name:   "repeated claims are listed once",
+			answer: "Go is typed [1]. Go is typed [1]. It is also simple [1], and fast [1]!",
+			want:   []types.Citation{citation(1, "Go is typed.", "It is also simple, and fast!")},
+		},
+		{
+			name:   "lines are sentences",
+			answer: "- Go is typed [1]\n- Rust is typed [2]",
+			want: []types.Citation{
+				citation(1, "- Go is typed"),
+				citation(2, "- Rust is typed"),
+			},
+		},
+		{
+			name:   "markers without a source are ignored",
+			answer: "Go is typed [0]. Rust is typed [3, 2].",
+			want:   []types.Citation{citation(2, "Rust is typed.")},
+		},
+		{
+			name:   "a marker alone cites nothing before it",
+			answer: "[1]",
+			want:   []types.Citation{citation(1)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, citeAnswer(tt.answer, sources))
+		})
+	}
+}
+
+func TestSplitSentences(t *testing.T) {
+	assert.Equal(t, []string{"One.", " Two?", " Three!", "\n", "\n", "Four"}, splitSentences("One. Two? Three!\n\nFour"))
+	assert.Equal(t, []string{"Version 1.2 is out."}, splitSentences("Version 1.2 is out."))
+	assert.Nil(t, splitSentences(""))
+}
+
+// newTestPrompts returns a registry with the default prompt set and a
+// "verbose" set whose context template puts a long heading on every source
+func newTestPrompts(t *testing.T) *prompt.Registry {
+	t.Helper()
+
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "verbose"), 0o755))
+	verbose := `{{range .Sources}}=== Source number {{.Number}} from {{.DocumentName}}, retrieved for this question with score {{.Score}} ===
+{{.Text}}
+{{end}}Question: {{.Question}}
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "verbose", "context.tmpl"), []byte(verbose), 0o644))
+
+	registry, err := prompt.NewRegistry(dir, prompts.Defaults, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	return registry
+}
+
+func TestSelectSources(t *testing.T) {
+	service := &Service{
+		logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+		prompts: newTestPrompts(t),
+	}
+
+	var results []types.SearchResult
+	for _, id := range []string{"a", "b", "c"} {
+		results = append(results, types.SearchResult{
+			Score:    0.5,
+			Document: types.Document{ID: id, Name: "doc " + id},
+			Chunk:    types.Chunk{ID: id + ":0", DocumentID: id, Text: strings.Repeat(id, 40)},
+		})
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		budget   int
+		want     []string
+	}{
+		{name: "everything fits", budget: 1000, want: []string{"a:0", "b:0", "c:0"}},
+		{name: "default template", budget: 45, want: []string{"a:0", "b:0", "c:0"}},
+		{name: "budget cuts the ranking", budget: 30, want: []string{"a:0", "b:0"}},
+		{name: "template headings count", template: "verbose", budget: 45, want: []string{"a:0"}},
+		{name: "verbose template with room", template: "verbose", budget: 100, want: []string{"a:0", "b:0", "c:0"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sources, err := service.selectSources(results, tt.budget, tt.template, "What are the letters?")
+			require.NoError(t, err)
+
+			ids := make([]string, len(sources))
+			for i, source := range sources {
+				ids[i] = source.ChunkID
+				assert.Equal(t, i+1, source.Number)
+				assert.Equal(t, results[i].Chunk.Text, source.Text)
+			}
+			assert.Equal(t, tt.want, ids)
+		})
+	}
+
+	t.Run("best source is truncated to the budget", func(t *testing.T) {
+		sources, err := service.selectSources(results, 8, "", "Why?")
+		require.NoError(t, err)
+		require.Len(t, sources, 1)
+		assert.NotEmpty(t, sources[0].Text)
+		assert.Less(t, len(sources[0].Text), len(results[0].Chunk.Text))
+
+		withSource, err := service.prompts.Render("", prompt.Context, prompt.AskData{Question: "Why?", Sources: sources})
+		require.NoError(t, err)
+		withoutSources, err := service.prompts.Render("", prompt.Context, prompt.AskData{Question: "Why?"})
+		require.NoError(t, err)
+		assert.LessOrEqual(t, estimateTokens(withSource)-estimateTokens(withoutSources), 8+1)
+	})
+
+	t.Run("unknown template", func(t *testing.T) {
+		_, err := service.selectSources(results, 100, "missing", "Why?")
+		assert.ErrorIs(t, err, types.ErrUnknownPromptTemplate)
+	})
+}
@@ -0,0 +1,214 @@
+// Package chunking splits documents into spans that are embedded separately
+package chunking
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/robstave/gorag/internal/domain/types"
+)
+
+// Span is a byte range [Start, End) of a text
+type Span struct {
+	Start int
+	End   int
+}
+
+// Splitter splits a text into non-empty spans
+type Splitter interface {
+	Split(text string) []Span
+}
+
+// Strategies accepted by Config.Strategy
+const (
+	StrategyAuto      = "auto"
+	StrategyFixed     = "fixed"
+	StrategySentence  = "sentence"
+	StrategyParagraph = "paragraph"
+	StrategyMarkdown  = "markdown"
+	StrategyGo        = "go"
+)
+
+// Config controls how documents are chunked
+type Config struct {
+	// Strategy selects the splitter. "auto" picks one based on the document name.
+	Strategy string
+	// Size is the maximum chunk size in bytes
+	Size int
+	// Overlap is the number of bytes shared by consecutive fixed-size chunks
+	Overlap int
+}
+
+// DefaultConfig returns the default chunking configuration
+func DefaultConfig() Config {
+	return Config{
+		Strategy: StrategyAuto,
+		Size:     1000,
+		Overlap:  100,
+	}
+}
+
+// Chunker turns documents into chunks using the configured splitter
+type Chunker struct {
+	config Config
+}
+
+// NewChunker creates a new chunker, validating the configuration
+func NewChunker(config Config) (*Chunker, error) {
+	if config.Strategy == "" {
+		config.Strategy = StrategyAuto
+	}
+	if config.Size <= 0 {
+		return nil, fmt.Errorf("chunk size must be positive, got %d", config.Size)
+	}
+	if config.Overlap < 0 || config.Overlap >= config.Size {
+		return nil, fmt.Errorf("chunk overlap must be between 0 and size, got %d", config.Overlap)
+	}
+
+	switch config.Strategy {
+	case StrategyAuto, StrategyFixed, StrategySentence, StrategyParagraph, StrategyMarkdown, StrategyGo:
+	default:
+		return nil, fmt.Errorf("unknown chunking strategy: %s", config.Strategy)
+	}
+
+	return &Chunker{config: config}, nil
+}
+
+// SplitterFor returns the splitter used for the given document
+func (c *Chunker) SplitterFor(doc types.Document) Splitter {
+	strategy := c.config.Strategy
+	if strategy == StrategyAuto {
+		switch strings.ToLower(path.Ext(doc.Name)) {
+		case ".go":
+			strategy = StrategyGo
+		case ".md", ".markdown":
+			strategy = StrategyMarkdown
+		default:
+			strategy = StrategyParagraph
+		}
+	}
+
+	switch strategy {
+	case StrategyFixed:
+		return NewFixedSplitter(c.config.Size, c.config.Overlap)
+	case StrategySentence:
+		return NewSentenceSplitter(c.config.Size)
+	case StrategyMarkdown:
+		return NewMarkdownSplitter(c.config.Size)
+	case StrategyGo:
+		return NewGoSplitter(c.config.Size)
+	default:
+		return NewParagraphSplitter(c.config.Size)
+	}
+}
+
+// Chunk splits a document into chunks. Each chunk carries the document's
+// search metadata. A document that is empty or only whitespace has no chunks,
+// since embedding APIs reject empty input.
+func (c *Chunker) Chunk(doc types.Document) []types.Chunk {
+	spans := c.SplitterFor(doc).Split(doc.Value)
+	if len(spans) == 0 {
+		return nil
+	}
+
+	hash := doc.ContentHash()
+	metadata := doc.SearchMetadata()
+	chunks := make([]types.Chunk, len(spans))
+	for i, span := range spans {
+		chunks[i] = types.Chunk{
+			ID:          ChunkID(doc.ID, i),
+			DocumentID:  doc.ID,
+			Index:       i,
+			Start:       span.Start,
+			End:         span.End,
+			Text:        doc.Value[span.Start:span.End],
+			ContentHash: hash,
+			Metadata:    metadata,
+		}
+	}
+	return chunks
+}
+
+// ChunkID returns the vector store ID of the i-th chunk of a document
+func ChunkID(documentID string, index int) string {
+	return fmt.Sprintf("%s:%d", documentID, index)
+}
+
+// trim shrinks a span to exclude leading and trailing whitespace. It returns
+// false if nothing but whitespace remains.
+func trim(text string, span Span) (Span, bool) {
+	for span.Start < span.End {
+		r, size := utf8.DecodeRuneInString(text[span.Start:span.End])
+		if !unicode.IsSpace(r) {
+			break
+		}
+		span.Start += size
+	}
+	for span.End > span.Start {
+		r, size := utf8.DecodeLastRuneInString(text[span.Start:span.End])
+		if !unicode.IsSpace(r) {
+			break
+		}
+		span.End -= size
+	}
+	return span, span.End > span.Start
+}
+
+// pack merges consecutive segments into spans of at most maxSize bytes.
+// Segments that are larger than maxSize on their own are split with fallback.
+func pack(text string, segments []Span, maxSize int, fallback Splitter) []Span {
+	var spans []Span
+	var current Span
+	open := false
+
+	flush := func() {
+		if open {
+			if span, ok := trim(text, current); ok {
+				spans = append(spans, span)
+			}
+			open = false
+		}
+	}
+
+	for _, seg := range segments {
+		seg, ok := trim(text, seg)
+		if !ok {
+			continue
+		}
+
+		if seg.End-seg.Start > maxSize {
+			flush()
+			for _, sub := range fallback.Split(text[seg.Start:seg.End]) {
+				spans = append(spans, Span{Start: seg.Start + sub.Start, End: seg.Start + sub.End})
+			}
+			continue
+		}
+
+		if open && seg.End-current.Start > maxSize {
+			flush()
+		}
+		if !open {
+			current = seg
+			open = true
+			continue
+		}
+		current.End = seg.End
+	}
+	flush()
+
+	return spans
+}
+
+// runeBoundary moves i back to the start of the rune that contains it
+func runeBoundary(text string, i int) int {
+	if i >= len(text) {
+		return len(text)
+	}
+	for i > 0 && !utf8.RuneStart(text[i]) {
+		i--
+	}
+	return i
+}
@@ -0,0 +1,177 @@
+package chunking
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/robstave/gorag/internal/domain/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const goSource = `package example
+
+import "fmt"
+
+// Hello greets someone
+func Hello(name string) string {
+	return fmt.Sprintf("Hello, %s", name)
+}
+
+// Goodbye says goodbye
+func Goodbye(name string) string {
+	return fmt.Sprintf("Goodbye, %s", name)
+}
+`
+
+const markdownSource = "# Title\n\nIntro paragraph.\n\n## Usage\n\nRun it.\n\n```sh\n# not a heading\nmake\n```\n\n## License\n\nMIT\n"
+
+func TestSplitters(t *testing.T) {
+	tests := []struct {
+		name     string
+		splitter Splitter
+		text     string
+		want     []string
+	}{
+		{
+			name:     "fixed windows overlap by bytes and break on whitespace",
+			splitter: NewFixedSplitter(12, 4),
+			text:     "alpha beta gamma delta epsilon",
+			want:     []string{"alpha beta", "beta gamma", "amma delta", "elta epsilon"},
+		},
+		{
+			name:     "fixed keeps multibyte runes whole",
+			splitter: NewFixedSplitter(4, 0),
+			text:     "ééééé",
+			want:     []string{"éé", "éé", "é"},
+		},
+		{
+			name:     "sentences are packed up to the size",
+			splitter: NewSentenceSplitter(30),
+			text:     "One sentence. Two sentence. Three sentence here.",
+			want:     []string{"One sentence. Two sentence.", "Three sentence here."},
+		},
+		{
+			name:     "paragraphs split on blank lines",
+			splitter: NewParagraphSplitter(20),
+			text:     "First paragraph.\n\nSecond paragraph.\n\n\nThird.",
+			want:     []string{"First paragraph.", "Second paragraph.", "Third."},
+		},
+		{
+			name:     "small paragraphs are packed together",
+			splitter: NewParagraphSplitter(100),
+			text:     "First.\n\nSecond.",
+			want:     []string{"First.\n\nSecond."},
+		},
+		{
+			name:     "markdown splits at headings outside code fences",
+			splitter: NewMarkdownSplitter(200),
+			text:     markdownSource,
+			want: []string{
+				"# Title\n\nIntro paragraph.",
+				"## Usage\n\nRun it.\n\n```sh\n# not a heading\nmake\n```",
+				"## License\n\nMIT",
+			},
+		},
+		{
+			name:     "go splits on declarations with their doc comments",
+			splitter: NewGoSplitter(120),
+			text:     goSource,
+			want: []string{
+				"package example\n\nimport \"fmt\"",
+				"// Hello greets someone\nfunc Hello(name string) string {\n\treturn fmt.Sprintf(\"Hello, %s\", name)\n}",
+				"// Goodbye says goodbye\nfunc Goodbye(name string) string {\n\treturn fmt.Sprintf(\"Goodbye, %s\", name)\n}",
+			},
+		},
+		{
+			name:     "invalid go falls back to paragraphs",
+			splitter: NewGoSplitter(80),
+			text:     "not go\n\nat all",
+			want:     []string{"not go\n\nat all"},
+		},
+		{
+			name:     "whitespace yields nothing",
+			splitter: NewParagraphSplitter(10),
+			text:     " \n\n \t",
+			want:     nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spans := tt.splitter.Split(tt.text)
+
+			var got []string
+			for _, span := range spans {
+				require.True(t, 0 <= span.Start && span.Start < span.End && span.End <= len(tt.text), "span %v out of range", span)
+				got = append(got, tt.text[span.Start:span.End])
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSplittersRespectSize(t *testing.T) {
+	text := strings.Repeat("A long sentence that keeps going on and on. ", 50) +
+		"\n\n" + strings.Repeat("x", 500)
+	size := 120
+
+	for name, splitter := range map[string]Splitter{
+		"fixed":     NewFixedSplitter(size, 20),
+		"sentence":  NewSentenceSplitter(size),
+		"paragraph": NewParagraphSplitter(size),
+		"markdown":  NewMarkdownSplitter(size),
+		"go":        NewGoSplitter(size),
+	} {
+		t.Run(name, func(t *testing.T) {
+			spans := splitter.Split(text)
+			require.NotEmpty(t, spans)
+			for _, span := range spans {
+				assert.LessOrEqual(t, span.End-span.Start, size)
+				assert.Equal(t, strings.TrimSpace(text[span.Start:span.End]), text[span.Start:span.End])
+			}
+		})
+	}
+}
+
+func TestChunk(t *testing.T) {
+	chunker, err := NewChunker(Config{Strategy: StrategyAuto, Size: 40, Overlap: 0})
+	require.NoError(t, err)
+
+	doc := types.Document{
+		ID:    "doc",
+		Name:  "notes.md",
+		Value: "# One\n\nFirst section.\n\n# Two\n\nSecond section.",
+		Tags:  []string{"notes"},
+	}
+	chunks := chunker.Chunk(doc)
+	require.Len(t, chunks, 2)
+	for i, chunk := range chunks {
+		assert.Equal(t, ChunkID("doc", i), chunk.ID)
+		assert.Equal(t, i, chunk.Index)
+		assert.Equal(t, "doc", chunk.DocumentID)
+		assert.Equal(t, doc.ContentHash(), chunk.ContentHash)
+		assert.Equal(t, doc.Value[chunk.Start:chunk.End], chunk.Text)
+		assert.Equal(t, []string{"notes"}, chunk.Metadata[types.TagsField])
+	}
+	assert.Equal(t, "# Two\n\nSecond section.", chunks[1].Text)
+}
+
+func TestChunkEmptyDocument(t *testing.T) {
+	chunker, err := NewChunker(DefaultConfig())
+	require.NoError(t, err)
+
+	assert.Empty(t, chunker.Chunk(types.Document{ID: "doc", Value: ""}))
+	assert.Empty(t, chunker.Chunk(types.Document{ID: "doc", Value: " \n\t "}))
+}
+
+func TestNewChunkerValidates(t *testing.T) {
+	for _, config := range []Config{
+		{Strategy: StrategyFixed, Size: 0},
+		{Strategy: StrategyFixed, Size: 10, Overlap: 10},
+		{Strategy: "words", Size: 10},
+	} {
+		_, err := NewChunker(config)
+		assert.Error(t, err, "%+v", config)
+	}
+}
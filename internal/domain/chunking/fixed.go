@@ -0,0 +1,60 @@
+package chunking
+
+import (
+	"strings"
+)
+
+// FixedSplitter splits text into windows of a fixed size that overlap by a
+// fixed amount. Windows end on whitespace when one is close to the limit.
+type FixedSplitter struct {
+	size    int
+	overlap int
+}
+
+// NewFixedSplitter creates a fixed-size splitter
+func NewFixedSplitter(size, overlap int) *FixedSplitter {
+	if size <= 0 {
+		size = 1
+	}
+	if overlap < 0 || overlap >= size {
+		overlap = 0
+	}
+	return &FixedSplitter{size: size, overlap: overlap}
+}
+
+// Split implements Splitter
+func (f *FixedSplitter) Split(text string) []Span {
+	var spans []Span
+
+	start := 0
+	for start < len(text) {
+		end := start + f.size
+		if end >= len(text) {
+			end = len(text)
+		} else {
+			// Prefer to break on whitespace within the last quarter of the window
+			if ws := strings.LastIndexAny(text[start:end], " \t\n\r"); ws > f.size*3/4 {
+				end = start + ws
+			}
+			end = runeBoundary(text, end)
+			if end <= start {
+				end = runeBoundary(text, start+f.size)
+			}
+		}
+
+		if span, ok := trim(text, Span{Start: start, End: end}); ok {
+			spans = append(spans, span)
+		}
+		if end == len(text) {
+			break
+		}
+
+		next := runeBoundary(text, end-f.overlap)
+		if next <= start {
+			next = end
+		}
+		start = next
+	}
+
+	return spans
+}
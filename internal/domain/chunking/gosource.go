@@ -0,0 +1,63 @@
+package chunking
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+)
+
+// GoSplitter splits Go source on top-level declarations, keeping each
+// declaration together with its doc comment. The package clause and imports
+// form the first segment. Small adjacent declarations are packed together and
+// declarations longer than size are split by paragraph. Text that does not
+// parse as a Go file is split by paragraph.
+type GoSplitter struct {
+	size int
+}
+
+// NewGoSplitter creates a Go source splitter
+func NewGoSplitter(size int) *GoSplitter {
+	return &GoSplitter{size: size}
+}
+
+// Split implements Splitter
+func (g *GoSplitter) Split(text string) []Span {
+	paragraphs := NewParagraphSplitter(g.size)
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", text, parser.ParseComments)
+	if err != nil {
+		return paragraphs.Split(text)
+	}
+
+	var boundaries []int
+	for _, decl := range file.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.IMPORT {
+			continue
+		}
+		start := decl.Pos()
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Doc != nil {
+				start = d.Doc.Pos()
+			}
+		case *ast.GenDecl:
+			if d.Doc != nil {
+				start = d.Doc.Pos()
+			}
+		}
+		boundaries = append(boundaries, fset.Position(start).Offset)
+	}
+
+	var segments []Span
+	prev := 0
+	for _, b := range boundaries {
+		if b > prev {
+			segments = append(segments, Span{Start: prev, End: b})
+			prev = b
+		}
+	}
+	segments = append(segments, Span{Start: prev, End: len(text)})
+
+	return pack(text, segments, g.size, paragraphs)
+}
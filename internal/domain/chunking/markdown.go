@@ -0,0 +1,64 @@
+package chunking
+
+import (
+	"regexp"
+	"strings"
+)
+
+var markdownHeading = regexp.MustCompile(`^#{1,6}\s`)
+
+// MarkdownSplitter splits Markdown into sections that start at a heading, so a
+// chunk never spans two sections. Headings inside fenced code blocks are
+// ignored. Sections longer than size are split by paragraph.
+type MarkdownSplitter struct {
+	size int
+}
+
+// NewMarkdownSplitter creates a Markdown splitter
+func NewMarkdownSplitter(size int) *MarkdownSplitter {
+	return &MarkdownSplitter{size: size}
+}
+
+// Split implements Splitter
+func (m *MarkdownSplitter) Split(text string) []Span {
+	var spans []Span
+	paragraphs := NewParagraphSplitter(m.size)
+
+	emit := func(section Span) {
+		section, ok := trim(text, section)
+		if !ok {
+			return
+		}
+		if section.End-section.Start <= m.size {
+			spans = append(spans, section)
+			return
+		}
+		for _, sub := range paragraphs.Split(text[section.Start:section.End]) {
+			spans = append(spans, Span{Start: section.Start + sub.Start, End: section.Start + sub.End})
+		}
+	}
+
+	sectionStart := 0
+	inFence := false
+	for offset := 0; offset < len(text); {
+		lineEnd := strings.IndexByte(text[offset:], '\n')
+		if lineEnd < 0 {
+			lineEnd = len(text)
+		} else {
+			lineEnd += offset + 1
+		}
+		line := strings.TrimLeft(text[offset:lineEnd], " ")
+
+		switch {
+		case strings.HasPrefix(line, "```") || strings.HasPrefix(line, "~~~"):
+			inFence = !inFence
+		case !inFence && markdownHeading.MatchString(line) && offset > sectionStart:
+			emit(Span{Start: sectionStart, End: offset})
+			sectionStart = offset
+		}
+		offset = lineEnd
+	}
+	emit(Span{Start: sectionStart, End: len(text)})
+
+	return spans
+}
@@ -0,0 +1,56 @@
+package chunking
+
+import (
+	"regexp"
+)
+
+var (
+	sentenceEnd    = regexp.MustCompile(`[.!?]+["')\]]*\s+`)
+	paragraphBreak = regexp.MustCompile(`\n[ \t]*\n\s*`)
+)
+
+// SentenceSplitter packs whole sentences into chunks of at most size bytes.
+// Sentences longer than size are split into fixed-size windows.
+type SentenceSplitter struct {
+	size int
+}
+
+// NewSentenceSplitter creates a sentence splitter
+func NewSentenceSplitter(size int) *SentenceSplitter {
+	return &SentenceSplitter{size: size}
+}
+
+// Split implements Splitter
+func (s *SentenceSplitter) Split(text string) []Span {
+	return pack(text, splitAfter(text, sentenceEnd), s.size, NewFixedSplitter(s.size, 0))
+}
+
+// ParagraphSplitter packs whole paragraphs (separated by blank lines) into
+// chunks of at most size bytes. Paragraphs longer than size are split by sentence.
+type ParagraphSplitter struct {
+	size int
+}
+
+// NewParagraphSplitter creates a paragraph splitter
+func NewParagraphSplitter(size int) *ParagraphSplitter {
+	return &ParagraphSplitter{size: size}
+}
+
+// Split implements Splitter
+func (p *ParagraphSplitter) Split(text string) []Span {
+	return pack(text, splitAfter(text, paragraphBreak), p.size, NewSentenceSplitter(p.size))
+}
+
+// splitAfter cuts text into segments ending after each match of sep
+func splitAfter(text string, sep *regexp.Regexp) []Span {
+	var segments []Span
+	start := 0
+	for _, loc := range sep.FindAllStringIndex(text, -1) {
+		segments = append(segments, Span{Start: start, End: loc[1]})
+		start = loc[1]
+	}
+	if start < len(text) {
+		segments = append(segments, Span{Start: start, End: len(text)})
+	}
+	return segments
+}
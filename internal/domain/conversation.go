@@ -0,0 +1,222 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/robstave/gorag/internal/domain/llm"
+	"github.com/robstave/gorag/internal/domain/prompt"
+	"github.com/robstave/gorag/internal/domain/types"
+)
+
+// conversationHistoryMessages is how many of the latest messages are used to
+// rewrite a follow-up question and are replayed to the model when answering
+const conversationHistoryMessages = 6
+
+// conversationTitleLength caps the title taken from a conversation's first question
+const conversationTitleLength = 80
+
+func (s *Service) CreateConversation(title string) (*types.Conversation, error) {
+	s.logger.Info("Creating conversation", "title", title)
+
+	conversation := types.Conversation{
+		ID:    uuid.New().String(),
+		Title: strings.TrimSpace(title),
+	}
+	if err := s.repo.CreateConversation(conversation); err != nil {
+		s.logger.Error("Failed to create conversation", "error", err)
+		return nil, err
+	}
+
+	return &conversation, nil
+}
+
+func (s *Service) GetConversations() ([]types.Conversation, error) {
+	s.logger.Info("Retrieving all conversations")
+
+	conversations, err := s.repo.GetConversations()
+	if err != nil {
+		s.logger.Error("Error retrieving conversations", "error", err)
+		return nil, err
+	}
+
+	return conversations, nil
+}
+
+// GetConversationByID returns the conversation with all of its messages
+func (s *Service) GetConversationByID(conversationID string) (*types.Conversation, error) {
+	s.logger.Info("Retrieving conversation by ID", "conversationID", conversationID)
+
+	conversation, err := s.repo.GetConversation(conversationID)
+	if err != nil {
+		s.logger.Error("Error retrieving conversation", "error", err)
+		return nil, err
+	}
+
+	if conversation == nil {
+		s.logger.Warn("conversation not found", "conversationID", conversationID)
+		return nil, types.ErrConversationNotFound
+	}
+
+	return conversation, nil
+}
+
+func (s *Service) DeleteConversation(conversationID string) error {
+	s.logger.Info("Deleting conversation", "id", conversationID)
+
+	if _, err := s.GetConversationByID(conversationID); err != nil {
+		return err
+	}
+
+	if err := s.repo.DeleteConversation(conversationID); err != nil {
+		s.logger.Error("Failed to delete conversation", "error", err)
+		return err
+	}
+
+	return nil
+}
+
+// SendMessage answers request.Question as the next turn of the conversation.
+// The question and the recent history are first condensed into a standalone
+// query for retrieval; the answer is then generated from the retrieved
+// sources with the history replayed before the question. The question and
+// answer are stored together once the answer is complete.
+func (s *Service) SendMessage(ctx context.Context, conversationID string, request types.AskRequest) (*types.ConversationTurn, error) {
+	s.logger.Info("Answering conversation message", "conversationID", conversationID, "question", request.Question)
+
+	if s.chatModel == nil {
+		s.logger.Error("Message posted but no chat model is configured")
+		return nil, types.ErrLLMUnavailable
+	}
+
+	if !s.prompts.Has(request.Template) {
+		return nil, fmt.Errorf("%w: %q", types.ErrUnknownPromptTemplate, request.Template)
+	}
+
+	conversation, err := s.GetConversationByID(conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	history := conversation.Messages
+	if len(history) > conversationHistoryMessages {
+		history = history[len(history)-conversationHistoryMessages:]
+	}
+
+	query, usage := s.rewriteQuery(ctx, request.Template, history, request.Question)
+
+	sources, messages, err := s.prepareAsk(ctx, request, query, historyMessages(history))
+	if err != nil {
+		return nil, err
+	}
+
+	answer := types.ConversationMessage{
+		ConversationID: conversationID,
+		Role:           llm.RoleAssistant,
+		Content:        noSourcesAnswer,
+		Query:          query,
+		Sources:        sources,
+		Citations:      []types.Citation{},
+		Model:          s.chatModel.ModelID(),
+	}
+	if len(sources) > 0 {
+		reply, err := s.chatModel.Chat(ctx, messages)
+		if err != nil {
+			s.logger.Error("Failed to generate answer", "model", s.chatModel.ModelID(), "error", err)
+			return nil, err
+		}
+		answer.Content = reply.Content
+		answer.Citations = citeAnswer(reply.Content, sources)
+		usage = usage.Add(reply.Usage)
+	}
+	answer.Usage = &usage
+
+	question := types.ConversationMessage{
+		ConversationID: conversationID,
+		Role:           llm.RoleUser,
+		Content:        request.Question,
+	}
+
+	if conversation.Title == "" {
+		conversation.Title = conversationTitle(request.Question)
+	}
+
+	turn := []types.ConversationMessage{question, answer}
+	if err := s.repo.AddConversationMessages(*conversation, turn); err != nil {
+		if errors.Is(err, types.ErrConversationNotFound) {
+			s.logger.Warn("Conversation was deleted while answering", "conversationID", conversationID)
+			return nil, err
+		}
+		s.logger.Error("Failed to save conversation messages", "error", err)
+		return nil, err
+	}
+
+	return &types.ConversationTurn{
+		ConversationID: conversationID,
+		Question:       turn[0],
+		Answer:         turn[1],
+	}, nil
+}
+
+// rewriteQuery asks the chat model to condense history and a follow-up
+// question into a standalone search query using the rewrite template of the
+// named prompt set. The first question of a conversation is used as is. If
+// rewriting fails the question is used as is.
+func (s *Service) rewriteQuery(ctx context.Context, template string, history []types.ConversationMessage, question string) (string, types.TokenUsage) {
+	if len(history) == 0 {
+		return question, types.TokenUsage{}
+	}
+
+	messages := make([]types.ConversationMessage, 0, len(history))
+	for _, message := range history {
+		message.Content = stripCitations(message.Content)
+		messages = append(messages, message)
+	}
+	rewritePrompt, err := s.prompts.Render(template, prompt.Rewrite, prompt.RewriteData{
+		Question: question,
+		Messages: messages,
+	})
+	if err != nil {
+		s.logger.Warn("Failed to render rewrite prompt, searching with the question unchanged", "template", template, "error", err)
+		return question, types.TokenUsage{}
+	}
+
+	reply, err := s.chatModel.Chat(ctx, []llm.Message{{Role: llm.RoleUser, Content: rewritePrompt}})
+	if err != nil {
+		s.logger.Warn("Failed to rewrite question, searching with it unchanged", "error", err)
+		return question, types.TokenUsage{}
+	}
+
+	query := strings.Trim(strings.TrimSpace(reply.Content), `"'`)
+	if query == "" {
+		return question, reply.Usage
+	}
+
+	s.logger.Info("Rewrote follow-up question", "question", question, "query", query)
+	return query, reply.Usage
+}
+
+// historyMessages converts stored messages to chat messages. Citation markers
+// are removed since they number sources that are no longer in the prompt.
+func historyMessages(history []types.ConversationMessage) []llm.Message {
+	messages := make([]llm.Message, 0, len(history))
+	for _, message := range history {
+		messages = append(messages, llm.Message{
+			Role:    message.Role,
+			Content: stripCitations(message.Content),
+		})
+	}
+	return messages
+}
+
+// conversationTitle derives a title from the first question of a conversation
+func conversationTitle(question string) string {
+	title := strings.Join(strings.Fields(question), " ")
+	if runes := []rune(title); len(runes) > conversationTitleLength {
+		title = strings.TrimSpace(string(runes[:conversationTitleLength-3])) + "..."
+	}
+	return title
+}
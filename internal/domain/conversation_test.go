@@ -0,0 +1,77 @@
+package domain
+
+import (
+	"context"
+	"testing"
+
+	"github.com/robstave/gorag/internal/adapters/repositories"
+	"github.com/robstave/gorag/internal/domain/llm"
+	"github.com/robstave/gorag/internal/domain/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// deletingLLM deletes a conversation while answering, like a user removing
+// it during a slow generation
+type deletingLLM struct {
+	*llm.MockLLM
+	repo           repositories.Repository
+	conversationID string
+}
+
+func (l *deletingLLM) Chat(ctx context.Context, messages []llm.Message) (*llm.Response, error) {
+	if err := l.repo.DeleteConversation(l.conversationID); err != nil {
+		return nil, err
+	}
+	return l.MockLLM.Chat(ctx, messages)
+}
+
+func newConversationService(t *testing.T) (*Service, repositories.Repository) {
+	t.Helper()
+
+	service, repo := newTestService(t, newMemoryStore(t))
+	service.prompts = newTestPrompts(t)
+	service.chatModel = llm.NewMockLLM()
+
+	require.NoError(t, repo.Createdocument(types.Document{ID: "otters", Name: "otters", Value: "Sea otters hold hands while they sleep."}))
+	require.NoError(t, service.DispatchOutbox())
+	return service, repo
+}
+
+func TestSendMessageSavesTurn(t *testing.T) {
+	service, repo := newConversationService(t)
+
+	conversation, err := service.CreateConversation("")
+	require.NoError(t, err)
+
+	turn, err := service.SendMessage(context.Background(), conversation.ID, types.AskRequest{Question: "How do sea otters sleep?"})
+	require.NoError(t, err)
+	assert.Equal(t, conversation.ID, turn.ConversationID)
+
+	stored, err := repo.GetConversation(conversation.ID)
+	require.NoError(t, err)
+	require.NotNil(t, stored)
+	assert.Equal(t, "How do sea otters sleep?", stored.Title)
+	require.Len(t, stored.Messages, 2)
+	assert.Equal(t, llm.RoleUser, stored.Messages[0].Role)
+	assert.Equal(t, llm.RoleAssistant, stored.Messages[1].Role)
+}
+
+func TestSendMessageToDeletedConversation(t *testing.T) {
+	service, repo := newConversationService(t)
+
+	conversation, err := service.CreateConversation("Otters")
+	require.NoError(t, err)
+	service.chatModel = &deletingLLM{MockLLM: llm.NewMockLLM(), repo: repo, conversationID: conversation.ID}
+
+	_, err = service.SendMessage(context.Background(), conversation.ID, types.AskRequest{Question: "How do sea otters sleep?"})
+	assert.ErrorIs(t, err, types.ErrConversationNotFound)
+
+	stored, err := repo.GetConversation(conversation.ID)
+	require.NoError(t, err)
+	assert.Nil(t, stored, "a deleted conversation must not be re-created")
+
+	conversations, err := repo.GetConversations()
+	require.NoError(t, err)
+	assert.Empty(t, conversations)
+}
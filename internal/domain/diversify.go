@@ -0,0 +1,143 @@
+package domain
+
+import (
+	"context"
+	"math"
+
+	"github.com/robstave/gorag/internal/adapters/repositories/vectorstore"
+	"github.com/robstave/gorag/internal/domain/types"
+)
+
+// diversifyOverfetch is how many candidates per result are retrieved when
+// results are diversified, so there is something to choose from
+const diversifyOverfetch = 4
+
+// diversify selects up to limit results that cover distinct material. With a
+// lambda it runs Maximal Marginal Relevance, picking at each step the result
+// that maximises lambda * relevance - (1 - lambda) * its highest cosine
+// similarity to the results already picked. maxPerDocument, when positive,
+// caps how many chunks of one parent document are picked. Results are
+// expected in relevance order.
+func (s *Service) diversify(ctx context.Context, results []types.SearchResult, lambda *float64, maxPerDocument int, limit int) []types.SearchResult {
+	var embeddings [][]float32
+	if lambda != nil && len(results) > 0 {
+		embeddings = s.candidateEmbeddings(ctx, results)
+	}
+
+	selected := make([]types.SearchResult, 0, min(limit, len(results)))
+	picked := make([]bool, len(results))
+	var pickedIndexes []int
+	perDocument := make(map[string]int)
+
+	for len(selected) < limit {
+		best := -1
+		bestValue := math.Inf(-1)
+		for i, result := range results {
+			if picked[i] || (maxPerDocument > 0 && perDocument[result.Chunk.DocumentID] >= maxPerDocument) {
+				continue
+			}
+			if embeddings == nil {
+				// Without MMR take candidates in relevance order
+				best = i
+				break
+			}
+
+			redundancy := 0.0
+			for _, j := range pickedIndexes {
+				redundancy = math.Max(redundancy, cosineSimilarity(embeddings[i], embeddings[j]))
+			}
+			value := *lambda*relevance(result) - (1-*lambda)*redundancy
+			if value > bestValue {
+				best, bestValue = i, value
+			}
+		}
+		if best < 0 {
+			break
+		}
+
+		picked[best] = true
+		pickedIndexes = append(pickedIndexes, best)
+		perDocument[results[best].Chunk.DocumentID]++
+		selected = append(selected, results[best])
+	}
+	return selected
+}
+
+// candidateEmbeddings returns the embeddings of the results, read from the
+// vector store when it keeps them. Chunks the store does not return are only
+// embedded again when the embedder is cached, since embedding every
+// candidate on each search is too slow; without them it returns nil and
+// diversify keeps the relevance order.
+func (s *Service) candidateEmbeddings(ctx context.Context, results []types.SearchResult) [][]float32 {
+	embeddings := make([][]float32, len(results))
+
+	if reader, ok := s.vectorStore.(vectorstore.EmbeddingReader); ok {
+		ids := make([]string, len(results))
+		for i, result := range results {
+			ids[i] = result.Chunk.ID
+		}
+		stored, err := reader.GetEmbeddings(ids)
+		if err != nil {
+			s.logger.Warn("Failed to read stored embeddings for MMR", "error", err)
+		}
+		for i, id := range ids {
+			embeddings[i] = stored[id]
+		}
+	}
+
+	var missing []int
+	var texts []string
+	for i, embedding := range embeddings {
+		if embedding == nil {
+			missing = append(missing, i)
+			texts = append(texts, results[i].Chunk.Text)
+		}
+	}
+	if len(missing) > 0 {
+		if _, cached := s.embedService.(cacheStatser); !cached {
+			s.logger.Warn("Candidate embeddings are not stored and the embedder is not cached, keeping relevance order", "missing", len(missing))
+			return nil
+		}
+
+		embedded, err := s.embedService.Embed(ctx, texts)
+		if err != nil {
+			s.logger.Warn("Failed to embed candidates for MMR, keeping relevance order", "model", s.embedService.ModelID(), "error", err)
+			return nil
+		}
+		for j, i := range missing {
+			embeddings[i] = embedded[j]
+		}
+	}
+
+	// Entries written by an earlier model can have another dimension
+	for _, embedding := range embeddings {
+		if len(embedding) != len(embeddings[0]) {
+			s.logger.Warn("Candidate embeddings differ in dimension, keeping relevance order")
+			return nil
+		}
+	}
+	return embeddings
+}
+
+// relevance returns the reranker's score when results were reranked and the
+// retrieval score otherwise
+func relevance(result types.SearchResult) float64 {
+	if result.RerankScore != nil {
+		return *result.RerankScore
+	}
+	return result.Score
+}
+
+// cosineSimilarity returns the cosine of the angle between a and b
+func cosineSimilarity(a, b []float32) float64 {
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
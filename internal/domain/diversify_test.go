@@ -0,0 +1,245 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"testing"
+
+	"github.com/robstave/gorag/internal/domain/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// textEmbedder returns a fixed embedding for each known text and counts the
+// texts it was asked to embed
+type textEmbedder struct {
+	vectors  map[string][]float32
+	err      error
+	embedded int
+}
+
+func (e *textEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	e.embedded += len(texts)
+	if e.err != nil {
+		return nil, e.err
+	}
+	embeddings := make([][]float32, len(texts))
+	for i, text := range texts {
+		embeddings[i] = e.vectors[text]
+	}
+	return embeddings, nil
+}
+
+func (e *textEmbedder) Dimension() int  { return 2 }
+func (e *textEmbedder) ModelID() string { return "test/text" }
+
+// cachedTextEmbedder is a textEmbedder that reports cache stats, so MMR may
+// embed candidates the store does not return
+type cachedTextEmbedder struct {
+	*textEmbedder
+}
+
+func (e cachedTextEmbedder) CacheStats() (hits, misses int64) { return 0, 0 }
+
+// candidate returns a search result for chunk id of document with the given
+// retrieval score; its text selects its embedding
+func candidate(id, document, text string, score float64) types.SearchResult {
+	return types.SearchResult{
+		ID:    id,
+		Score: score,
+		Chunk: types.Chunk{ID: id, DocumentID: document, Text: text},
+	}
+}
+
+func TestDiversify(t *testing.T) {
+	embedder := &textEmbedder{vectors: map[string][]float32{
+		"north":     {0, 1},
+		"north-ish": {0.1, 1},
+		"east":      {1, 0},
+		"diagonal":  {1, 1},
+	}}
+	lambda := func(v float64) *float64 { return &v }
+	rerankScore := func(v float64) *float64 { return &v }
+
+	candidates := []types.SearchResult{
+		candidate("a:0", "a", "north", 0.9),
+		candidate("a:1", "a", "north-ish", 0.88),
+		candidate("b:0", "b", "east", 0.7),
+		candidate("c:0", "c", "diagonal", 0.6),
+	}
+	reranked := []types.SearchResult{
+		candidate("a:0", "a", "north", 0.9),
+		candidate("b:0", "b", "east", 0.5),
+	}
+	reranked[0].RerankScore = rerankScore(0.2)
+	reranked[1].RerankScore = rerankScore(0.8)
+
+	tests := []struct {
+		name           string
+		results        []types.SearchResult
+		lambda         *float64
+		maxPerDocument int
+		limit          int
+		unstored       []string // candidates missing from the vector store
+		cached         bool
+		embedErr       error
+		want           []string
+	}{
+		{
+			name:    "no diversification keeps relevance order",
+			results: candidates,
+			limit:   3,
+			want:    []string{"a:0", "a:1", "b:0"},
+		},
+		{
+			name:           "per-document cap",
+			results:        candidates,
+			maxPerDocument: 1,
+			limit:          4,
+			want:           []string{"a:0", "b:0", "c:0"},
+		},
+		{
+			name:    "lambda 1 is relevance order",
+			results: candidates,
+			lambda:  lambda(1),
+			limit:   4,
+			want:    []string{"a:0", "a:1", "b:0", "c:0"},
+		},
+		{
+			name:    "mmr skips the near duplicate",
+			results: candidates,
+			lambda:  lambda(0.5),
+			limit:   2,
+			want:    []string{"a:0", "b:0"},
+		},
+		{
+			name:    "lambda 0 picks the least similar after the first",
+			results: candidates,
+			lambda:  lambda(0),
+			limit:   3,
+			want:    []string{"a:0", "b:0", "c:0"},
+		},
+		{
+			name:           "mmr with a per-document cap",
+			results:        candidates,
+			lambda:         lambda(0.9),
+			maxPerDocument: 1,
+			limit:          4,
+			want:           []string{"a:0", "b:0", "c:0"},
+		},
+		{
+			name:    "rerank scores are the relevance",
+			results: reranked,
+			lambda:  lambda(1),
+			limit:   2,
+			want:    []string{"b:0", "a:0"},
+		},
+		{
+			name:     "a cached embedder fills in unstored candidates",
+			results:  candidates,
+			lambda:   lambda(0.5),
+			limit:    2,
+			unstored: []string{"b:0"},
+			cached:   true,
+			want:     []string{"a:0", "b:0"},
+		},
+		{
+			name:     "unstored candidates are not embedded without a cache",
+			results:  candidates,
+			lambda:   lambda(0.5),
+			limit:    2,
+			unstored: []string{"b:0"},
+			want:     []string{"a:0", "a:1"},
+		},
+		{
+			name:           "embedding failure falls back to relevance order",
+			results:        candidates,
+			lambda:         lambda(0.5),
+			maxPerDocument: 1,
+			limit:          2,
+			unstored:       []string{"b:0"},
+			cached:         true,
+			embedErr:       errors.New("embedder down"),
+			want:           []string{"a:0", "b:0"},
+		},
+		{
+			name:   "no candidates",
+			lambda: lambda(0.5),
+			limit:  3,
+			want:   []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			embedder.err = tt.embedErr
+			embedder.embedded = 0
+			service := &Service{
+				logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+				vectorStore:  newMemoryStore(t),
+				embedService: embedder,
+			}
+			if tt.cached {
+				service.embedService = cachedTextEmbedder{embedder}
+			}
+
+			var chunks []types.Chunk
+			var embeddings [][]float32
+			for _, result := range tt.results {
+				if !slices.Contains(tt.unstored, result.ID) {
+					chunks = append(chunks, result.Chunk)
+					embeddings = append(embeddings, embedder.vectors[result.Chunk.Text])
+				}
+			}
+			require.NoError(t, service.vectorStore.AddChunks(chunks, embeddings))
+
+			selected := service.diversify(context.Background(), tt.results, tt.lambda, tt.maxPerDocument, tt.limit)
+			ids := make([]string, len(selected))
+			for i, result := range selected {
+				ids[i] = result.ID
+			}
+			assert.Equal(t, tt.want, ids)
+
+			// Stored embeddings are used as they are; only a cached embedder
+			// is asked for the missing ones
+			if tt.cached && tt.lambda != nil {
+				assert.Equal(t, len(tt.unstored), embedder.embedded)
+			} else {
+				assert.Zero(t, embedder.embedded)
+			}
+		})
+	}
+}
+
+func TestCosineSimilarity(t *testing.T) {
+	assert.InDelta(t, 1, cosineSimilarity([]float32{1, 2}, []float32{2, 4}), 1e-9)
+	assert.InDelta(t, 0, cosineSimilarity([]float32{1, 0}, []float32{0, 3}), 1e-9)
+	assert.InDelta(t, -1, cosineSimilarity([]float32{1, 1}, []float32{-1, -1}), 1e-9)
+	assert.Equal(t, 0.0, cosineSimilarity([]float32{0, 0}, []float32{1, 1}))
+}
+
+func TestDiversifyDimensionMismatch(t *testing.T) {
+	embedder := &textEmbedder{vectors: map[string][]float32{"north": {0, 1}}}
+	service := &Service{
+		logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+		vectorStore:  newMemoryStore(t),
+		embedService: cachedTextEmbedder{embedder},
+	}
+	// One candidate was stored by a model with another dimension
+	require.NoError(t, service.vectorStore.AddChunks(
+		[]types.Chunk{{ID: "a:0", DocumentID: "a"}, {ID: "a:1", DocumentID: "a"}},
+		[][]float32{{0, 1}, {1, 0, 0}},
+	))
+
+	lambda := 0.5
+	selected := service.diversify(context.Background(), []types.SearchResult{
+		candidate("a:0", "a", "north", 0.9),
+		candidate("a:1", "a", "north", 0.8),
+	}, &lambda, 0, 2)
+	require.Len(t, selected, 2)
+	assert.Equal(t, "a:0", selected[0].ID)
+	assert.Equal(t, "a:1", selected[1].ID)
+}
@@ -45,6 +45,16 @@ func (s *Service) Createdocument(document types.Document) (*types.Document, erro
 		document.ID = uuid.New().String()
 	}
 
+	if document.Collection == "" {
+		document.Collection = types.DefaultCollection
+	}
+
+	if err := document.ValidateMetadata(); err != nil {
+		s.logger.Warn("Rejected document metadata", "name", document.Name, "error", err)
+		return nil, err
+	}
+
+	// The repository queues the vector store upsert in the same transaction
 	if err := s.repo.Createdocument(document); err != nil {
 		s.logger.Error("Failed to create document", "error", err)
 		return nil, err
@@ -68,6 +78,26 @@ func (s *Service) Updatedocument(document types.Document) (*types.Document, erro
 		return nil, errors.New("document not found")
 	}
 
+	// Keep the existing collection, creation time, metadata and tags unless given
+	if document.Collection == "" {
+		document.Collection = existingdocument.Collection
+	}
+	if document.CreatedAt.IsZero() {
+		document.CreatedAt = existingdocument.CreatedAt
+	}
+	if document.Metadata == nil {
+		document.Metadata = existingdocument.Metadata
+	}
+	if document.Tags == nil {
+		document.Tags = existingdocument.Tags
+	}
+
+	if err := document.ValidateMetadata(); err != nil {
+		s.logger.Warn("Rejected document metadata", "id", document.ID, "error", err)
+		return nil, err
+	}
+
+	// The repository queues the vector store upsert in the same transaction
 	if err := s.repo.Updatedocument(document); err != nil {
 		s.logger.Error("Failed to update document", "error", err)
 		return nil, err
@@ -91,6 +121,7 @@ func (s *Service) Deletedocument(documentID string) error {
 		return errors.New("document not found")
 	}
 
+	// The repository queues the vector store delete in the same transaction
 	if err := s.repo.Deletedocument(documentID); err != nil {
 		s.logger.Error("Failed to delete document", "error", err)
 		return err
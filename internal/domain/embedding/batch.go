@@ -0,0 +1,213 @@
+package embedding
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+)
+
+// defaultMaxRetries is the number of retries when BatchConfig.MaxRetries is not set
+const defaultMaxRetries = 5
+
+// BatchConfig controls how BatchEmbedder splits and retries requests
+type BatchConfig struct {
+	// BatchSize is the maximum number of texts sent in one request (default 64)
+	BatchSize int
+	// Concurrency is the maximum number of requests in flight (default 4)
+	Concurrency int
+	// MaxRetries is the number of retries after a failed request. nil uses the
+	// default of 5 and 0 disables retries.
+	MaxRetries *int
+	// BaseBackoff is the initial retry delay, doubled on every attempt (default 500ms)
+	BaseBackoff time.Duration
+	// MaxBackoff caps the retry delay (default 30s)
+	MaxBackoff time.Duration
+	// TokensPerMinute limits the estimated tokens sent per minute, 0 for no limit
+	TokensPerMinute int
+}
+
+func (c BatchConfig) withDefaults() BatchConfig {
+	if c.BatchSize <= 0 {
+		c.BatchSize = 64
+	}
+	if c.Concurrency <= 0 {
+		c.Concurrency = 4
+	}
+	if c.MaxRetries == nil {
+		retries := defaultMaxRetries
+		c.MaxRetries = &retries
+	} else if *c.MaxRetries < 0 {
+		retries := 0
+		c.MaxRetries = &retries
+	}
+	if c.BaseBackoff <= 0 {
+		c.BaseBackoff = 500 * time.Millisecond
+	}
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = 30 * time.Second
+	}
+	return c
+}
+
+// BatchEmbedder wraps an Embedder, splitting large inputs into batches that
+// are sent by a bounded pool of workers. Failed batches are retried with
+// exponential backoff and full jitter, honouring the server's Retry-After.
+type BatchEmbedder struct {
+	inner   Embedder
+	config  BatchConfig
+	limiter *tokenLimiter
+	logger  *slog.Logger
+}
+
+// NewBatchEmbedder wraps inner with batching, concurrency limits and retries
+func NewBatchEmbedder(inner Embedder, config BatchConfig, logger *slog.Logger) *BatchEmbedder {
+	config = config.withDefaults()
+
+	embedder := &BatchEmbedder{
+		inner:  inner,
+		config: config,
+		logger: logger,
+	}
+	if config.TokensPerMinute > 0 {
+		embedder.limiter = newTokenLimiter(config.TokensPerMinute)
+	}
+	return embedder
+}
+
+// Embed splits texts into batches and embeds them concurrently
+func (b *BatchEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) <= b.config.BatchSize {
+		return b.embedWithRetry(ctx, texts)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	embeddings := make([][]float32, len(texts))
+	sem := make(chan struct{}, b.config.Concurrency)
+
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+
+	for start := 0; start < len(texts); start += b.config.BatchSize {
+		end := start + b.config.BatchSize
+		if end > len(texts) {
+			end = len(texts)
+		}
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			batch, err := b.embedWithRetry(ctx, texts[start:end])
+			if err != nil {
+				once.Do(func() {
+					firstErr = err
+					cancel()
+				})
+				return
+			}
+			copy(embeddings[start:end], batch)
+		}(start, end)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return embeddings, nil
+}
+
+// embedWithRetry embeds a single batch, retrying transient failures. Every
+// attempt is counted against the token limit, since a failed request may
+// still have used the provider's quota.
+func (b *BatchEmbedder) embedWithRetry(ctx context.Context, texts []string) ([][]float32, error) {
+	tokens := estimateTokens(texts)
+
+	for attempt := 0; ; attempt++ {
+		if b.limiter != nil {
+			if err := b.limiter.Wait(ctx, tokens); err != nil {
+				return nil, err
+			}
+		}
+
+		embeddings, err := b.inner.Embed(ctx, texts)
+		if err == nil {
+			return embeddings, nil
+		}
+		if attempt >= *b.config.MaxRetries || !retryable(err) || ctx.Err() != nil {
+			return nil, err
+		}
+
+		delay := b.backoff(attempt, err)
+		b.logger.Warn("Embedding request failed, retrying", "model", b.inner.ModelID(), "attempt", attempt+1, "retryIn", delay, "error", err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns the delay before the next attempt: the server's Retry-After
+// if given, otherwise a random delay up to BaseBackoff * 2^attempt
+func (b *BatchEmbedder) backoff(attempt int, err error) time.Duration {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		if apiErr.RetryAfter > b.config.MaxBackoff {
+			return b.config.MaxBackoff
+		}
+		return apiErr.RetryAfter
+	}
+
+	ceiling := b.config.BaseBackoff << attempt
+	if ceiling <= 0 || ceiling > b.config.MaxBackoff {
+		ceiling = b.config.MaxBackoff
+	}
+	return time.Duration(rand.Int63n(int64(ceiling))) + time.Millisecond
+}
+
+// retryable reports whether err is a transient failure worth retrying
+func retryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Retryable()
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// Dimension returns the dimension of the wrapped embedder
+func (b *BatchEmbedder) Dimension() int {
+	return b.inner.Dimension()
+}
+
+// ModelID returns the model ID of the wrapped embedder
+func (b *BatchEmbedder) ModelID() string {
+	return b.inner.ModelID()
+}
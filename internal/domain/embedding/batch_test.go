@@ -0,0 +1,138 @@
+package embedding
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyEmbedder fails its first failures calls with err, then embeds each
+// text as a one-dimensional vector of its length
+type flakyEmbedder struct {
+	mu       sync.Mutex
+	failures int
+	err      error
+	calls    int
+	batches  [][]string
+}
+
+func (f *flakyEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls++
+	if f.calls <= f.failures {
+		return nil, f.err
+	}
+	f.batches = append(f.batches, texts)
+
+	embeddings := make([][]float32, len(texts))
+	for i, text := range texts {
+		embeddings[i] = []float32{float32(len(text))}
+	}
+	return embeddings, nil
+}
+
+func (f *flakyEmbedder) Dimension() int  { return 1 }
+func (f *flakyEmbedder) ModelID() string { return "test/flaky" }
+
+var errRateLimited = &APIError{Provider: "test", StatusCode: http.StatusTooManyRequests, Status: "429 Too Many Requests"}
+
+func retries(n int) *int {
+	return &n
+}
+
+func newTestBatchEmbedder(inner Embedder, config BatchConfig) *BatchEmbedder {
+	config.BaseBackoff = time.Millisecond
+	config.MaxBackoff = time.Millisecond
+	return NewBatchEmbedder(inner, config, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestBatchEmbedderRetries(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxRetries *int
+		failures   int
+		err        error
+		wantCalls  int
+		wantErr    bool
+	}{
+		{name: "default retries", maxRetries: nil, failures: 5, err: errRateLimited, wantCalls: 6},
+		{name: "default gives up", maxRetries: nil, failures: 6, err: errRateLimited, wantCalls: 6, wantErr: true},
+		{name: "zero disables retries", maxRetries: retries(0), failures: 1, err: errRateLimited, wantCalls: 1, wantErr: true},
+		{name: "negative disables retries", maxRetries: retries(-1), failures: 1, err: errRateLimited, wantCalls: 1, wantErr: true},
+		{name: "explicit retries", maxRetries: retries(2), failures: 2, err: errRateLimited, wantCalls: 3},
+		{name: "client errors are not retried", maxRetries: retries(3), failures: 1, err: &APIError{StatusCode: http.StatusBadRequest}, wantCalls: 1, wantErr: true},
+		{name: "other errors are not retried", maxRetries: retries(3), failures: 1, err: errors.New("bad input"), wantCalls: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := &flakyEmbedder{failures: tt.failures, err: tt.err}
+			embedder := newTestBatchEmbedder(inner, BatchConfig{MaxRetries: tt.maxRetries})
+
+			_, err := embedder.Embed(context.Background(), []string{"text"})
+			assert.Equal(t, tt.wantErr, err != nil, "error: %v", err)
+			assert.Equal(t, tt.wantCalls, inner.calls)
+		})
+	}
+}
+
+func TestBatchEmbedderSplitsBatches(t *testing.T) {
+	inner := &flakyEmbedder{}
+	embedder := newTestBatchEmbedder(inner, BatchConfig{BatchSize: 2, Concurrency: 2})
+
+	texts := []string{"a", "bb", "ccc", "dddd", "eeeee"}
+	embeddings, err := embedder.Embed(context.Background(), texts)
+	require.NoError(t, err)
+
+	// Results keep the input order whatever order the batches finish in
+	require.Len(t, embeddings, len(texts))
+	for i, text := range texts {
+		assert.Equal(t, []float32{float32(len(text))}, embeddings[i])
+	}
+	assert.Len(t, inner.batches, 3)
+	for _, batch := range inner.batches {
+		assert.LessOrEqual(t, len(batch), 2)
+	}
+}
+
+func TestBatchEmbedderCountsRetriesAgainstTokenLimit(t *testing.T) {
+	inner := &flakyEmbedder{failures: 1, err: errRateLimited}
+	embedder := newTestBatchEmbedder(inner, BatchConfig{TokensPerMinute: 60000})
+
+	texts := []string{strings.Repeat("x", 396)}
+	_, err := embedder.Embed(context.Background(), texts)
+	require.NoError(t, err)
+	assert.Equal(t, 2, inner.calls)
+
+	// Both attempts took their tokens; allow for the refill while the test ran
+	perAttempt := float64(estimateTokens(texts))
+	embedder.limiter.mu.Lock()
+	remaining := embedder.limiter.tokens
+	embedder.limiter.mu.Unlock()
+	assert.InDelta(t, 60000-2*perAttempt, remaining, perAttempt/2)
+}
+
+func TestTokenLimiterWaits(t *testing.T) {
+	limiter := newTokenLimiter(6000)
+	require.NoError(t, limiter.Wait(context.Background(), 6000))
+
+	// The bucket is empty, so 10 tokens take about 100ms to refill
+	started := time.Now()
+	require.NoError(t, limiter.Wait(context.Background(), 10))
+	assert.GreaterOrEqual(t, time.Since(started), 50*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, limiter.Wait(ctx, 6000), context.Canceled)
+}
@@ -0,0 +1,114 @@
+package embedding
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"sync/atomic"
+)
+
+// Cache stores embeddings keyed by model, dimension and text hash
+type Cache interface {
+	// GetCachedEmbeddings returns the cached embeddings for the given hashes.
+	// A dimension of 0 matches any dimension.
+	GetCachedEmbeddings(model string, dimension int, hashes []string) (map[string][]float32, error)
+
+	// PutCachedEmbeddings stores embeddings keyed by text hash
+	PutCachedEmbeddings(model string, embeddings map[string][]float32) error
+}
+
+// CachedEmbedder wraps an Embedder with a persistent cache so identical text
+// is only embedded once per model. Cache failures are logged and the request
+// falls through to the wrapped embedder.
+type CachedEmbedder struct {
+	inner  Embedder
+	cache  Cache
+	logger *slog.Logger
+
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// NewCachedEmbedder wraps inner with cache
+func NewCachedEmbedder(inner Embedder, cache Cache, logger *slog.Logger) *CachedEmbedder {
+	return &CachedEmbedder{
+		inner:  inner,
+		cache:  cache,
+		logger: logger,
+	}
+}
+
+// Embed returns cached embeddings where available and embeds the rest
+func (c *CachedEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	model := c.inner.ModelID()
+
+	hashes := make([]string, len(texts))
+	for i, text := range texts {
+		hashes[i] = TextHash(text)
+	}
+
+	cached, err := c.cache.GetCachedEmbeddings(model, c.inner.Dimension(), hashes)
+	if err != nil {
+		c.logger.Warn("Failed to read embedding cache", "model", model, "error", err)
+		cached = map[string][]float32{}
+	}
+
+	embeddings := make([][]float32, len(texts))
+	var missTexts []string
+	var missIndexes []int
+	for i, hash := range hashes {
+		if embedding, ok := cached[hash]; ok {
+			embeddings[i] = embedding
+			continue
+		}
+		missTexts = append(missTexts, texts[i])
+		missIndexes = append(missIndexes, i)
+	}
+
+	c.hits.Add(int64(len(texts) - len(missTexts)))
+	c.misses.Add(int64(len(missTexts)))
+
+	if len(missTexts) == 0 {
+		return embeddings, nil
+	}
+
+	fresh, err := c.inner.Embed(ctx, missTexts)
+	if err != nil {
+		return nil, err
+	}
+
+	toStore := make(map[string][]float32, len(fresh))
+	for j, embedding := range fresh {
+		i := missIndexes[j]
+		embeddings[i] = embedding
+		toStore[hashes[i]] = embedding
+	}
+
+	if err := c.cache.PutCachedEmbeddings(model, toStore); err != nil {
+		c.logger.Warn("Failed to write embedding cache", "model", model, "error", err)
+	}
+
+	return embeddings, nil
+}
+
+// CacheStats returns the number of cache hits and misses since startup
+func (c *CachedEmbedder) CacheStats() (hits, misses int64) {
+	return c.hits.Load(), c.misses.Load()
+}
+
+// Dimension returns the dimension of the wrapped embedder
+func (c *CachedEmbedder) Dimension() int {
+	return c.inner.Dimension()
+}
+
+// ModelID returns the model ID of the wrapped embedder
+func (c *CachedEmbedder) ModelID() string {
+	return c.inner.ModelID()
+}
+
+// TextHash returns the hex-encoded SHA-256 of text, used as the cache key
+func TextHash(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
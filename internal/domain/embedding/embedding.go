@@ -1,115 +1,83 @@
 package embedding
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
-	"io"
 	"log/slog"
-	"net/http"
-	"os"
-	"strings"
 	"time"
 )
 
-// EmbeddingService handles the generation of embeddings for documents
-type EmbeddingService struct {
-	client    *http.Client
-	apiKey    string
-	model     string
-	endpoint  string
-	dimension int
-	logger    *slog.Logger
-}
-
-// NewOpenAIEmbeddingService creates a new embedding service using OpenAI
-func NewOpenAIEmbeddingService(logger *slog.Logger) *EmbeddingService {
-	apiKey := os.Getenv("OPENAI_API_KEY")
-	model := "text-embedding-3-small"
-	if m := os.Getenv("OPENAI_EMBEDDING_MODEL"); m != "" {
-		model = m
-	}
+// Embedder generates embeddings for text
+type Embedder interface {
+	// Embed returns one embedding per input text, in the same order
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
 
-	dimension := 1536 // default for text-embedding-3-small
+	// Dimension returns the length of the embeddings produced, or 0 if it is
+	// not known until the first embedding has been created
+	Dimension() int
 
-	return &EmbeddingService{
-		client: &http.Client{
-			Timeout: time.Second * 30,
-		},
-		apiKey:    apiKey,
-		model:     model,
-		endpoint:  "https://api.openai.com/v1/embeddings",
-		dimension: dimension,
-		logger:    logger,
-	}
+	// ModelID identifies the provider and model, e.g. "openai/text-embedding-3-small"
+	ModelID() string
 }
 
-// CreateEmbedding generates an embedding for the given text
-func (s *EmbeddingService) CreateEmbedding(text string) ([]float32, error) {
-	if s.apiKey == "" {
-		return nil, fmt.Errorf("OpenAI API key not set")
-	}
-
-	// Clean up text - remove excessive whitespace
-	text = strings.TrimSpace(text)
-
-	// Create request to OpenAI
-	reqBody := map[string]interface{}{
-		"input": text,
-		"model": s.model,
-	}
-
-	jsonData, err := json.Marshal(reqBody)
-	if err != nil {
-		s.logger.Error("Failed to marshal embedding request", "error", err)
-		return nil, err
-	}
-
-	req, err := http.NewRequest("POST", s.endpoint, bytes.NewBuffer(jsonData))
-	if err != nil {
-		s.logger.Error("Failed to create embedding request", "error", err)
-		return nil, err
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", s.apiKey))
-
-	resp, err := s.client.Do(req)
-	if err != nil {
-		s.logger.Error("Failed to call OpenAI API", "error", err)
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		s.logger.Error("OpenAI API error", "status", resp.Status, "body", string(body))
-		return nil, fmt.Errorf("OpenAI API error: %s", resp.Status)
-	}
-
-	// Parse response
-	var result struct {
-		Object string `json:"object"`
-		Data   []struct {
-			Embedding []float32 `json:"embedding"`
-		} `json:"data"`
-	}
+// Providers accepted by Config.Provider
+const (
+	ProviderOpenAI           = "openai"
+	ProviderOpenAICompatible = "openai-compatible"
+	ProviderOllama           = "ollama"
+	ProviderLocal            = "local"
+	ProviderMock             = "mock"
+)
 
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		s.logger.Error("Failed to decode embedding response", "error", err)
-		return nil, err
-	}
+// Config selects and configures an embedding provider
+type Config struct {
+	Provider string
+	Model    string
+	// BaseURL overrides the provider's default endpoint
+	BaseURL string
+	APIKey  string
+	// Dimension overrides the model's default dimension. For OpenAI v3 models
+	// it is also sent to the API to shorten the returned embeddings.
+	Dimension int
+	// Timeout bounds a single HTTP request (default 30s)
+	Timeout time.Duration
+
+	// Batching applies to the remote providers
+	Batch BatchConfig
+}
 
-	if len(result.Data) == 0 || len(result.Data[0].Embedding) == 0 {
-		return nil, fmt.Errorf("empty embedding result")
+func (c Config) timeout() time.Duration {
+	if c.Timeout > 0 {
+		return c.Timeout
 	}
-
-	return result.Data[0].Embedding, nil
+	return time.Second * 30
 }
 
-// GetEmbeddingDimension returns the dimension of embeddings from this service
-func (s *EmbeddingService) GetEmbeddingDimension() int {
-	return s.dimension
+// New creates the embedder selected by the configuration
+func New(config Config, logger *slog.Logger) (Embedder, error) {
+	switch config.Provider {
+	case "", ProviderOpenAI:
+		if config.BaseURL == "" {
+			config.BaseURL = openAIBaseURL
+		}
+		return NewBatchEmbedder(NewOpenAIEmbedder(ProviderOpenAI, config, logger), config.Batch, logger), nil
+	case ProviderOpenAICompatible:
+		if config.BaseURL == "" {
+			return nil, fmt.Errorf("base URL is required for provider %s", config.Provider)
+		}
+		if config.Model == "" {
+			return nil, fmt.Errorf("model is required for provider %s", config.Provider)
+		}
+		return NewBatchEmbedder(NewOpenAIEmbedder(ProviderOpenAICompatible, config, logger), config.Batch, logger), nil
+	case ProviderOllama:
+		return NewBatchEmbedder(NewOllamaEmbedder(config, logger), config.Batch, logger), nil
+	case ProviderLocal:
+		return NewLocalEmbedder(config.Dimension), nil
+	case ProviderMock:
+		return NewMockEmbeddingService(), nil
+	default:
+		return nil, fmt.Errorf("unknown embedding provider: %s", config.Provider)
+	}
 }
 
 // MockEmbeddingService is a simple mock implementation for testing
@@ -124,18 +92,27 @@ func NewMockEmbeddingService() *MockEmbeddingService {
 	}
 }
 
-// CreateEmbedding generates a mock embedding with random values
-func (s *MockEmbeddingService) CreateEmbedding(text string) ([]float32, error) {
-	// Create a deterministic mock embedding based on the text length
-	embedding := make([]float32, s.dimension)
-	for i := range embedding {
-		// Use text length to seed a simple value
-		embedding[i] = float32(len(text)%10) / 10.0
+// Embed generates mock embeddings
+func (s *MockEmbeddingService) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	embeddings := make([][]float32, len(texts))
+	for i, text := range texts {
+		// Create a deterministic mock embedding based on the text length
+		embedding := make([]float32, s.dimension)
+		for j := range embedding {
+			// Use text length to seed a simple value
+			embedding[j] = float32(len(text)%10) / 10.0
+		}
+		embeddings[i] = embedding
 	}
-	return embedding, nil
+	return embeddings, nil
 }
 
-// GetEmbeddingDimension returns the dimension of mock embeddings
-func (s *MockEmbeddingService) GetEmbeddingDimension() int {
+// Dimension returns the dimension of mock embeddings
+func (s *MockEmbeddingService) Dimension() int {
 	return s.dimension
 }
+
+// ModelID identifies the mock embedder
+func (s *MockEmbeddingService) ModelID() string {
+	return "mock/length"
+}
@@ -0,0 +1,56 @@
+package embedding
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// APIError is returned when an embedding provider responds with a non-2xx status
+type APIError struct {
+	Provider   string
+	StatusCode int
+	Status     string
+	Body       string
+	// RetryAfter is the delay requested by the server, or 0 if none was given
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s API error: %s", e.Provider, e.Status)
+}
+
+// Retryable reports whether the request may succeed if sent again
+func (e *APIError) Retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests ||
+		e.StatusCode == http.StatusRequestTimeout ||
+		e.StatusCode >= 500
+}
+
+// newAPIError builds an APIError from a response whose body has already been read
+func newAPIError(provider string, resp *http.Response, body []byte) *APIError {
+	return &APIError{
+		Provider:   provider,
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Body:       string(body),
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+		return time.Duration(seconds * float64(time.Second))
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if d := time.Until(at); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
@@ -0,0 +1,160 @@
+package embedding
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+)
+
+const localDefaultDimension = 512
+
+// LocalEmbedder is a deterministic, in-process embedder that needs no network
+// access or API key. It uses feature hashing: each word, word bigram and
+// character trigram of the text is hashed into one of dimension buckets with a
+// hashed sign, counts are log-scaled and the vector is L2-normalised. Common
+// stopwords are skipped. Texts
+// that share vocabulary and word fragments therefore have a high cosine
+// similarity, which is enough to exercise ranking end to end.
+type LocalEmbedder struct {
+	dimension int
+}
+
+// NewLocalEmbedder creates a local embedder. A dimension of 0 uses the default.
+func NewLocalEmbedder(dimension int) *LocalEmbedder {
+	if dimension <= 0 {
+		dimension = localDefaultDimension
+	}
+	return &LocalEmbedder{dimension: dimension}
+}
+
+// localStopwords are common English words that carry little meaning on their own
+var localStopwords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "by": true, "for": true, "from": true, "in": true, "is": true,
+	"it": true, "of": true, "on": true, "or": true, "that": true, "the": true,
+	"this": true, "to": true, "was": true, "with": true,
+}
+
+// Feature weights relative to a single word
+const (
+	localBigramWeight  = 0.5
+	localTrigramWeight = 0.25
+)
+
+// Embed generates embeddings for the given texts
+func (s *LocalEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	embeddings := make([][]float32, len(texts))
+	for i, text := range texts {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		embeddings[i] = s.embed(text)
+	}
+	return embeddings, nil
+}
+
+func (s *LocalEmbedder) embed(text string) []float32 {
+	counts := make(map[string]float64)
+
+	words := localTokenize(text)
+	for i, word := range words {
+		if localStopwords[word] {
+			continue
+		}
+		counts["w:"+word]++
+		if i > 0 {
+			counts["b:"+words[i-1]+" "+word] += localBigramWeight
+		}
+
+		padded := []rune("^" + word + "$")
+		for j := 0; j+3 <= len(padded); j++ {
+			counts["t:"+string(padded[j:j+3])] += localTrigramWeight
+		}
+	}
+
+	vector := make([]float64, s.dimension)
+	for feature, count := range counts {
+		h := fnv.New64a()
+		h.Write([]byte(feature))
+		sum := h.Sum64()
+
+		bucket := int(sum % uint64(s.dimension))
+		// Sublinear term frequency so repeated words do not dominate
+		weight := count
+		if count > 1 {
+			weight = 1 + math.Log(count)
+		}
+		// Use a separate bit of the hash as the sign so collisions cancel out
+		// on average instead of accumulating
+		if sum>>63 == 1 {
+			weight = -weight
+		}
+		vector[bucket] += weight
+	}
+
+	var norm float64
+	for _, v := range vector {
+		norm += v * v
+	}
+	norm = math.Sqrt(norm)
+
+	embedding := make([]float32, s.dimension)
+	if norm == 0 {
+		return embedding
+	}
+	for i, v := range vector {
+		embedding[i] = float32(v / norm)
+	}
+	return embedding
+}
+
+// localTokenize lowercases text and splits it into words of letters and digits.
+// Identifiers such as snake_case and camelCase are also split into their parts.
+func localTokenize(text string) []string {
+	var words []string
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+
+	for _, field := range fields {
+		parts := splitIdentifier(field)
+		if len(parts) > 1 {
+			words = append(words, strings.ToLower(field))
+		}
+		for _, part := range parts {
+			words = append(words, strings.ToLower(part))
+		}
+	}
+	return words
+}
+
+// splitIdentifier splits snake_case and camelCase identifiers into their parts
+func splitIdentifier(field string) []string {
+	var parts []string
+	for _, piece := range strings.Split(field, "_") {
+		runes := []rune(piece)
+		start := 0
+		for i := 1; i < len(runes); i++ {
+			if unicode.IsUpper(runes[i]) && unicode.IsLower(runes[i-1]) {
+				parts = append(parts, string(runes[start:i]))
+				start = i
+			}
+		}
+		if start < len(runes) {
+			parts = append(parts, string(runes[start:]))
+		}
+	}
+	return parts
+}
+
+// Dimension returns the dimension of local embeddings
+func (s *LocalEmbedder) Dimension() int {
+	return s.dimension
+}
+
+// ModelID identifies the local embedder and its feature set
+func (s *LocalEmbedder) ModelID() string {
+	return ProviderLocal + "/hashing-ngram-v1"
+}
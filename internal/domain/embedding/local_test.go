@@ -0,0 +1,77 @@
+package embedding
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func dot(a, b []float32) float64 {
+	var sum float64
+	for i := range a {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
+
+func TestLocalEmbedderIsDeterministic(t *testing.T) {
+	text := "The HNSW graph links every vector to its nearest neighbours."
+
+	first, err := NewLocalEmbedder(0).Embed(context.Background(), []string{text, text})
+	require.NoError(t, err)
+	second, err := NewLocalEmbedder(0).Embed(context.Background(), []string{text})
+	require.NoError(t, err)
+
+	assert.Len(t, first[0], localDefaultDimension)
+	assert.Equal(t, first[0], first[1])
+	assert.Equal(t, first[0], second[0])
+	assert.InDelta(t, 1, math.Sqrt(dot(first[0], first[0])), 1e-5)
+}
+
+func TestLocalEmbedderRanksOverlappingTexts(t *testing.T) {
+	embedder := NewLocalEmbedder(256)
+	embeddings, err := embedder.Embed(context.Background(), []string{
+		"How do sea otters sleep?",
+		"Sea otters hold hands while they sleep so they do not drift apart.",
+		"Otters are playful animals.",
+		"The quarterly report covers revenue and operating costs.",
+	})
+	require.NoError(t, err)
+
+	query := embeddings[0]
+	overlapping := dot(query, embeddings[1])
+	partial := dot(query, embeddings[2])
+	unrelated := dot(query, embeddings[3])
+
+	assert.Greater(t, overlapping, partial)
+	assert.Greater(t, partial, unrelated)
+	assert.Less(t, math.Abs(unrelated), 0.2)
+}
+
+func TestLocalEmbedderIdentifiersAndEmptyText(t *testing.T) {
+	embedder := NewLocalEmbedder(0)
+	embeddings, err := embedder.Embed(context.Background(), []string{
+		"maxRetries",
+		"max retries",
+		"",
+		"the and of",
+	})
+	require.NoError(t, err)
+
+	// camelCase identifiers share the features of their parts
+	assert.Greater(t, dot(embeddings[0], embeddings[1]), 0.5)
+	// Text without words, or with only stopwords, gives a zero vector
+	assert.Equal(t, make([]float32, localDefaultDimension), embeddings[2])
+	assert.Equal(t, make([]float32, localDefaultDimension), embeddings[3])
+}
+
+func TestLocalEmbedderHonoursContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := NewLocalEmbedder(0).Embed(ctx, []string{"anything"})
+	assert.ErrorIs(t, err, context.Canceled)
+}
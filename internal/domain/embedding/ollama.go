@@ -0,0 +1,142 @@
+package embedding
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const ollamaBaseURL = "http://localhost:11434"
+
+// ollamaDimensions holds the dimension of common Ollama embedding models
+var ollamaDimensions = map[string]int{
+	"nomic-embed-text":       768,
+	"mxbai-embed-large":      1024,
+	"all-minilm":             384,
+	"snowflake-arctic-embed": 1024,
+}
+
+// OllamaEmbedder creates embeddings with a local Ollama server
+type OllamaEmbedder struct {
+	client   *http.Client
+	model    string
+	endpoint string
+	logger   *slog.Logger
+
+	mu        sync.RWMutex
+	dimension int
+}
+
+// NewOllamaEmbedder creates an embedder for the Ollama /api/embed endpoint
+func NewOllamaEmbedder(config Config, logger *slog.Logger) *OllamaEmbedder {
+	baseURL := config.BaseURL
+	if baseURL == "" {
+		baseURL = ollamaBaseURL
+	}
+
+	model := config.Model
+	if model == "" {
+		model = "nomic-embed-text"
+	}
+
+	dimension := config.Dimension
+	if dimension == 0 {
+		dimension = ollamaDimensions[strings.SplitN(model, ":", 2)[0]]
+	}
+
+	// Ollama may need to load the model on the first request
+	timeout := time.Second * 120
+	if config.Timeout > 0 {
+		timeout = config.Timeout
+	}
+
+	return &OllamaEmbedder{
+		client: &http.Client{
+			Timeout: timeout,
+		},
+		model:     model,
+		endpoint:  strings.TrimSuffix(baseURL, "/") + "/api/embed",
+		dimension: dimension,
+		logger:    logger,
+	}
+}
+
+// Embed generates embeddings for the given texts in a single request
+func (s *OllamaEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return [][]float32{}, nil
+	}
+
+	reqBody := map[string]interface{}{
+		"model": s.model,
+		"input": texts,
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		s.logger.Error("Failed to marshal embedding request", "error", err)
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", s.endpoint, bytes.NewBuffer(jsonData))
+	if err != nil {
+		s.logger.Error("Failed to create embedding request", "error", err)
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		s.logger.Error("Failed to call Ollama API", "error", err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		s.logger.Error("Ollama API error", "status", resp.Status, "body", string(body))
+		return nil, newAPIError("Ollama", resp, body)
+	}
+
+	var result struct {
+		Embeddings [][]float32 `json:"embeddings"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		s.logger.Error("Failed to decode embedding response", "error", err)
+		return nil, err
+	}
+
+	if len(result.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(result.Embeddings))
+	}
+	for i, embedding := range result.Embeddings {
+		if len(embedding) == 0 {
+			return nil, fmt.Errorf("empty embedding at index %d", i)
+		}
+	}
+
+	s.mu.Lock()
+	s.dimension = len(result.Embeddings[0])
+	s.mu.Unlock()
+
+	return result.Embeddings, nil
+}
+
+// Dimension returns the dimension of embeddings from this model
+func (s *OllamaEmbedder) Dimension() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.dimension
+}
+
+// ModelID identifies the provider and model
+func (s *OllamaEmbedder) ModelID() string {
+	return ProviderOllama + "/" + s.model
+}
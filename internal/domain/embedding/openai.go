@@ -0,0 +1,177 @@
+package embedding
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+const openAIBaseURL = "https://api.openai.com/v1"
+
+// openAIDimensions holds the default dimension of known OpenAI embedding models
+var openAIDimensions = map[string]int{
+	"text-embedding-3-small": 1536,
+	"text-embedding-3-large": 3072,
+	"text-embedding-ada-002": 1536,
+}
+
+// OpenAIEmbedder creates embeddings with the OpenAI embeddings API or any
+// server that implements it (vLLM, LM Studio, LocalAI, TEI, ...)
+type OpenAIEmbedder struct {
+	client     *http.Client
+	provider   string
+	apiKey     string
+	model      string
+	endpoint   string
+	dimensions int // sent to the API when explicitly configured
+	logger     *slog.Logger
+
+	mu        sync.RWMutex
+	dimension int
+}
+
+// NewOpenAIEmbedder creates an embedder for the OpenAI embeddings API at config.BaseURL
+func NewOpenAIEmbedder(provider string, config Config, logger *slog.Logger) *OpenAIEmbedder {
+	model := config.Model
+	if model == "" {
+		model = "text-embedding-3-small"
+	}
+
+	dimension := config.Dimension
+	if dimension == 0 {
+		dimension = openAIDimensions[model]
+	}
+
+	embedder := &OpenAIEmbedder{
+		client: &http.Client{
+			Timeout: config.timeout(),
+		},
+		provider:  provider,
+		apiKey:    config.APIKey,
+		model:     model,
+		endpoint:  strings.TrimSuffix(config.BaseURL, "/") + "/embeddings",
+		dimension: dimension,
+		logger:    logger,
+	}
+	if config.Dimension > 0 && strings.HasPrefix(model, "text-embedding-3") {
+		embedder.dimensions = config.Dimension
+	}
+	return embedder
+}
+
+// Embed generates embeddings for the given texts in a single request
+func (s *OpenAIEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if s.provider == ProviderOpenAI && s.apiKey == "" {
+		return nil, fmt.Errorf("OpenAI API key not set")
+	}
+	if len(texts) == 0 {
+		return [][]float32{}, nil
+	}
+
+	// Clean up text - remove excessive whitespace
+	input := make([]string, len(texts))
+	for i, text := range texts {
+		input[i] = strings.TrimSpace(text)
+	}
+
+	reqBody := map[string]interface{}{
+		"input": input,
+		"model": s.model,
+	}
+	if s.dimensions > 0 {
+		reqBody["dimensions"] = s.dimensions
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		s.logger.Error("Failed to marshal embedding request", "error", err)
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", s.endpoint, bytes.NewBuffer(jsonData))
+	if err != nil {
+		s.logger.Error("Failed to create embedding request", "error", err)
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if s.apiKey != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", s.apiKey))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		s.logger.Error("Failed to call embeddings API", "provider", s.provider, "error", err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		s.logger.Error("Embeddings API error", "provider", s.provider, "status", resp.Status, "body", string(body))
+		return nil, newAPIError(s.provider, resp, body)
+	}
+
+	// Parse response
+	var result struct {
+		Object string `json:"object"`
+		Data   []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		s.logger.Error("Failed to decode embedding response", "error", err)
+		return nil, err
+	}
+
+	if len(result.Data) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(result.Data))
+	}
+
+	embeddings := make([][]float32, len(texts))
+	for _, item := range result.Data {
+		if item.Index < 0 || item.Index >= len(texts) || len(item.Embedding) == 0 {
+			return nil, fmt.Errorf("invalid embedding at index %d", item.Index)
+		}
+		embeddings[item.Index] = item.Embedding
+	}
+	for i, embedding := range embeddings {
+		if embedding == nil {
+			return nil, fmt.Errorf("missing embedding at index %d", i)
+		}
+	}
+
+	s.setDimension(len(embeddings[0]))
+	return embeddings, nil
+}
+
+// Dimension returns the dimension of embeddings from this service
+func (s *OpenAIEmbedder) Dimension() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.dimension
+}
+
+// ModelID identifies the provider and model
+func (s *OpenAIEmbedder) ModelID() string {
+	return s.provider + "/" + s.model
+}
+
+func (s *OpenAIEmbedder) setDimension(dimension int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.dimension != dimension {
+		if s.dimension != 0 {
+			s.logger.Warn("Embedding dimension differs from configured value", "configured", s.dimension, "actual", dimension)
+		}
+		s.dimension = dimension
+	}
+}
@@ -0,0 +1,72 @@
+package embedding
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// tokenLimiter is a token bucket that limits the number of tokens sent per minute
+type tokenLimiter struct {
+	mu       sync.Mutex
+	capacity float64
+	tokens   float64
+	perSec   float64
+	last     time.Time
+}
+
+// newTokenLimiter creates a limiter allowing tokensPerMinute, starting full
+func newTokenLimiter(tokensPerMinute int) *tokenLimiter {
+	capacity := float64(tokensPerMinute)
+	return &tokenLimiter{
+		capacity: capacity,
+		tokens:   capacity,
+		perSec:   capacity / 60,
+		last:     time.Now(),
+	}
+}
+
+// Wait blocks until n tokens are available and takes them. Requests larger
+// than the bucket are clamped to its capacity so they can still proceed.
+func (l *tokenLimiter) Wait(ctx context.Context, n int) error {
+	need := float64(n)
+	if need > l.capacity {
+		need = l.capacity
+	}
+
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		l.tokens += now.Sub(l.last).Seconds() * l.perSec
+		if l.tokens > l.capacity {
+			l.tokens = l.capacity
+		}
+		l.last = now
+
+		if l.tokens >= need {
+			l.tokens -= need
+			l.mu.Unlock()
+			return nil
+		}
+		wait := time.Duration((need - l.tokens) / l.perSec * float64(time.Second))
+		l.mu.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// estimateTokens roughly estimates the number of tokens in texts at four
+// characters per token, which is close enough for rate limiting
+func estimateTokens(texts []string) int {
+	total := 0
+	for _, text := range texts {
+		total += len(text)/4 + 1
+	}
+	return total
+}
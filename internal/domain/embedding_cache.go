@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"github.com/robstave/gorag/internal/domain/types"
+)
+
+// cacheStatser is implemented by embedders that keep hit/miss counters
+type cacheStatser interface {
+	CacheStats() (hits, misses int64)
+}
+
+// EmbeddingCacheStats reports embedding cache hits and misses since startup
+// and the number of cached embeddings per model
+func (s *Service) EmbeddingCacheStats() (*types.EmbeddingCacheStats, error) {
+	stats := &types.EmbeddingCacheStats{
+		Model:  s.embedService.ModelID(),
+		Models: []types.EmbeddingCacheModelStats{},
+	}
+
+	if cached, ok := s.embedService.(cacheStatser); ok {
+		stats.Enabled = true
+		stats.Hits, stats.Misses = cached.CacheStats()
+		if total := stats.Hits + stats.Misses; total > 0 {
+			stats.HitRate = float64(stats.Hits) / float64(total)
+		}
+	}
+
+	models, err := s.repo.GetEmbeddingCacheStats()
+	if err != nil {
+		s.logger.Error("Failed to load embedding cache stats", "error", err)
+		return nil, err
+	}
+	if models != nil {
+		stats.Models = models
+	}
+
+	return stats, nil
+}
+
+// PruneEmbeddingCache deletes cached embeddings for models other than
+// keepModels. When keepModels is empty the current model is kept.
+func (s *Service) PruneEmbeddingCache(keepModels []string) (int64, error) {
+	if len(keepModels) == 0 {
+		keepModels = []string{s.embedService.ModelID()}
+	}
+
+	s.logger.Info("Pruning embedding cache", "keep", keepModels)
+
+	removed, err := s.repo.PruneEmbeddingCache(keepModels)
+	if err != nil {
+		s.logger.Error("Failed to prune embedding cache", "error", err)
+		return 0, err
+	}
+
+	s.logger.Info("Pruned embedding cache", "removed", removed)
+	return removed, nil
+}
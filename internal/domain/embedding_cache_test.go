@@ -0,0 +1,140 @@
+package domain
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/robstave/gorag/internal/domain/embedding"
+	"github.com/robstave/gorag/internal/domain/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingEmbedder is a local embedder under a chosen model ID that counts
+// the texts it embeds
+type countingEmbedder struct {
+	*embedding.LocalEmbedder
+	model    string
+	embedded int
+}
+
+func (e *countingEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	e.embedded += len(texts)
+	return e.LocalEmbedder.Embed(ctx, texts)
+}
+
+func (e *countingEmbedder) ModelID() string {
+	return e.model
+}
+
+func TestTextHash(t *testing.T) {
+	sum := sha256.Sum256([]byte("Sea otters"))
+	assert.Equal(t, hex.EncodeToString(sum[:]), embedding.TextHash("Sea otters"))
+	assert.NotEqual(t, embedding.TextHash("Sea otters"), embedding.TextHash("sea otters"))
+}
+
+func TestCachedEmbedder(t *testing.T) {
+	_, repo := newTestService(t, newMemoryStore(t))
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctx := context.Background()
+
+	inner := &countingEmbedder{LocalEmbedder: embedding.NewLocalEmbedder(64), model: "test/a"}
+	cached := embedding.NewCachedEmbedder(inner, repo, logger)
+
+	first, err := cached.Embed(ctx, []string{"otters", "beavers"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, inner.embedded)
+	hits, misses := cached.CacheStats()
+	assert.Equal(t, int64(0), hits)
+	assert.Equal(t, int64(2), misses)
+
+	// Cached texts are served from the database, in input order
+	second, err := cached.Embed(ctx, []string{"beavers", "muskrats", "otters"})
+	require.NoError(t, err)
+	assert.Equal(t, 3, inner.embedded)
+	assert.Equal(t, first[1], second[0])
+	assert.Equal(t, first[0], second[2])
+	hits, misses = cached.CacheStats()
+	assert.Equal(t, int64(2), hits)
+	assert.Equal(t, int64(3), misses)
+
+	// The same text under another model is a miss
+	other := &countingEmbedder{LocalEmbedder: embedding.NewLocalEmbedder(64), model: "test/b"}
+	_, err = embedding.NewCachedEmbedder(other, repo, logger).Embed(ctx, []string{"otters"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, other.embedded)
+
+	// So is the same model at another dimension
+	resized := &countingEmbedder{LocalEmbedder: embedding.NewLocalEmbedder(32), model: "test/a"}
+	vectors, err := embedding.NewCachedEmbedder(resized, repo, logger).Embed(ctx, []string{"otters"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, resized.embedded)
+	assert.Len(t, vectors[0], 32)
+
+	stats, err := repo.GetEmbeddingCacheStats()
+	require.NoError(t, err)
+	assert.Equal(t, []types.EmbeddingCacheModelStats{
+		{Model: "test/a", Dimension: 32, Entries: 1},
+		{Model: "test/a", Dimension: 64, Entries: 3},
+		{Model: "test/b", Dimension: 64, Entries: 1},
+	}, stats)
+}
+
+func TestEmbeddingCacheStatsAndPrune(t *testing.T) {
+	service, repo := newTestService(t, newMemoryStore(t))
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctx := context.Background()
+
+	current := embedding.NewCachedEmbedder(service.embedService, repo, logger)
+	service.embedService = current
+	_, err := current.Embed(ctx, []string{"one", "two"})
+	require.NoError(t, err)
+	_, err = current.Embed(ctx, []string{"one"})
+	require.NoError(t, err)
+	for _, model := range []string{"old/a", "old/b"} {
+		stale := &countingEmbedder{LocalEmbedder: embedding.NewLocalEmbedder(8), model: model}
+		_, err := embedding.NewCachedEmbedder(stale, repo, logger).Embed(ctx, []string{"one"})
+		require.NoError(t, err)
+	}
+
+	stats, err := service.EmbeddingCacheStats()
+	require.NoError(t, err)
+	assert.True(t, stats.Enabled)
+	assert.Equal(t, int64(1), stats.Hits)
+	assert.Equal(t, int64(2), stats.Misses)
+	assert.InDelta(t, 1.0/3, stats.HitRate, 1e-9)
+	assert.Len(t, stats.Models, 3)
+
+	// --keep old/a keeps only that model
+	removed, err := service.PruneEmbeddingCache([]string{"old/a"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), removed)
+	stats, err = service.EmbeddingCacheStats()
+	require.NoError(t, err)
+	require.Len(t, stats.Models, 1)
+	assert.Equal(t, "old/a", stats.Models[0].Model)
+
+	// Without --keep only the configured model survives
+	_, err = current.Embed(ctx, []string{"three"})
+	require.NoError(t, err)
+	removed, err = service.PruneEmbeddingCache(nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), removed)
+	stats, err = service.EmbeddingCacheStats()
+	require.NoError(t, err)
+	require.Len(t, stats.Models, 1)
+	assert.Equal(t, service.embedService.ModelID(), stats.Models[0].Model)
+}
+
+func TestEmbeddingCacheStatsWithoutCache(t *testing.T) {
+	service, _ := newTestService(t, newMemoryStore(t))
+
+	stats, err := service.EmbeddingCacheStats()
+	require.NoError(t, err)
+	assert.False(t, stats.Enabled)
+	assert.Empty(t, stats.Models)
+}
@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"sort"
+
+	"github.com/robstave/gorag/internal/domain/types"
+)
+
+// rrfK dampens the weight of top ranks in reciprocal rank fusion. 60 is the
+// value from the original RRF paper and works well without tuning.
+const rrfK = 60
+
+// fuseResults merges vector and keyword results for the same chunks into one
+// ranking and returns the best limit. With FusionRRF each list contributes
+// weight / (rrfK + rank); with FusionWeighted it contributes weight * score.
+// Both are scaled so a chunk ranked first by, or scoring 1 in, both lists
+// scores 1. Distance and Metric come from the vector result when there is one.
+func fuseResults(vector, keyword []types.SearchResult, fusion string, vectorWeight float64, limit int) []types.SearchResult {
+	fused := make(map[string]*types.SearchResult)
+	var order []string
+
+	add := func(results []types.SearchResult, weight float64) {
+		for rank, result := range results {
+			contribution := weight * result.Score
+			if fusion != types.FusionWeighted {
+				contribution = weight * (rrfK + 1) / float64(rrfK+rank+1)
+			}
+
+			if existing, ok := fused[result.ID]; ok {
+				existing.Score += contribution
+				continue
+			}
+			result.Score = contribution
+			fused[result.ID] = &result
+			order = append(order, result.ID)
+		}
+	}
+	add(vector, vectorWeight)
+	add(keyword, 1-vectorWeight)
+
+	results := make([]types.SearchResult, len(order))
+	for i, id := range order {
+		results[i] = *fused[id]
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
@@ -0,0 +1,176 @@
+package domain
+
+import (
+	"context"
+	"testing"
+
+	"github.com/robstave/gorag/internal/domain/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ranked returns results with the given IDs and scores, in order
+func ranked(metric string, idScores ...interface{}) []types.SearchResult {
+	var results []types.SearchResult
+	for i := 0; i+1 < len(idScores); i += 2 {
+		id := idScores[i].(string)
+		results = append(results, types.SearchResult{
+			ID:     id,
+			Score:  idScores[i+1].(float64),
+			Metric: metric,
+			Chunk:  types.Chunk{ID: id, DocumentID: id},
+		})
+	}
+	return results
+}
+
+func TestFuseResults(t *testing.T) {
+	// rrf returns the RRF contribution of a rank, scaled so rank 0 scores weight
+	rrf := func(weight float64, rank int) float64 {
+		return weight * (rrfK + 1) / float64(rrfK+rank+1)
+	}
+
+	tests := []struct {
+		name         string
+		vector       []types.SearchResult
+		keyword      []types.SearchResult
+		fusion       string
+		vectorWeight float64
+		limit        int
+		want         map[string]float64
+		wantOrder    []string
+	}{
+		{
+			name:         "rrf rewards chunks in both lists",
+			vector:       ranked("cosine", "a", 0.9, "b", 0.8, "c", 0.7),
+			keyword:      ranked("bm25", "c", 0.6, "d", 0.5),
+			vectorWeight: 0.5,
+			limit:        10,
+			want: map[string]float64{
+				"a": rrf(0.5, 0),
+				"b": rrf(0.5, 1),
+				"c": rrf(0.5, 2) + rrf(0.5, 0),
+				"d": rrf(0.5, 1),
+			},
+			wantOrder: []string{"c", "a", "b", "d"},
+		},
+		{
+			name:         "first in both lists scores 1",
+			vector:       ranked("cosine", "a", 0.2),
+			keyword:      ranked("bm25", "a", 0.1),
+			fusion:       types.FusionRRF,
+			vectorWeight: 0.3,
+			limit:        10,
+			want:         map[string]float64{"a": 1},
+			wantOrder:    []string{"a"},
+		},
+		{
+			name:         "weighted combines scores",
+			vector:       ranked("cosine", "a", 0.9, "b", 0.4),
+			keyword:      ranked("bm25", "b", 0.8, "c", 0.9),
+			fusion:       types.FusionWeighted,
+			vectorWeight: 0.75,
+			limit:        10,
+			want: map[string]float64{
+				"a": 0.75 * 0.9,
+				"b": 0.75*0.4 + 0.25*0.8,
+				"c": 0.25 * 0.9,
+			},
+			wantOrder: []string{"a", "b", "c"},
+		},
+		{
+			name:         "keyword only",
+			keyword:      ranked("bm25", "a", 0.5, "b", 0.4),
+			fusion:       types.FusionWeighted,
+			vectorWeight: 0.5,
+			limit:        10,
+			want:         map[string]float64{"a": 0.25, "b": 0.2},
+			wantOrder:    []string{"a", "b"},
+		},
+		{
+			name:         "truncated to the limit",
+			vector:       ranked("cosine", "a", 0.9, "b", 0.8, "c", 0.7),
+			keyword:      ranked("bm25", "d", 0.9),
+			vectorWeight: 0.8,
+			limit:        2,
+			want:         map[string]float64{"a": rrf(0.8, 0), "b": rrf(0.8, 1)},
+			wantOrder:    []string{"a", "b"},
+		},
+		{
+			name:         "nothing to fuse",
+			vectorWeight: 0.5,
+			limit:        5,
+			want:         map[string]float64{},
+			wantOrder:    []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := fuseResults(tt.vector, tt.keyword, tt.fusion, tt.vectorWeight, tt.limit)
+
+			order := make([]string, len(results))
+			for i, result := range results {
+				order[i] = result.ID
+				assert.InDelta(t, tt.want[result.ID], result.Score, 1e-9, result.ID)
+			}
+			assert.Equal(t, tt.wantOrder, order)
+		})
+	}
+}
+
+func TestFuseResultsKeepsVectorMetric(t *testing.T) {
+	vector := ranked("cosine", "a", 0.9)
+	vector[0].Distance = 0.1
+	keyword := ranked("bm25", "a", 0.5, "b", 0.4)
+	keyword[0].Distance = -1
+
+	results := fuseResults(vector, keyword, types.FusionRRF, 0.5, 10)
+	require.Len(t, results, 2)
+	assert.Equal(t, "cosine", results[0].Metric)
+	assert.Equal(t, 0.1, results[0].Distance)
+	assert.Equal(t, "bm25", results[1].Metric)
+}
+
+// stubKeywordIndex returns fixed results for every search
+type stubKeywordIndex struct {
+	results []types.SearchResult
+}
+
+func (s *stubKeywordIndex) IndexDocument(documentID string, chunks []types.Chunk) error { return nil }
+func (s *stubKeywordIndex) DeleteDocument(documentID string) error                      { return nil }
+func (s *stubKeywordIndex) Count() (int64, error)                                       { return int64(len(s.results)), nil }
+
+func (s *stubKeywordIndex) Search(query string, limit int, filter *types.Filter) ([]types.SearchResult, error) {
+	return append([]types.SearchResult(nil), s.results...), nil
+}
+
+func TestHybridSearchAppliesMinScoreBeforeFusion(t *testing.T) {
+	store := newMemoryStore(t)
+	service, _ := newTestService(t, store)
+
+	texts := []string{"reset the router password", "banana bread recipe with walnuts"}
+	embeddings, err := service.embedService.Embed(context.Background(), texts)
+	require.NoError(t, err)
+	chunks := []types.Chunk{
+		{ID: "close:0", DocumentID: "close", Text: texts[0]},
+		{ID: "far:0", DocumentID: "far", Text: texts[1]},
+	}
+	require.NoError(t, store.AddChunks(chunks, embeddings))
+
+	// A keyword match with a low BM25 score must survive the threshold
+	service.keywordIndex = &stubKeywordIndex{results: ranked("bm25", "keyword:0", 0.2)}
+
+	results, err := service.SearchDocuments(context.Background(), types.SearchQuery{
+		Query:    texts[0],
+		Mode:     types.SearchModeHybrid,
+		MinScore: 0.9,
+	})
+	require.NoError(t, err)
+
+	ids := make([]string, len(results))
+	for i, result := range results {
+		ids[i] = result.ID
+	}
+	assert.Equal(t, []string{"close:0", "keyword:0"}, ids)
+}
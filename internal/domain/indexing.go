@@ -0,0 +1,115 @@
+package domain
+
+import (
+	"context"
+
+	"github.com/robstave/gorag/internal/domain/types"
+)
+
+// indexDocument splits a document into chunks and replaces the document's
+// entries in the keyword index and, after embedding each chunk, in the vector
+// store. An empty document is removed from both. It is a no-op when neither
+// is configured.
+func (s *Service) indexDocument(document types.Document) error {
+	if s.vectorStore == nil && s.keywordIndex == nil {
+		s.logger.Warn("No vector store configured, skipping indexing", "id", document.ID)
+		return nil
+	}
+
+	chunks := s.chunker.Chunk(document)
+	if s.keywordIndex != nil {
+		if err := s.keywordIndex.IndexDocument(document.ID, chunks); err != nil {
+			return err
+		}
+	}
+	if s.vectorStore == nil {
+		return nil
+	}
+
+	if len(chunks) == 0 {
+		s.logger.Info("Document is empty, removing it from the vector store", "id", document.ID)
+		return s.vectorStore.DeleteDocument(document.ID)
+	}
+
+	texts := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		texts[i] = chunk.Text
+	}
+
+	embeddings, err := s.embedService.Embed(context.Background(), texts)
+	if err != nil {
+		s.logger.Error("Failed to create embeddings", "id", document.ID, "model", s.embedService.ModelID(), "error", err)
+		return err
+	}
+
+	// Replace the chunks in place, then remove any the new version no longer
+	// has, so the document stays searchable throughout
+	if err := s.vectorStore.AddChunks(chunks, embeddings); err != nil {
+		s.logger.Error("Failed to add chunks to vector store", "id", document.ID, "error", err)
+		return err
+	}
+
+	keep := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		keep[i] = chunk.ID
+	}
+	if err := s.vectorStore.DeleteStaleChunks(document.ID, keep); err != nil {
+		s.logger.Error("Failed to remove stale chunks from vector store", "id", document.ID, "error", err)
+		return err
+	}
+
+	s.logger.Info("Indexed document", "id", document.ID, "chunks", len(chunks))
+	return nil
+}
+
+// unindexDocument removes every chunk of a document from the keyword index
+// and the vector store. It is a no-op when neither is configured.
+func (s *Service) unindexDocument(documentID string) error {
+	if s.keywordIndex != nil {
+		if err := s.keywordIndex.DeleteDocument(documentID); err != nil {
+			s.logger.Error("Failed to delete document from keyword index", "id", documentID, "error", err)
+			return err
+		}
+	}
+	if s.vectorStore == nil {
+		if s.keywordIndex == nil {
+			s.logger.Warn("No vector store configured, skipping unindexing", "id", documentID)
+		}
+		return nil
+	}
+
+	if err := s.vectorStore.DeleteDocument(documentID); err != nil {
+		s.logger.Error("Failed to delete document from vector store", "id", documentID, "error", err)
+		return err
+	}
+
+	return nil
+}
+
+// backfillKeywordIndex indexes every document when the keyword index is empty,
+// as it is when first created on a database that already has documents
+func (s *Service) backfillKeywordIndex() error {
+	if s.keywordIndex == nil {
+		return nil
+	}
+
+	count, err := s.keywordIndex.Count()
+	if err != nil || count > 0 {
+		return err
+	}
+
+	documents, err := s.repo.GetAlldocuments()
+	if err != nil {
+		return err
+	}
+	for _, document := range documents {
+		if err := s.keywordIndex.IndexDocument(document.ID, s.chunker.Chunk(document)); err != nil {
+			return err
+		}
+	}
+
+	if len(documents) > 0 {
+		s.logger.Info("Backfilled keyword index", "documents", len(documents))
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+// Package llm generates text with chat models
+package llm
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/robstave/gorag/internal/domain/types"
+)
+
+// Message roles
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// Message is a single chat message
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// Response is a completed chat reply
+type Response struct {
+	Content string
+	Usage   types.TokenUsage
+}
+
+// LLM generates chat replies
+type LLM interface {
+	// Chat returns the model's reply to the conversation in messages
+	Chat(ctx context.Context, messages []Message) (*Response, error)
+
+	// ChatStream is like Chat but calls onDelta with each piece of the reply
+	// as it is generated; an error from onDelta aborts the request. The
+	// returned response holds the whole reply. Cancelling ctx cancels the
+	// upstream request.
+	ChatStream(ctx context.Context, messages []Message, onDelta func(delta string) error) (*Response, error)
+
+	// ModelID identifies the provider and model, e.g. "openai/gpt-4o-mini"
+	ModelID() string
+}
+
+// Providers accepted by Config.Provider
+const (
+	ProviderOpenAI           = "openai"
+	ProviderOpenAICompatible = "openai-compatible"
+	ProviderOllama           = "ollama"
+	ProviderMock             = "mock"
+)
+
+// Config selects and configures a chat model
+type Config struct {
+	Provider string
+	Model    string
+	// BaseURL overrides the provider's default endpoint
+	BaseURL string
+	APIKey  string
+	// Temperature is sent with every request; 0 is the most deterministic
+	Temperature float64
+	// MaxTokens caps the length of a reply; 0 leaves it to the provider
+	MaxTokens int
+	// Timeout bounds a single HTTP request (default 120s). For streamed
+	// replies it only bounds the wait for the response headers.
+	Timeout time.Duration
+}
+
+func (c Config) timeout() time.Duration {
+	if c.Timeout > 0 {
+		return c.Timeout
+	}
+	return time.Second * 120
+}
+
+// streamClient returns an HTTP client for streamed replies. An overall
+// timeout would cut off long answers, so only the wait for the response
+// headers is bounded and the request context ends the stream.
+func (c Config) streamClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = c.timeout()
+	return &http.Client{Transport: transport}
+}
+
+// New creates the chat model selected by the configuration
+func New(config Config, logger *slog.Logger) (LLM, error) {
+	switch config.Provider {
+	case "", ProviderOpenAI:
+		if config.BaseURL == "" {
+			config.BaseURL = openAIBaseURL
+		}
+		return NewOpenAILLM(ProviderOpenAI, config, logger), nil
+	case ProviderOpenAICompatible:
+		if config.BaseURL == "" {
+			return nil, fmt.Errorf("base URL is required for provider %s", config.Provider)
+		}
+		if config.Model == "" {
+			return nil, fmt.Errorf("model is required for provider %s", config.Provider)
+		}
+		return NewOpenAILLM(ProviderOpenAICompatible, config, logger), nil
+	case ProviderOllama:
+		return NewOllamaLLM(config, logger), nil
+	case ProviderMock:
+		return NewMockLLM(), nil
+	default:
+		return nil, fmt.Errorf("unknown LLM provider: %s", config.Provider)
+	}
+}
@@ -0,0 +1,92 @@
+package llm
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/robstave/gorag/internal/domain/types"
+)
+
+// mockSource matches the first numbered source in a prompt: its header line
+// followed by the first line of its text
+var mockSource = regexp.MustCompile(`(?m)^\[1\][^\n]*\n([^\n]+)`)
+
+// mockFollowUp and mockUserTurn match the follow-up question and the earlier
+// user messages in a query rewriting prompt
+var (
+	mockFollowUp = regexp.MustCompile(`(?m)^Follow-up question: (.+)$`)
+	mockUserTurn = regexp.MustCompile(`(?m)^User: (.+)$`)
+)
+
+// MockLLM answers without a model by quoting the first sentence of the first
+// numbered source in the last message, and rewrites follow-up questions by
+// prefixing the previous user message. It is deterministic and needs no
+// network, which makes it useful for local development and tests.
+type MockLLM struct{}
+
+// NewMockLLM creates a mock chat model
+func NewMockLLM() *MockLLM {
+	return &MockLLM{}
+}
+
+// Chat returns an extractive answer citing source [1]
+func (l *MockLLM) Chat(ctx context.Context, messages []Message) (*Response, error) {
+	var prompt string
+	if len(messages) > 0 {
+		prompt = messages[len(messages)-1].Content
+	}
+
+	content := "I don't know based on the provided sources."
+	if followUp := mockFollowUp.FindStringSubmatch(prompt); followUp != nil {
+		content = followUp[1]
+		if turns := mockUserTurn.FindAllStringSubmatch(prompt, -1); len(turns) > 0 {
+			content = turns[len(turns)-1][1] + " " + content
+		}
+	} else if match := mockSource.FindStringSubmatch(prompt); match != nil {
+		sentence := strings.TrimSpace(match[1])
+		if end := strings.IndexAny(sentence, ".!?"); end >= 0 {
+			sentence = sentence[:end]
+		}
+		content = sentence + " [1]."
+	}
+
+	promptTokens := 0
+	for _, message := range messages {
+		promptTokens += len(strings.Fields(message.Content))
+	}
+	completionTokens := len(strings.Fields(content))
+
+	return &Response{
+		Content: content,
+		Usage: types.TokenUsage{
+			PromptTokens:     promptTokens,
+			CompletionTokens: completionTokens,
+			TotalTokens:      promptTokens + completionTokens,
+		},
+	}, nil
+}
+
+// ChatStream returns the same answer as Chat, one word at a time
+func (l *MockLLM) ChatStream(ctx context.Context, messages []Message, onDelta func(delta string) error) (*Response, error) {
+	response, err := l.Chat(ctx, messages)
+	if err != nil {
+		return nil, err
+	}
+
+	words := strings.SplitAfter(response.Content, " ")
+	for _, word := range words {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if err := onDelta(word); err != nil {
+			return nil, err
+		}
+	}
+	return response, nil
+}
+
+// ModelID identifies the mock model
+func (l *MockLLM) ModelID() string {
+	return "mock/extractive"
+}
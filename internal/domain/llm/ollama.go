@@ -0,0 +1,173 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/robstave/gorag/internal/domain/types"
+)
+
+const ollamaBaseURL = "http://localhost:11434"
+
+// OllamaLLM generates replies with a local Ollama server
+type OllamaLLM struct {
+	client       *http.Client
+	streamClient *http.Client
+	model        string
+	endpoint     string
+	temperature  float64
+	maxTokens    int
+	logger       *slog.Logger
+}
+
+// NewOllamaLLM creates a chat model for the Ollama /api/chat endpoint
+func NewOllamaLLM(config Config, logger *slog.Logger) *OllamaLLM {
+	baseURL := config.BaseURL
+	if baseURL == "" {
+		baseURL = ollamaBaseURL
+	}
+
+	model := config.Model
+	if model == "" {
+		model = "llama3.2"
+	}
+
+	return &OllamaLLM{
+		client: &http.Client{
+			Timeout: config.timeout(),
+		},
+		streamClient: config.streamClient(),
+		model:        model,
+		endpoint:     strings.TrimSuffix(baseURL, "/") + "/api/chat",
+		temperature:  config.Temperature,
+		maxTokens:    config.MaxTokens,
+		logger:       logger,
+	}
+}
+
+// ollamaChatResponse is a reply from /api/chat, or one line of a streamed
+// reply. Token counts are only set once Done.
+type ollamaChatResponse struct {
+	Message         Message `json:"message"`
+	Done            bool    `json:"done"`
+	PromptEvalCount int     `json:"prompt_eval_count"`
+	EvalCount       int     `json:"eval_count"`
+}
+
+func (r ollamaChatResponse) usage() types.TokenUsage {
+	return types.TokenUsage{
+		PromptTokens:     r.PromptEvalCount,
+		CompletionTokens: r.EvalCount,
+		TotalTokens:      r.PromptEvalCount + r.EvalCount,
+	}
+}
+
+// Chat sends the conversation and waits for the whole reply
+func (l *OllamaLLM) Chat(ctx context.Context, messages []Message) (*Response, error) {
+	resp, err := l.post(ctx, messages, false)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result ollamaChatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		l.logger.Error("Failed to decode Ollama chat response", "error", err)
+		return nil, err
+	}
+
+	return &Response{
+		Content: result.Message.Content,
+		Usage:   result.usage(),
+	}, nil
+}
+
+// ChatStream requests a streamed reply, which Ollama sends as one JSON object per line
+func (l *OllamaLLM) ChatStream(ctx context.Context, messages []Message, onDelta func(delta string) error) (*Response, error) {
+	resp, err := l.post(ctx, messages, true)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var content strings.Builder
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var chunk ollamaChatResponse
+		if err := decoder.Decode(&chunk); err != nil {
+			l.logger.Error("Failed to read Ollama chat stream", "error", err)
+			return nil, err
+		}
+
+		if chunk.Message.Content != "" {
+			content.WriteString(chunk.Message.Content)
+			if err := onDelta(chunk.Message.Content); err != nil {
+				return nil, err
+			}
+		}
+		if chunk.Done {
+			return &Response{
+				Content: content.String(),
+				Usage:   chunk.usage(),
+			}, nil
+		}
+	}
+}
+
+// post sends a chat request and returns the response once its status is OK
+func (l *OllamaLLM) post(ctx context.Context, messages []Message, stream bool) (*http.Response, error) {
+	options := map[string]interface{}{
+		"temperature": l.temperature,
+	}
+	if l.maxTokens > 0 {
+		options["num_predict"] = l.maxTokens
+	}
+	reqBody := map[string]interface{}{
+		"model":    l.model,
+		"messages": messages,
+		"stream":   stream,
+		"options":  options,
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		l.logger.Error("Failed to marshal Ollama chat request", "error", err)
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, l.endpoint, bytes.NewBuffer(jsonData))
+	if err != nil {
+		l.logger.Error("Failed to create Ollama chat request", "error", err)
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := l.client
+	if stream {
+		client = l.streamClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		l.logger.Error("Failed to call Ollama chat API", "error", err)
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		l.logger.Error("Ollama chat API error", "status", resp.Status, "body", string(body))
+		return nil, fmt.Errorf("ollama chat API returned %s: %s", resp.Status, string(body))
+	}
+	return resp, nil
+}
+
+// ModelID identifies the provider and model
+func (l *OllamaLLM) ModelID() string {
+	return ProviderOllama + "/" + l.model
+}
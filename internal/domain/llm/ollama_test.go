@@ -0,0 +1,52 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeOllamaDelta(w io.Writer, delta string, last bool) {
+	chunk := map[string]interface{}{
+		"message": Message{Role: RoleAssistant, Content: delta},
+		"done":    false,
+	}
+	json.NewEncoder(w).Encode(chunk)
+	if last {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":           Message{Role: RoleAssistant},
+			"done":              true,
+			"prompt_eval_count": 5,
+			"eval_count":        3,
+		})
+	}
+}
+
+func TestOllamaChatStreamOutlivesTimeout(t *testing.T) {
+	deltas := []string{"Local ", "models ", "are ", "slow."}
+	server := slowServer(t, 0, 40*time.Millisecond, deltas, writeOllamaDelta)
+	model := NewOllamaLLM(Config{BaseURL: server.URL, Timeout: 100 * time.Millisecond}, testLogger())
+
+	var received []string
+	reply, err := model.ChatStream(context.Background(), []Message{{Role: RoleUser, Content: "Hi"}}, func(delta string) error {
+		received = append(received, delta)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, deltas, received)
+	assert.Equal(t, "Local models are slow.", reply.Content)
+	assert.Equal(t, 8, reply.Usage.TotalTokens)
+}
+
+func TestOllamaChatStreamTimesOutWaitingForHeaders(t *testing.T) {
+	server := slowServer(t, 300*time.Millisecond, 0, []string{"late"}, writeOllamaDelta)
+	model := NewOllamaLLM(Config{BaseURL: server.URL, Timeout: 50 * time.Millisecond}, testLogger())
+
+	_, err := model.ChatStream(context.Background(), []Message{{Role: RoleUser, Content: "Hi"}}, func(string) error { return nil })
+	assert.Error(t, err)
+}
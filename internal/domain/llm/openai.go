@@ -0,0 +1,200 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/robstave/gorag/internal/domain/types"
+)
+
+const openAIBaseURL = "https://api.openai.com/v1"
+
+// OpenAILLM generates replies with the OpenAI chat completions API or any
+// server that implements it (vLLM, LM Studio, LocalAI, Ollama's /v1, ...)
+type OpenAILLM struct {
+	client       *http.Client
+	streamClient *http.Client
+	provider     string
+	apiKey       string
+	model        string
+	endpoint     string
+	temperature  float64
+	maxTokens    int
+	logger       *slog.Logger
+}
+
+// NewOpenAILLM creates a chat model for the chat completions API at config.BaseURL
+func NewOpenAILLM(provider string, config Config, logger *slog.Logger) *OpenAILLM {
+	model := config.Model
+	if model == "" {
+		model = "gpt-4o-mini"
+	}
+
+	return &OpenAILLM{
+		client: &http.Client{
+			Timeout: config.timeout(),
+		},
+		streamClient: config.streamClient(),
+		provider:     provider,
+		apiKey:       config.APIKey,
+		model:        model,
+		endpoint:     strings.TrimSuffix(config.BaseURL, "/") + "/chat/completions",
+		temperature:  config.Temperature,
+		maxTokens:    config.MaxTokens,
+		logger:       logger,
+	}
+}
+
+// Chat sends the conversation and returns the first choice
+func (l *OpenAILLM) Chat(ctx context.Context, messages []Message) (*Response, error) {
+	resp, err := l.post(ctx, l.client, l.requestBody(messages))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Choices []struct {
+			Message Message `json:"message"`
+		} `json:"choices"`
+		Usage types.TokenUsage `json:"usage"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		l.logger.Error("Failed to decode chat response", "error", err)
+		return nil, err
+	}
+	if len(result.Choices) == 0 {
+		return nil, fmt.Errorf("%s chat API returned no choices", l.provider)
+	}
+
+	return &Response{
+		Content: result.Choices[0].Message.Content,
+		Usage:   result.Usage,
+	}, nil
+}
+
+// ChatStream requests a streamed reply and reads its server-sent events.
+// Usage is requested with stream_options; servers that ignore it report none.
+func (l *OpenAILLM) ChatStream(ctx context.Context, messages []Message, onDelta func(delta string) error) (*Response, error) {
+	reqBody := l.requestBody(messages)
+	reqBody["stream"] = true
+	reqBody["stream_options"] = map[string]interface{}{"include_usage": true}
+
+	resp, err := l.post(ctx, l.streamClient, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var content strings.Builder
+	var usage types.TokenUsage
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk struct {
+			Choices []struct {
+				Delta struct {
+					Content string `json:"content"`
+				} `json:"delta"`
+			} `json:"choices"`
+			Usage *types.TokenUsage `json:"usage"`
+		}
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			l.logger.Error("Failed to decode chat stream chunk", "error", err)
+			return nil, err
+		}
+		if chunk.Usage != nil {
+			usage = *chunk.Usage
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content == "" {
+				continue
+			}
+			content.WriteString(choice.Delta.Content)
+			if err := onDelta(choice.Delta.Content); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		l.logger.Error("Failed to read chat stream", "provider", l.provider, "error", err)
+		return nil, err
+	}
+
+	return &Response{
+		Content: content.String(),
+		Usage:   usage,
+	}, nil
+}
+
+// requestBody returns the chat completions request for messages
+func (l *OpenAILLM) requestBody(messages []Message) map[string]interface{} {
+	reqBody := map[string]interface{}{
+		"model":       l.model,
+		"messages":    messages,
+		"temperature": l.temperature,
+	}
+	if l.maxTokens > 0 {
+		reqBody["max_tokens"] = l.maxTokens
+	}
+	return reqBody
+}
+
+// post sends a chat request with client and returns the response once its status is OK
+func (l *OpenAILLM) post(ctx context.Context, client *http.Client, reqBody map[string]interface{}) (*http.Response, error) {
+	if l.provider == ProviderOpenAI && l.apiKey == "" {
+		return nil, fmt.Errorf("OpenAI API key not set")
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		l.logger.Error("Failed to marshal chat request", "error", err)
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, l.endpoint, bytes.NewBuffer(jsonData))
+	if err != nil {
+		l.logger.Error("Failed to create chat request", "error", err)
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if l.apiKey != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", l.apiKey))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		l.logger.Error("Failed to call chat API", "provider", l.provider, "error", err)
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		l.logger.Error("Chat API error", "provider", l.provider, "status", resp.Status, "body", string(body))
+		return nil, fmt.Errorf("%s chat API returned %s: %s", l.provider, resp.Status, string(body))
+	}
+	return resp, nil
+}
+
+// ModelID identifies the provider and model
+func (l *OpenAILLM) ModelID() string {
+	return l.provider + "/" + l.model
+}
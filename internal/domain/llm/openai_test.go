@@ -0,0 +1,98 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// slowServer sends each delta after pause, so a reply takes about
+// len(deltas) * pause to complete. write renders one delta for the wire.
+func slowServer(t *testing.T, headerDelay, pause time.Duration, deltas []string, write func(w io.Writer, delta string, last bool)) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(headerDelay)
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		for i, delta := range deltas {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(pause):
+			}
+			write(w, delta, i == len(deltas)-1)
+			w.(http.Flusher).Flush()
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func writeOpenAIDelta(w io.Writer, delta string, last bool) {
+	fmt.Fprintf(w, "data: {\"choices\":[{\"delta\":{\"content\":%q}}]}\n\n", delta)
+	if last {
+		fmt.Fprint(w, "data: {\"choices\":[],\"usage\":{\"prompt_tokens\":3,\"completion_tokens\":4,\"total_tokens\":7}}\n\ndata: [DONE]\n\n")
+	}
+}
+
+func TestOpenAIChatStreamOutlivesTimeout(t *testing.T) {
+	deltas := []string{"Streams ", "may ", "take ", "a while."}
+	server := slowServer(t, 0, 40*time.Millisecond, deltas, writeOpenAIDelta)
+	model := NewOpenAILLM(ProviderOpenAICompatible, Config{BaseURL: server.URL, Timeout: 100 * time.Millisecond}, testLogger())
+
+	var received []string
+	reply, err := model.ChatStream(context.Background(), []Message{{Role: RoleUser, Content: "Hi"}}, func(delta string) error {
+		received = append(received, delta)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, deltas, received)
+	assert.Equal(t, "Streams may take a while.", reply.Content)
+	assert.Equal(t, 7, reply.Usage.TotalTokens)
+}
+
+func TestOpenAIChatStreamTimesOutWaitingForHeaders(t *testing.T) {
+	server := slowServer(t, 300*time.Millisecond, 0, []string{"late"}, writeOpenAIDelta)
+	model := NewOpenAILLM(ProviderOpenAICompatible, Config{BaseURL: server.URL, Timeout: 50 * time.Millisecond}, testLogger())
+
+	_, err := model.ChatStream(context.Background(), []Message{{Role: RoleUser, Content: "Hi"}}, func(string) error { return nil })
+	assert.Error(t, err)
+}
+
+func TestOpenAIChatStreamStopsWithContext(t *testing.T) {
+	server := slowServer(t, 0, 50*time.Millisecond, []string{"one ", "two ", "three ", "four"}, writeOpenAIDelta)
+	model := NewOpenAILLM(ProviderOpenAICompatible, Config{BaseURL: server.URL}, testLogger())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Millisecond)
+	defer cancel()
+	_, err := model.ChatStream(ctx, []Message{{Role: RoleUser, Content: "Hi"}}, func(string) error { return nil })
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestOpenAIChatKeepsTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	t.Cleanup(server.Close)
+	model := NewOpenAILLM(ProviderOpenAICompatible, Config{BaseURL: server.URL, Timeout: 50 * time.Millisecond}, testLogger())
+
+	started := time.Now()
+	_, err := model.Chat(context.Background(), []Message{{Role: RoleUser, Content: "Hi"}})
+	assert.Error(t, err)
+	assert.Less(t, time.Since(started), 500*time.Millisecond)
+}
@@ -3,8 +3,13 @@
 package mocks
 
 import (
-	types "github.com/robstave/gorag/internal/domain/types"
+	context "context"
+
 	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+
+	types "github.com/robstave/gorag/internal/domain/types"
 )
 
 // Domain is an autogenerated mock type for the Domain type
@@ -12,6 +17,66 @@ type Domain struct {
 	mock.Mock
 }
 
+// Ask provides a mock function with given fields: ctx, request
+func (_m *Domain) Ask(ctx context.Context, request types.AskRequest) (*types.AskResponse, error) {
+	ret := _m.Called(ctx, request)
+
+	var r0 *types.AskResponse
+	if rf, ok := ret.Get(0).(func(context.Context, types.AskRequest) *types.AskResponse); ok {
+		r0 = rf(ctx, request)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.AskResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, types.AskRequest) error); ok {
+		r1 = rf(ctx, request)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// AskStream provides a mock function with given fields: ctx, request, emit
+func (_m *Domain) AskStream(ctx context.Context, request types.AskRequest, emit func(types.AskEvent) error) error {
+	ret := _m.Called(ctx, request, emit)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, types.AskRequest, func(types.AskEvent) error) error); ok {
+		r0 = rf(ctx, request, emit)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateConversation provides a mock function with given fields: title
+func (_m *Domain) CreateConversation(title string) (*types.Conversation, error) {
+	ret := _m.Called(title)
+
+	var r0 *types.Conversation
+	if rf, ok := ret.Get(0).(func(string) *types.Conversation); ok {
+		r0 = rf(title)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.Conversation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(title)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Createdocument provides a mock function with given fields: document
 func (_m *Domain) Createdocument(document types.Document) (*types.Document, error) {
 	ret := _m.Called(document)
@@ -35,6 +100,20 @@ func (_m *Domain) Createdocument(document types.Document) (*types.Document, erro
 	return r0, r1
 }
 
+// DeleteConversation provides a mock function with given fields: conversationID
+func (_m *Domain) DeleteConversation(conversationID string) error {
+	ret := _m.Called(conversationID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(conversationID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Deletedocument provides a mock function with given fields: documentID
 func (_m *Domain) Deletedocument(documentID string) error {
 	ret := _m.Called(documentID)
@@ -49,6 +128,43 @@ func (_m *Domain) Deletedocument(documentID string) error {
 	return r0
 }
 
+// DispatchOutbox provides a mock function with given fields:
+func (_m *Domain) DispatchOutbox() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// EmbeddingCacheStats provides a mock function with given fields:
+func (_m *Domain) EmbeddingCacheStats() (*types.EmbeddingCacheStats, error) {
+	ret := _m.Called()
+
+	var r0 *types.EmbeddingCacheStats
+	if rf, ok := ret.Get(0).(func() *types.EmbeddingCacheStats); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.EmbeddingCacheStats)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetAlldocuments provides a mock function with given fields:
 func (_m *Domain) GetAlldocuments() ([]types.Document, error) {
 	ret := _m.Called()
@@ -72,6 +188,52 @@ func (_m *Domain) GetAlldocuments() ([]types.Document, error) {
 	return r0, r1
 }
 
+// GetConversationByID provides a mock function with given fields: conversationID
+func (_m *Domain) GetConversationByID(conversationID string) (*types.Conversation, error) {
+	ret := _m.Called(conversationID)
+
+	var r0 *types.Conversation
+	if rf, ok := ret.Get(0).(func(string) *types.Conversation); ok {
+		r0 = rf(conversationID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.Conversation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(conversationID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetConversations provides a mock function with given fields:
+func (_m *Domain) GetConversations() ([]types.Conversation, error) {
+	ret := _m.Called()
+
+	var r0 []types.Conversation
+	if rf, ok := ret.Get(0).(func() []types.Conversation); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]types.Conversation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetdocumentByID provides a mock function with given fields: documentID
 func (_m *Domain) GetdocumentByID(documentID string) (*types.Document, error) {
 	ret := _m.Called(documentID)
@@ -95,6 +257,73 @@ func (_m *Domain) GetdocumentByID(documentID string) (*types.Document, error) {
 	return r0, r1
 }
 
+// PruneEmbeddingCache provides a mock function with given fields: keepModels
+func (_m *Domain) PruneEmbeddingCache(keepModels []string) (int64, error) {
+	ret := _m.Called(keepModels)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func([]string) int64); ok {
+		r0 = rf(keepModels)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func([]string) error); ok {
+		r1 = rf(keepModels)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Reconcile provides a mock function with given fields: repair
+func (_m *Domain) Reconcile(repair bool) (*types.ReconcileReport, error) {
+	ret := _m.Called(repair)
+
+	var r0 *types.ReconcileReport
+	if rf, ok := ret.Get(0).(func(bool) *types.ReconcileReport); ok {
+		r0 = rf(repair)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.ReconcileReport)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(bool) error); ok {
+		r1 = rf(repair)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SearchDocuments provides a mock function with given fields: ctx, query
+func (_m *Domain) SearchDocuments(ctx context.Context, query types.SearchQuery) ([]types.SearchResult, error) {
+	ret := _m.Called(ctx, query)
+
+	var r0 []types.SearchResult
+	if rf, ok := ret.Get(0).(func(context.Context, types.SearchQuery) []types.SearchResult); ok {
+		r0 = rf(ctx, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]types.SearchResult)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, types.SearchQuery) error); ok {
+		r1 = rf(ctx, query)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Seeddocument provides a mock function with given fields:
 func (_m *Domain) Seeddocument() error {
 	ret := _m.Called()
@@ -109,6 +338,34 @@ func (_m *Domain) Seeddocument() error {
 	return r0
 }
 
+// SendMessage provides a mock function with given fields: ctx, conversationID, request
+func (_m *Domain) SendMessage(ctx context.Context, conversationID string, request types.AskRequest) (*types.ConversationTurn, error) {
+	ret := _m.Called(ctx, conversationID, request)
+
+	var r0 *types.ConversationTurn
+	if rf, ok := ret.Get(0).(func(context.Context, string, types.AskRequest) *types.ConversationTurn); ok {
+		r0 = rf(ctx, conversationID, request)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.ConversationTurn)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, types.AskRequest) error); ok {
+		r1 = rf(ctx, conversationID, request)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// StartOutboxDispatcher provides a mock function with given fields: ctx, interval
+func (_m *Domain) StartOutboxDispatcher(ctx context.Context, interval time.Duration) {
+	_m.Called(ctx, interval)
+}
+
 // Updatedocument provides a mock function with given fields: document
 func (_m *Domain) Updatedocument(document types.Document) (*types.Document, error) {
 	ret := _m.Called(document)
@@ -0,0 +1,121 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/robstave/gorag/internal/domain/types"
+)
+
+const (
+	outboxBatchSize   = 50
+	outboxMaxAttempts = 10
+	outboxBaseBackoff = 2 * time.Second
+	outboxMaxBackoff  = 5 * time.Minute
+)
+
+// StartOutboxDispatcher drains the outbox into the vector store and keyword
+// index every interval until ctx is cancelled. Entries left over from a previous run are picked up
+// on the first tick, so indexing survives restarts.
+func (s *Service) StartOutboxDispatcher(ctx context.Context, interval time.Duration) {
+	if s.vectorStore == nil && s.keywordIndex == nil {
+		s.logger.Warn("No vector store configured, outbox dispatcher not started")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if err := s.DispatchOutbox(); err != nil {
+				s.logger.Error("Outbox dispatch failed", "error", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				s.logger.Info("Outbox dispatcher stopped")
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// DispatchOutbox processes one batch of due outbox entries. Entries for the
+// same document are applied in order; once one fails, later entries for that
+// document wait until it succeeds.
+func (s *Service) DispatchOutbox() error {
+	entries, err := s.repo.GetDueOutbox(time.Now(), outboxBatchSize)
+	if err != nil {
+		s.logger.Error("Failed to load outbox entries", "error", err)
+		return err
+	}
+
+	blocked := make(map[string]bool)
+
+	for _, entry := range entries {
+		if blocked[entry.DocumentID] {
+			continue
+		}
+
+		if err := s.applyOutboxEntry(entry); err != nil {
+			blocked[entry.DocumentID] = true
+			s.retryOutboxEntry(entry, err)
+			continue
+		}
+
+		if err := s.repo.DeleteOutboxEntry(entry.ID); err != nil {
+			s.logger.Error("Failed to remove processed outbox entry", "id", entry.ID, "error", err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// applyOutboxEntry pushes a single outbox entry to the vector store and keyword index
+func (s *Service) applyOutboxEntry(entry types.OutboxEntry) error {
+	switch entry.Operation {
+	case types.OutboxUpsert:
+		// Index the current row rather than a snapshot; if it has since been
+		// deleted, a later delete entry will clean up the vector store
+		document, err := s.repo.GetdocumentById(entry.DocumentID)
+		if err != nil {
+			return err
+		}
+		if document == nil {
+			s.logger.Info("Outbox document no longer exists, skipping upsert", "id", entry.DocumentID)
+			return nil
+		}
+		return s.indexDocument(*document)
+	case types.OutboxDelete:
+		return s.unindexDocument(entry.DocumentID)
+	default:
+		return fmt.Errorf("unknown outbox operation: %s", entry.Operation)
+	}
+}
+
+// retryOutboxEntry records a failed attempt and schedules the next one with
+// exponential backoff, giving up after outboxMaxAttempts
+func (s *Service) retryOutboxEntry(entry types.OutboxEntry, cause error) {
+	entry.Attempts++
+	entry.LastError = cause.Error()
+
+	if entry.Attempts >= outboxMaxAttempts {
+		entry.Status = types.OutboxDead
+		s.logger.Error("Outbox entry exceeded max attempts", "id", entry.ID, "documentID", entry.DocumentID, "operation", entry.Operation, "error", cause)
+	} else {
+		backoff := outboxBaseBackoff << (entry.Attempts - 1)
+		if backoff > outboxMaxBackoff {
+			backoff = outboxMaxBackoff
+		}
+		entry.NextAttemptAt = time.Now().Add(backoff)
+		s.logger.Warn("Outbox entry failed, will retry", "id", entry.ID, "documentID", entry.DocumentID, "attempts", entry.Attempts, "retryIn", backoff, "error", cause)
+	}
+
+	if err := s.repo.UpdateOutboxEntry(entry); err != nil {
+		s.logger.Error("Failed to record outbox retry", "id", entry.ID, "error", err)
+	}
+}
@@ -0,0 +1,155 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/robstave/gorag/internal/adapters/repositories/vectorstore"
+	"github.com/robstave/gorag/internal/domain/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingStore is a vector store whose writes fail, counting the attempts
+type failingStore struct {
+	*vectorstore.MemoryStore
+	adds int
+}
+
+func (f *failingStore) AddChunks(chunks []types.Chunk, embeddings [][]float32) error {
+	f.adds++
+	return errors.New("vector store unavailable")
+}
+
+func TestDispatchOutboxIndexesDocument(t *testing.T) {
+	store := newMemoryStore(t)
+	service, repo := newTestService(t, store)
+
+	require.NoError(t, repo.Createdocument(types.Document{ID: "doc", Name: "doc", Value: "Outbox entries are applied in order."}))
+	require.NoError(t, service.DispatchOutbox())
+
+	indexed, err := store.ListDocuments()
+	require.NoError(t, err)
+	require.Len(t, indexed, 1)
+	assert.Equal(t, "doc", indexed[0].DocumentID)
+
+	pending, err := repo.GetPendingOutbox(-1)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func TestDispatchOutboxBacksOff(t *testing.T) {
+	store := &failingStore{MemoryStore: newMemoryStore(t)}
+	service, repo := newTestService(t, store)
+
+	require.NoError(t, repo.Createdocument(types.Document{ID: "doc", Name: "doc", Value: "text"}))
+	require.NoError(t, service.DispatchOutbox())
+
+	pending, err := repo.GetPendingOutbox(-1)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, 1, pending[0].Attempts)
+	assert.Equal(t, types.OutboxPending, pending[0].Status)
+	assert.Contains(t, pending[0].LastError, "vector store unavailable")
+	assert.True(t, pending[0].NextAttemptAt.After(time.Now()))
+
+	// The entry is not retried before its backoff has passed
+	require.NoError(t, service.DispatchOutbox())
+	assert.Equal(t, 1, store.adds)
+}
+
+func TestDispatchOutboxDeadLetters(t *testing.T) {
+	store := &failingStore{MemoryStore: newMemoryStore(t)}
+	service, repo := newTestService(t, store)
+
+	require.NoError(t, repo.Createdocument(types.Document{ID: "doc", Name: "doc", Value: "text"}))
+	pending, err := repo.GetPendingOutbox(-1)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	entry := pending[0]
+	entry.Attempts = outboxMaxAttempts - 1
+	require.NoError(t, repo.UpdateOutboxEntry(entry))
+
+	require.NoError(t, service.DispatchOutbox())
+
+	pending, err = repo.GetPendingOutbox(-1)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func TestDispatchOutboxSkipsEntriesInBackoff(t *testing.T) {
+	store := newMemoryStore(t)
+	service, repo := newTestService(t, store)
+
+	// A full batch of entries waiting out their backoff...
+	require.NoError(t, repo.Createdocument(types.Document{ID: "waiting", Name: "waiting", Value: "text"}))
+	for i := 0; i < outboxBatchSize; i++ {
+		require.NoError(t, repo.Updatedocument(types.Document{ID: "waiting", Name: "waiting", Value: "text"}))
+	}
+	pending, err := repo.GetPendingOutbox(-1)
+	require.NoError(t, err)
+	for _, entry := range pending {
+		entry.NextAttemptAt = time.Now().Add(time.Hour)
+		require.NoError(t, repo.UpdateOutboxEntry(entry))
+	}
+
+	// ...does not hold up another document
+	require.NoError(t, repo.Createdocument(types.Document{ID: "due", Name: "due", Value: "text"}))
+	require.NoError(t, service.DispatchOutbox())
+
+	indexed, err := store.ListDocuments()
+	require.NoError(t, err)
+	require.Len(t, indexed, 1)
+	assert.Equal(t, "due", indexed[0].DocumentID)
+}
+
+func TestDispatchOutboxKeepsDocumentOrder(t *testing.T) {
+	store := newMemoryStore(t)
+	service, repo := newTestService(t, store)
+
+	require.NoError(t, repo.Createdocument(types.Document{ID: "doc", Name: "doc", Value: "text"}))
+	pending, err := repo.GetPendingOutbox(-1)
+	require.NoError(t, err)
+	entry := pending[0]
+	entry.NextAttemptAt = time.Now().Add(time.Hour)
+	require.NoError(t, repo.UpdateOutboxEntry(entry))
+
+	// A later delete of the same document waits behind the upsert in backoff
+	require.NoError(t, repo.Deletedocument("doc"))
+	require.NoError(t, service.DispatchOutbox())
+
+	pending, err = repo.GetPendingOutbox(-1)
+	require.NoError(t, err)
+	assert.Len(t, pending, 2)
+}
+
+func TestIndexDocumentRemovesStaleChunks(t *testing.T) {
+	store := newMemoryStore(t)
+	service, _ := newTestService(t, store)
+
+	long := strings.Repeat("Chunks are replaced in place when a document changes. ", 10)
+	require.NoError(t, service.indexDocument(types.Document{ID: "doc", Name: "doc", Value: long}))
+	indexed, err := store.ListDocuments()
+	require.NoError(t, err)
+	require.Greater(t, len(indexed), 1)
+
+	require.NoError(t, service.indexDocument(types.Document{ID: "doc", Name: "doc", Value: "Now it is short."}))
+	indexed, err = store.ListDocuments()
+	require.NoError(t, err)
+	require.Len(t, indexed, 1)
+	assert.Equal(t, "doc:0", indexed[0].ID)
+}
+
+func TestIndexDocumentRemovesEmptyDocument(t *testing.T) {
+	store := newMemoryStore(t)
+	service, _ := newTestService(t, store)
+
+	require.NoError(t, service.indexDocument(types.Document{ID: "doc", Name: "doc", Value: "Some text."}))
+	require.NoError(t, service.indexDocument(types.Document{ID: "doc", Name: "doc", Value: "  \n"}))
+
+	indexed, err := store.ListDocuments()
+	require.NoError(t, err)
+	assert.Empty(t, indexed)
+}
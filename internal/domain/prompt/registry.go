@@ -0,0 +1,348 @@
+// Package prompt renders the prompts sent to chat models from named sets of
+// text/template files
+package prompt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/robstave/gorag/internal/domain/types"
+)
+
+// DefaultSet is the prompt set used when a request does not name one. Other
+// sets inherit any template they do not define from it.
+const DefaultSet = "default"
+
+// Templates every prompt set must provide
+const (
+	// System is the system prompt for answering; it is executed with AskData
+	System = "system"
+	// Context renders the numbered sources and the question; it is executed with AskData
+	Context = "context"
+	// Citations tells the model how to cite sources; it is executed with AskData
+	// and is usually included by System
+	Citations = "citations"
+	// Rewrite asks for a standalone search query; it is executed with RewriteData
+	Rewrite = "rewrite"
+)
+
+// Kinds lists the templates every prompt set must provide
+var Kinds = []string{System, Context, Citations, Rewrite}
+
+// templateExt is the extension of template files. Files with other
+// extensions are ignored.
+const templateExt = ".tmpl"
+
+// AskData is given to the System, Context and Citations templates
+type AskData struct {
+	Question string
+	Sources  []types.AskSource
+}
+
+// RewriteData is given to the Rewrite template. Messages are the latest
+// messages of the conversation with citation markers removed.
+type RewriteData struct {
+	Question string
+	Messages []types.ConversationMessage
+}
+
+// funcs are available in every template
+var funcs = template.FuncMap{
+	"trim": strings.TrimSpace,
+}
+
+// Registry holds the prompt sets. Each subdirectory of the prompts directory
+// is a set named after it, made of one <template>.tmpl file per template;
+// files with other names can hold partials for {{template}}. The embedded
+// defaults are used for anything the directory does not provide.
+type Registry struct {
+	dir      string
+	defaults fs.FS
+	logger   *slog.Logger
+
+	mu          sync.RWMutex
+	sets        map[string]*template.Template
+	fingerprint string
+}
+
+// NewRegistry loads and validates the prompt sets in dir on top of defaults.
+// A missing dir leaves only the defaults.
+func NewRegistry(dir string, defaults fs.FS, logger *slog.Logger) (*Registry, error) {
+	registry := &Registry{
+		dir:      dir,
+		defaults: defaults,
+		logger:   logger,
+	}
+	if err := registry.Reload(); err != nil {
+		return nil, err
+	}
+	return registry, nil
+}
+
+// Render executes a template of the named set. An empty set name selects DefaultSet.
+func (r *Registry) Render(set string, kind string, data interface{}) (string, error) {
+	if set == "" {
+		set = DefaultSet
+	}
+
+	r.mu.RLock()
+	tmpl, ok := r.sets[set]
+	r.mu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("%w: %q", types.ErrUnknownPromptTemplate, set)
+	}
+
+	var out strings.Builder
+	if err := tmpl.ExecuteTemplate(&out, kind, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out.String()), nil
+}
+
+// Has reports whether the named set exists. An empty name is the default set.
+func (r *Registry) Has(set string) bool {
+	if set == "" {
+		return true
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.sets[set]
+	return ok
+}
+
+// Names returns the names of the loaded prompt sets in order
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.sets))
+	for name := range r.sets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Reload reads and validates every prompt set again. The loaded sets are only
+// replaced if all of them are valid.
+func (r *Registry) Reload() error {
+	fingerprint, err := r.scan()
+	if err != nil {
+		return err
+	}
+	return r.reload(fingerprint)
+}
+
+// Watch reloads the prompt sets whenever a template file in the prompts
+// directory is added, changed or removed, checking every interval. Invalid
+// changes are logged and the previous templates stay in use.
+func (r *Registry) Watch(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				r.logger.Info("Prompt template watcher stopped")
+				return
+			case <-ticker.C:
+			}
+
+			fingerprint, err := r.scan()
+			if err != nil {
+				r.logger.Error("Failed to scan prompt templates", "dir", r.dir, "error", err)
+				continue
+			}
+
+			r.mu.RLock()
+			changed := fingerprint != r.fingerprint
+			r.mu.RUnlock()
+			if !changed {
+				continue
+			}
+
+			if err := r.reload(fingerprint); err != nil {
+				r.logger.Error("Invalid prompt templates, keeping the previous ones", "dir", r.dir, "error", err)
+				// Remember the broken state so it is only reported again once it changes
+				r.mu.Lock()
+				r.fingerprint = fingerprint
+				r.mu.Unlock()
+			}
+		}
+	}()
+}
+
+func (r *Registry) reload(fingerprint string) error {
+	sets, err := r.load()
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.sets = sets
+	r.fingerprint = fingerprint
+	r.mu.Unlock()
+
+	r.logger.Info("Prompt templates loaded", "dir", r.dir, "sets", r.Names())
+	return nil
+}
+
+// load parses and validates the defaults merged with the prompts directory
+func (r *Registry) load() (map[string]*template.Template, error) {
+	sources := make(map[string]map[string]string)
+	if err := readSets(r.defaults, sources); err != nil {
+		return nil, fmt.Errorf("default prompt templates: %w", err)
+	}
+	if r.dir != "" {
+		if _, err := os.Stat(r.dir); err == nil {
+			if err := readSets(os.DirFS(r.dir), sources); err != nil {
+				return nil, err
+			}
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	sets := make(map[string]*template.Template, len(sources))
+	for name, files := range sources {
+		merged := make(map[string]string, len(files))
+		for file, text := range sources[DefaultSet] {
+			merged[file] = text
+		}
+		for file, text := range files {
+			merged[file] = text
+		}
+
+		tmpl, err := parseSet(name, merged)
+		if err != nil {
+			return nil, err
+		}
+		sets[name] = tmpl
+	}
+	if _, ok := sets[DefaultSet]; !ok {
+		return nil, fmt.Errorf("no %q prompt set", DefaultSet)
+	}
+	return sets, nil
+}
+
+// readSets adds the template files of every top-level directory of fsys to
+// sources, keyed by directory name and then template name
+func readSets(fsys fs.FS, sources map[string]map[string]string) error {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		files, err := fs.Glob(fsys, entry.Name()+"/*"+templateExt)
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			data, err := fs.ReadFile(fsys, file)
+			if err != nil {
+				return err
+			}
+			if sources[entry.Name()] == nil {
+				sources[entry.Name()] = make(map[string]string)
+			}
+			name := strings.TrimSuffix(path.Base(file), templateExt)
+			sources[entry.Name()][name] = string(data)
+		}
+	}
+	return nil
+}
+
+// parseSet parses the templates of one set and executes each required
+// template with example data, so mistakes such as unknown fields are found
+// when the set is loaded rather than when a question is asked
+func parseSet(set string, files map[string]string) (*template.Template, error) {
+	root := template.New(set).Funcs(funcs)
+	for name, text := range files {
+		// Drop the final newline so templates can be included inline
+		if _, err := root.New(name).Parse(strings.TrimSuffix(text, "\n")); err != nil {
+			return nil, fmt.Errorf("prompt set %q: %w", set, err)
+		}
+	}
+
+	askData := AskData{
+		Question: "What is an example?",
+		Sources: []types.AskSource{
+			{Number: 1, DocumentID: "example", DocumentName: "Example", ChunkID: "example:0", End: 20, Score: 1, Text: "This is an example."},
+		},
+	}
+	rewriteData := RewriteData{
+		Question: "Why?",
+		Messages: []types.ConversationMessage{
+			{Role: "user", Content: "What is an example?"},
+			{Role: "assistant", Content: "This is an example."},
+		},
+	}
+	for _, kind := range Kinds {
+		if root.Lookup(kind) == nil {
+			return nil, fmt.Errorf("prompt set %q: missing %s%s", set, kind, templateExt)
+		}
+
+		var data interface{} = askData
+		if kind == Rewrite {
+			data = rewriteData
+		}
+		if err := root.ExecuteTemplate(io.Discard, kind, data); err != nil {
+			return nil, fmt.Errorf("prompt set %q: %w", set, err)
+		}
+	}
+	return root, nil
+}
+
+// scan fingerprints the template files of the prompt sets by path, size and
+// modification time. Like readSets it only looks at the .tmpl files directly
+// inside each top-level directory, so other files do not trigger a reload.
+func (r *Registry) scan() (string, error) {
+	if r.dir == "" {
+		return "", nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(r.dir, "*", "*"+templateExt))
+	if err != nil {
+		return "", err
+	}
+
+	var fingerprint strings.Builder
+	for _, file := range files {
+		if strings.HasPrefix(filepath.Base(filepath.Dir(file)), ".") {
+			continue
+		}
+
+		info, err := os.Stat(file)
+		if errors.Is(err, fs.ErrNotExist) {
+			// Removed since the glob
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		if info.IsDir() {
+			continue
+		}
+		fmt.Fprintf(&fingerprint, "%s:%d:%d\n", file, info.Size(), info.ModTime().UnixNano())
+	}
+	return fingerprint.String(), nil
+}
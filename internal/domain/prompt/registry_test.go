@@ -0,0 +1,167 @@
+package prompt
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/robstave/gorag/internal/domain/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testDefaults returns a default set providing every kind, leaving out the
+// given ones
+func testDefaults(without ...string) fstest.MapFS {
+	defaults := fstest.MapFS{
+		"default/system.tmpl":    {Data: []byte("Answer from the sources. {{template \"citations\" .}}\n")},
+		"default/citations.tmpl": {Data: []byte("Cite as [n].\n")},
+		"default/context.tmpl":   {Data: []byte("{{range .Sources}}[{{.Number}}] {{trim .Text}}\n{{end}}Question: {{.Question}}\n")},
+		"default/rewrite.tmpl":   {Data: []byte("Rewrite: {{.Question}}\n")},
+	}
+	for _, kind := range without {
+		delete(defaults, "default/"+kind+templateExt)
+	}
+	return defaults
+}
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// writeTemplate writes a template file of a set in dir
+func writeTemplate(t *testing.T, dir, set, name, text string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, set), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, set, name), []byte(text), 0o644))
+}
+
+var testAskData = AskData{
+	Question: "Why?",
+	Sources:  []types.AskSource{{Number: 1, Text: "  Because.  "}},
+}
+
+func TestRegistryInheritsDefault(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, dir, "terse", "citations.tmpl", "Cite tersely.\n")
+
+	registry, err := NewRegistry(dir, testDefaults(), testLogger())
+	require.NoError(t, err)
+	assert.Equal(t, []string{DefaultSet, "terse"}, registry.Names())
+
+	// The default system prompt includes the set's own citations template
+	system, err := registry.Render("terse", System, testAskData)
+	require.NoError(t, err)
+	assert.Equal(t, "Answer from the sources. Cite tersely.", system)
+
+	defaultSystem, err := registry.Render("", System, testAskData)
+	require.NoError(t, err)
+	assert.Equal(t, "Answer from the sources. Cite as [n].", defaultSystem)
+
+	// Templates the set does not define come from the default set
+	for _, kind := range []string{Context, Rewrite} {
+		want, err := registry.Render(DefaultSet, kind, testAskData)
+		require.NoError(t, err)
+		got, err := registry.Render("terse", kind, testAskData)
+		require.NoError(t, err)
+		assert.Equal(t, want, got, kind)
+	}
+}
+
+func TestRegistryUnknownSet(t *testing.T) {
+	registry, err := NewRegistry("", testDefaults(), testLogger())
+	require.NoError(t, err)
+
+	_, err = registry.Render("missing", System, testAskData)
+	assert.ErrorIs(t, err, types.ErrUnknownPromptTemplate)
+	assert.False(t, registry.Has("missing"))
+	assert.True(t, registry.Has(""))
+	assert.True(t, registry.Has(DefaultSet))
+}
+
+func TestRegistryRejectsInvalidSets(t *testing.T) {
+	t.Run("missing kind", func(t *testing.T) {
+		_, err := NewRegistry("", testDefaults(Rewrite), testLogger())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "missing rewrite.tmpl")
+	})
+
+	t.Run("no default set", func(t *testing.T) {
+		_, err := NewRegistry("", fstest.MapFS{}, testLogger())
+		assert.Error(t, err)
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		dir := t.TempDir()
+		writeTemplate(t, dir, "broken", "context.tmpl", "{{.Nope}}\n")
+		_, err := NewRegistry(dir, testDefaults(), testLogger())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `prompt set "broken"`)
+	})
+}
+
+func TestRegistryWatchKeepsPreviousSets(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, dir, "custom", "system.tmpl", "First version.\n")
+
+	registry, err := NewRegistry(dir, testDefaults(), testLogger())
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	registry.Watch(ctx, 10*time.Millisecond)
+
+	render := func() string {
+		text, err := registry.Render("custom", System, testAskData)
+		require.NoError(t, err)
+		return text
+	}
+	fingerprint := func() string {
+		registry.mu.RLock()
+		defer registry.mu.RUnlock()
+		return registry.fingerprint
+	}
+
+	// An invalid edit is noticed but the loaded sets stay in use
+	before := fingerprint()
+	writeTemplate(t, dir, "custom", "system.tmpl", "{{.Nope}\n")
+	assert.Eventually(t, func() bool { return fingerprint() != before }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, "First version.", render())
+
+	// Fixing it loads the new version
+	writeTemplate(t, dir, "custom", "system.tmpl", "Second, longer version.\n")
+	assert.Eventually(t, func() bool { return render() == "Second, longer version." }, time.Second, 5*time.Millisecond)
+
+	// A new set is picked up
+	writeTemplate(t, dir, "extra", "rewrite.tmpl", "Extra: {{.Question}}\n")
+	assert.Eventually(t, func() bool { return registry.Has("extra") }, time.Second, 5*time.Millisecond)
+}
+
+func TestRegistryScanOnlySetTemplates(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, dir, "custom", "system.tmpl", "System.\n")
+	registry, err := NewRegistry(dir, testDefaults(), testLogger())
+	require.NoError(t, err)
+
+	before, err := registry.scan()
+	require.NoError(t, err)
+	assert.Contains(t, before, filepath.Join(dir, "custom", "system.tmpl"))
+
+	// Files readSets does not load leave the fingerprint unchanged
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "top.tmpl"), []byte("x"), 0o644))
+	writeTemplate(t, dir, "custom", "notes.md", "x")
+	writeTemplate(t, dir, filepath.Join("custom", "nested"), "system.tmpl", "x")
+	writeTemplate(t, dir, ".hidden", "system.tmpl", "x")
+	after, err := registry.scan()
+	require.NoError(t, err)
+	assert.Equal(t, before, after)
+
+	writeTemplate(t, dir, "custom", "partial.tmpl", "x")
+	after, err = registry.scan()
+	require.NoError(t, err)
+	assert.NotEqual(t, before, after)
+}
@@ -0,0 +1,130 @@
+package domain
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/robstave/gorag/internal/domain/types"
+)
+
+// Reconcile compares the documents in SQLite with the entries in the vector
+// store and reports documents that are missing from the index, index entries
+// with no matching document (orphaned) and entries whose content hash no
+// longer matches the document (stale). Documents with pending outbox entries
+// are reported separately since the dispatcher will bring them in sync.
+// When repair is true, missing and stale documents are re-indexed and orphaned
+// entries are deleted.
+func (s *Service) Reconcile(repair bool) (*types.ReconcileReport, error) {
+	s.logger.Info("Reconciling documents with vector store", "repair", repair)
+
+	if s.vectorStore == nil {
+		s.logger.Error("Reconcile requested but no vector store is configured")
+		return nil, errors.New("vector store not configured")
+	}
+
+	documents, err := s.repo.GetAlldocuments()
+	if err != nil {
+		s.logger.Error("Failed to retrieve documents", "error", err)
+		return nil, err
+	}
+
+	indexed, err := s.vectorStore.ListDocuments()
+	if err != nil {
+		s.logger.Error("Failed to list vector store entries", "error", err)
+		return nil, err
+	}
+
+	pendingEntries, err := s.repo.GetPendingOutbox(-1)
+	if err != nil {
+		s.logger.Error("Failed to load outbox entries", "error", err)
+		return nil, err
+	}
+
+	pending := make(map[string]bool)
+	for _, entry := range pendingEntries {
+		pending[entry.DocumentID] = true
+	}
+
+	// Group chunk entries by parent document, remembering every content hash
+	// seen so a partially re-indexed document is reported as stale
+	hashes := make(map[string]map[string]bool)
+	for _, entry := range indexed {
+		if hashes[entry.DocumentID] == nil {
+			hashes[entry.DocumentID] = make(map[string]bool)
+		}
+		hashes[entry.DocumentID][entry.ContentHash] = true
+	}
+
+	report := &types.ReconcileReport{
+		Documents: len(documents),
+		Indexed:   len(hashes),
+		Chunks:    len(indexed),
+		Missing:   []string{},
+		Orphaned:  []string{},
+		Stale:     []string{},
+		Pending:   []string{},
+	}
+
+	byID := make(map[string]types.Document, len(documents))
+	for _, document := range documents {
+		byID[document.ID] = document
+
+		if pending[document.ID] {
+			report.Pending = append(report.Pending, document.ID)
+			continue
+		}
+
+		seen, ok := hashes[document.ID]
+		switch {
+		case !ok && strings.TrimSpace(document.Value) == "":
+			// Blank documents have no chunks to index
+		case !ok:
+			report.Missing = append(report.Missing, document.ID)
+		case len(seen) != 1 || !seen[document.ContentHash()]:
+			report.Stale = append(report.Stale, document.ID)
+		}
+	}
+
+	for documentID := range hashes {
+		if _, ok := byID[documentID]; !ok && !pending[documentID] {
+			report.Orphaned = append(report.Orphaned, documentID)
+		}
+	}
+
+	sort.Strings(report.Missing)
+	sort.Strings(report.Orphaned)
+	sort.Strings(report.Stale)
+	sort.Strings(report.Pending)
+
+	s.logger.Info("Reconcile complete",
+		"documents", report.Documents,
+		"indexed", report.Indexed,
+		"chunks", report.Chunks,
+		"missing", len(report.Missing),
+		"orphaned", len(report.Orphaned),
+		"stale", len(report.Stale),
+		"pending", len(report.Pending))
+
+	if !repair {
+		return report, nil
+	}
+
+	for _, id := range append(append([]string{}, report.Missing...), report.Stale...) {
+		if err := s.indexDocument(byID[id]); err != nil {
+			report.Errors = append(report.Errors, id+": "+err.Error())
+		}
+	}
+	for _, id := range report.Orphaned {
+		if err := s.unindexDocument(id); err != nil {
+			report.Errors = append(report.Errors, id+": "+err.Error())
+		}
+	}
+	report.Repaired = len(report.Errors) == 0
+
+	if !report.Repaired {
+		s.logger.Warn("Reconcile repair finished with errors", "errors", len(report.Errors))
+	}
+
+	return report, nil
+}
@@ -0,0 +1,59 @@
+package domain
+
+import (
+	"context"
+	"testing"
+
+	"github.com/robstave/gorag/internal/domain/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReconcileClassifiesDrift(t *testing.T) {
+	store := newMemoryStore(t)
+	service, repo := newTestService(t, store)
+
+	for _, id := range []string{"synced", "missing", "stale", "pending"} {
+		require.NoError(t, repo.Createdocument(types.Document{ID: id, Name: id, Value: "The " + id + " document."}))
+	}
+	// Blank documents have no chunks and are not reported as missing
+	require.NoError(t, repo.Createdocument(types.Document{ID: "blank", Name: "blank", Value: " "}))
+	require.NoError(t, service.DispatchOutbox())
+
+	require.NoError(t, store.DeleteDocument("missing"))
+	embeddings, err := service.embedService.Embed(context.Background(), []string{"old text", "orphaned text"})
+	require.NoError(t, err)
+	require.NoError(t, store.AddChunks([]types.Chunk{
+		{ID: "stale:0", DocumentID: "stale", ContentHash: "outdated"},
+		{ID: "orphan:0", DocumentID: "orphan", ContentHash: "gone"},
+	}, embeddings))
+	require.NoError(t, repo.Updatedocument(types.Document{ID: "pending", Name: "pending", Value: "Changed."}))
+
+	report, err := service.Reconcile(false)
+	require.NoError(t, err)
+	assert.Equal(t, 5, report.Documents)
+	assert.Equal(t, []string{"missing"}, report.Missing)
+	assert.Equal(t, []string{"stale"}, report.Stale)
+	assert.Equal(t, []string{"orphan"}, report.Orphaned)
+	assert.Equal(t, []string{"pending"}, report.Pending)
+	assert.False(t, report.Repaired)
+
+	report, err = service.Reconcile(true)
+	require.NoError(t, err)
+	assert.True(t, report.Repaired)
+	assert.Empty(t, report.Errors)
+
+	report, err = service.Reconcile(false)
+	require.NoError(t, err)
+	assert.Empty(t, report.Missing)
+	assert.Empty(t, report.Stale)
+	assert.Empty(t, report.Orphaned)
+	assert.Equal(t, []string{"pending"}, report.Pending)
+}
+
+func TestReconcileRequiresVectorStore(t *testing.T) {
+	service, _ := newTestService(t, nil)
+
+	_, err := service.Reconcile(false)
+	assert.Error(t, err)
+}
@@ -0,0 +1,132 @@
+package rerank
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+const (
+	cohereBaseURL = "https://api.cohere.com/v2"
+	jinaBaseURL   = "https://api.jina.ai/v1"
+)
+
+// crossEncoderModels holds the default model of each hosted provider
+var crossEncoderModels = map[string]string{
+	ProviderCohere: "rerank-v3.5",
+	ProviderJina:   "jina-reranker-v2-base-multilingual",
+}
+
+// CrossEncoderReranker scores documents with a hosted cross-encoder. Cohere
+// and Jina share a request format, which vLLM and Infinity also accept; TEI
+// (Hugging Face text-embeddings-inference) has its own.
+type CrossEncoderReranker struct {
+	client   *http.Client
+	provider string
+	apiKey   string
+	model    string
+	endpoint string
+	logger   *slog.Logger
+}
+
+// NewCrossEncoderReranker creates a reranker for the cohere, jina or tei provider
+func NewCrossEncoderReranker(config Config, logger *slog.Logger) (*CrossEncoderReranker, error) {
+	baseURL := config.BaseURL
+	if baseURL == "" {
+		switch config.Provider {
+		case ProviderCohere:
+			baseURL = cohereBaseURL
+		case ProviderJina:
+			baseURL = jinaBaseURL
+		default:
+			return nil, fmt.Errorf("base URL is required for rerank provider %s", config.Provider)
+		}
+	}
+
+	model := config.Model
+	if model == "" {
+		model = crossEncoderModels[config.Provider]
+	}
+
+	return &CrossEncoderReranker{
+		client: &http.Client{
+			Timeout: config.timeout(),
+		},
+		provider: config.Provider,
+		apiKey:   config.APIKey,
+		model:    model,
+		endpoint: strings.TrimSuffix(baseURL, "/") + "/rerank",
+		logger:   logger,
+	}, nil
+}
+
+// Rerank scores every document in a single request
+func (r *CrossEncoderReranker) Rerank(ctx context.Context, query string, documents []string) ([]float64, error) {
+	if len(documents) == 0 {
+		return []float64{}, nil
+	}
+
+	type result struct {
+		Index int     `json:"index"`
+		Score float64 `json:"score"`
+		// Cohere and Jina name the score relevance_score
+		RelevanceScore float64 `json:"relevance_score"`
+	}
+
+	var results []result
+	var err error
+	if r.provider == ProviderTEI {
+		// TEI applies a sigmoid to the logits unless raw scores are requested
+		reqBody := map[string]interface{}{
+			"query":      query,
+			"texts":      documents,
+			"raw_scores": false,
+		}
+		err = postJSON(ctx, r.client, r.endpoint, r.apiKey, reqBody, &results)
+	} else {
+		reqBody := map[string]interface{}{
+			"model":     r.model,
+			"query":     query,
+			"documents": documents,
+			"top_n":     len(documents),
+		}
+		var resp struct {
+			Results []result `json:"results"`
+		}
+		err = postJSON(ctx, r.client, r.endpoint, r.apiKey, reqBody, &resp)
+		results = resp.Results
+		for i := range results {
+			results[i].Score = results[i].RelevanceScore
+		}
+	}
+	if err != nil {
+		r.logger.Error("Failed to call rerank API", "provider", r.provider, "error", err)
+		return nil, err
+	}
+
+	scores := make([]float64, len(documents))
+	seen := make([]bool, len(documents))
+	for _, result := range results {
+		if result.Index < 0 || result.Index >= len(documents) {
+			return nil, fmt.Errorf("invalid rerank result index %d", result.Index)
+		}
+		scores[result.Index] = result.Score
+		seen[result.Index] = true
+	}
+	for i, ok := range seen {
+		if !ok {
+			return nil, fmt.Errorf("missing rerank score at index %d", i)
+		}
+	}
+	return scores, nil
+}
+
+// ModelID identifies the provider and model
+func (r *CrossEncoderReranker) ModelID() string {
+	if r.model == "" {
+		return r.provider
+	}
+	return r.provider + "/" + r.model
+}
@@ -0,0 +1,155 @@
+package rerank
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// rerankServer answers every request with reply and records the decoded
+// request bodies and Authorization headers
+type rerankServer struct {
+	*httptest.Server
+	requests []map[string]interface{}
+	auth     []string
+}
+
+func newRerankServer(t *testing.T, status int, reply string) *rerankServer {
+	t.Helper()
+	server := &rerankServer{}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		server.requests = append(server.requests, body)
+		server.auth = append(server.auth, r.Header.Get("Authorization"))
+		w.WriteHeader(status)
+		io.WriteString(w, reply)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestCrossEncoderRelevanceScore(t *testing.T) {
+	for _, provider := range []string{ProviderCohere, ProviderJina} {
+		t.Run(provider, func(t *testing.T) {
+			// Results come back sorted by relevance, not in input order
+			server := newRerankServer(t, http.StatusOK, `{"results": [
+				{"index": 2, "relevance_score": 0.9},
+				{"index": 0, "relevance_score": 0.4},
+				{"index": 1, "relevance_score": 0.1}
+			]}`)
+			reranker, err := NewCrossEncoderReranker(Config{Provider: provider, BaseURL: server.URL + "/", APIKey: "key"}, testLogger())
+			require.NoError(t, err)
+
+			scores, err := reranker.Rerank(context.Background(), "query", []string{"a", "b", "c"})
+			require.NoError(t, err)
+			assert.Equal(t, []float64{0.4, 0.1, 0.9}, scores)
+
+			require.Len(t, server.requests, 1)
+			request := server.requests[0]
+			assert.Equal(t, crossEncoderModels[provider], request["model"])
+			assert.Equal(t, "query", request["query"])
+			assert.Equal(t, []interface{}{"a", "b", "c"}, request["documents"])
+			assert.Equal(t, float64(3), request["top_n"])
+			assert.Equal(t, "Bearer key", server.auth[0])
+			assert.Equal(t, provider+"/"+crossEncoderModels[provider], reranker.ModelID())
+		})
+	}
+}
+
+func TestCrossEncoderTEIScore(t *testing.T) {
+	server := newRerankServer(t, http.StatusOK, `[{"index": 1, "score": 0.75}, {"index": 0, "score": 0.25}]`)
+	reranker, err := NewCrossEncoderReranker(Config{Provider: ProviderTEI, BaseURL: server.URL}, testLogger())
+	require.NoError(t, err)
+
+	scores, err := reranker.Rerank(context.Background(), "query", []string{"a", "b"})
+	require.NoError(t, err)
+	assert.Equal(t, []float64{0.25, 0.75}, scores)
+
+	request := server.requests[0]
+	assert.Equal(t, []interface{}{"a", "b"}, request["texts"])
+	assert.Equal(t, false, request["raw_scores"])
+	assert.NotContains(t, request, "documents")
+	assert.Empty(t, server.auth[0])
+	assert.Equal(t, ProviderTEI, reranker.ModelID())
+}
+
+func TestCrossEncoderInvalidResults(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		status   int
+		reply    string
+		wantErr  string
+	}{
+		{
+			name:     "index out of range",
+			provider: ProviderCohere,
+			status:   http.StatusOK,
+			reply:    `{"results": [{"index": 0, "relevance_score": 0.5}, {"index": 2, "relevance_score": 0.5}]}`,
+			wantErr:  "invalid rerank result index 2",
+		},
+		{
+			name:     "negative index",
+			provider: ProviderTEI,
+			status:   http.StatusOK,
+			reply:    `[{"index": -1, "score": 0.5}, {"index": 1, "score": 0.5}]`,
+			wantErr:  "invalid rerank result index -1",
+		},
+		{
+			name:     "missing index",
+			provider: ProviderJina,
+			status:   http.StatusOK,
+			reply:    `{"results": [{"index": 1, "relevance_score": 0.5}]}`,
+			wantErr:  "missing rerank score at index 0",
+		},
+		{
+			name:     "error status",
+			provider: ProviderTEI,
+			status:   http.StatusServiceUnavailable,
+			reply:    `model loading`,
+			wantErr:  "503",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newRerankServer(t, tt.status, tt.reply)
+			reranker, err := NewCrossEncoderReranker(Config{Provider: tt.provider, BaseURL: server.URL}, testLogger())
+			require.NoError(t, err)
+
+			_, err = reranker.Rerank(context.Background(), "query", []string{"a", "b"})
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestCrossEncoderConfig(t *testing.T) {
+	_, err := NewCrossEncoderReranker(Config{Provider: ProviderTEI}, testLogger())
+	assert.Error(t, err, "tei has no default endpoint")
+
+	reranker, err := NewCrossEncoderReranker(Config{Provider: ProviderCohere, Model: "rerank-english-v3.0"}, testLogger())
+	require.NoError(t, err)
+	assert.Equal(t, cohereBaseURL+"/rerank", reranker.endpoint)
+	assert.Equal(t, "cohere/rerank-english-v3.0", reranker.ModelID())
+
+	scores, err := reranker.Rerank(context.Background(), "query", nil)
+	require.NoError(t, err)
+	assert.Empty(t, scores, "no request is made without documents")
+
+	_, err = New(Config{Provider: "unknown"}, testLogger())
+	assert.Error(t, err)
+}
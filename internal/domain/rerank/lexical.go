@@ -0,0 +1,73 @@
+package rerank
+
+import (
+	"context"
+	"strings"
+	"unicode"
+)
+
+// LexicalReranker scores documents by how many of the query's terms they
+// contain. It needs no model or network, so it works offline, and favours
+// exact identifiers that embeddings tend to blur.
+type LexicalReranker struct{}
+
+// NewLexicalReranker creates a lexical-overlap reranker
+func NewLexicalReranker() *LexicalReranker {
+	return &LexicalReranker{}
+}
+
+// Rerank scores each document by the fraction of distinct query terms it
+// contains, with a small bonus when the whole query appears verbatim
+func (r *LexicalReranker) Rerank(ctx context.Context, query string, documents []string) ([]float64, error) {
+	terms := lexicalTerms(query)
+	phrase := strings.ToLower(strings.TrimSpace(query))
+
+	scores := make([]float64, len(documents))
+	if len(terms) == 0 {
+		return scores, nil
+	}
+
+	for i, document := range documents {
+		present := make(map[string]bool)
+		for _, term := range lexicalTerms(document) {
+			present[term] = true
+		}
+
+		matched := 0
+		for _, term := range terms {
+			if present[term] {
+				matched++
+			}
+		}
+
+		score := 0.9 * float64(matched) / float64(len(terms))
+		if strings.Contains(strings.ToLower(document), phrase) {
+			score += 0.1
+		}
+		scores[i] = score
+	}
+	return scores, nil
+}
+
+// ModelID identifies the reranker
+func (r *LexicalReranker) ModelID() string {
+	return ProviderLexical
+}
+
+// lexicalTerms returns the distinct lowercased words of text. Underscores
+// are kept so snake_case identifiers stay whole.
+func lexicalTerms(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+
+	seen := make(map[string]bool, len(words))
+	terms := words[:0]
+	for _, word := range words {
+		if !seen[word] {
+			seen[word] = true
+			terms = append(terms, word)
+		}
+	}
+	return terms
+}
@@ -0,0 +1,28 @@
+package rerank
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLexicalReranker(t *testing.T) {
+	reranker := NewLexicalReranker()
+	scores, err := reranker.Rerank(context.Background(), "Reset password", []string{
+		"Use the reset password link",
+		"How to reset the router password",
+		"Reset the router",
+		"Banana bread",
+	})
+	require.NoError(t, err)
+	assert.InDelta(t, 1.0, scores[0], 1e-9, "every term plus the verbatim phrase")
+	assert.InDelta(t, 0.9, scores[1], 1e-9)
+	assert.InDelta(t, 0.45, scores[2], 1e-9)
+	assert.Equal(t, 0.0, scores[3])
+
+	scores, err = reranker.Rerank(context.Background(), "  ", []string{"anything"})
+	require.NoError(t, err)
+	assert.Equal(t, []float64{0}, scores)
+}
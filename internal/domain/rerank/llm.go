@@ -0,0 +1,192 @@
+package rerank
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+const openAIBaseURL = "https://api.openai.com/v1"
+
+// llmPassageLimit caps the characters of each passage sent to the judge
+const llmPassageLimit = 1500
+
+// Candidates are rated in batches of llmBatchSize per request, with up to
+// llmConcurrency requests in flight, so large candidate sets neither overflow
+// the model's context nor run one request per candidate. Ratings are on an
+// absolute scale, so scores from different batches are comparable.
+const (
+	llmBatchSize   = 20
+	llmConcurrency = 4
+)
+
+const llmJudgePrompt = `You judge how relevant passages are to a search query.
+Rate each passage from 0 (irrelevant) to 10 (directly answers the query).
+Reply with only a JSON array of numbers, one per passage, in the order given.`
+
+// LLMReranker asks a chat model to rate the candidates, a batch per request.
+// It works with the OpenAI chat completions API or any server that implements
+// it, including Ollama's /v1 endpoint.
+type LLMReranker struct {
+	client   *http.Client
+	apiKey   string
+	model    string
+	endpoint string
+	logger   *slog.Logger
+}
+
+// NewLLMReranker creates an LLM-as-judge reranker for the chat API at config.BaseURL
+func NewLLMReranker(config Config, logger *slog.Logger) *LLMReranker {
+	baseURL := config.BaseURL
+	if baseURL == "" {
+		baseURL = openAIBaseURL
+	}
+	model := config.Model
+	if model == "" {
+		model = "gpt-4o-mini"
+	}
+
+	return &LLMReranker{
+		client: &http.Client{
+			Timeout: config.timeout(),
+		},
+		apiKey:   config.APIKey,
+		model:    model,
+		endpoint: strings.TrimSuffix(baseURL, "/") + "/chat/completions",
+		logger:   logger,
+	}
+}
+
+// Rerank rates the documents in batches and scales the ratings to [0, 1]. If
+// any batch fails the whole call fails.
+func (r *LLMReranker) Rerank(ctx context.Context, query string, documents []string) ([]float64, error) {
+	if len(documents) <= llmBatchSize {
+		return r.rate(ctx, query, documents)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	scores := make([]float64, len(documents))
+	sem := make(chan struct{}, llmConcurrency)
+
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+
+	for start := 0; start < len(documents); start += llmBatchSize {
+		end := min(start+llmBatchSize, len(documents))
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			batch, err := r.rate(ctx, query, documents[start:end])
+			if err != nil {
+				once.Do(func() {
+					firstErr = err
+					cancel()
+				})
+				return
+			}
+			copy(scores[start:end], batch)
+		}(start, end)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return scores, nil
+}
+
+// rate asks the model to rate one batch of documents in a single request
+func (r *LLMReranker) rate(ctx context.Context, query string, documents []string) ([]float64, error) {
+	if len(documents) == 0 {
+		return []float64{}, nil
+	}
+
+	var prompt strings.Builder
+	fmt.Fprintf(&prompt, "Query: %s\n\nPassages:\n", query)
+	for i, document := range documents {
+		if runes := []rune(document); len(runes) > llmPassageLimit {
+			document = string(runes[:llmPassageLimit])
+		}
+		fmt.Fprintf(&prompt, "[%d] %s\n\n", i+1, strings.TrimSpace(document))
+	}
+
+	reqBody := map[string]interface{}{
+		"model":       r.model,
+		"temperature": 0,
+		"messages": []map[string]string{
+			{"role": "system", "content": llmJudgePrompt},
+			{"role": "user", "content": prompt.String()},
+		},
+	}
+	var resp struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := postJSON(ctx, r.client, r.endpoint, r.apiKey, reqBody, &resp); err != nil {
+		r.logger.Error("Failed to call chat API for reranking", "model", r.model, "error", err)
+		return nil, err
+	}
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("chat API returned no choices")
+	}
+
+	ratings, err := parseRatings(resp.Choices[0].Message.Content, len(documents))
+	if err != nil {
+		r.logger.Error("Failed to parse reranking ratings", "model", r.model, "error", err)
+		return nil, err
+	}
+
+	scores := make([]float64, len(ratings))
+	for i, rating := range ratings {
+		scores[i] = min(max(rating/10, 0), 1)
+	}
+	return scores, nil
+}
+
+// ModelID identifies the provider and model
+func (r *LLMReranker) ModelID() string {
+	return ProviderLLM + "/" + r.model
+}
+
+// parseRatings extracts the JSON array of n ratings from a model reply, which
+// may wrap it in prose or a code fence
+func parseRatings(content string, n int) ([]float64, error) {
+	start := strings.Index(content, "[")
+	end := strings.LastIndex(content, "]")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("no ratings array in reply: %q", content)
+	}
+
+	var ratings []float64
+	if err := json.Unmarshal([]byte(content[start:end+1]), &ratings); err != nil {
+		return nil, fmt.Errorf("invalid ratings array: %w", err)
+	}
+	if len(ratings) != n {
+		return nil, fmt.Errorf("expected %d ratings, got %d", n, len(ratings))
+	}
+	return ratings, nil
+}
@@ -0,0 +1,140 @@
+package rerank
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRatings(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		n       int
+		want    []float64
+		wantErr string
+	}{
+		{name: "bare array", content: "[7, 2, 10]", n: 3, want: []float64{7, 2, 10}},
+		{name: "code fence", content: "```json\n[3, 8.5]\n```", n: 2, want: []float64{3, 8.5}},
+		{name: "prose", content: "Here are the ratings: [0, 5]. Hope this helps!", n: 2, want: []float64{0, 5}},
+		{name: "wrong count", content: "[1, 2, 3]", n: 2, wantErr: "expected 2 ratings, got 3"},
+		{name: "no array", content: "I cannot rate these.", n: 1, wantErr: "no ratings array"},
+		{name: "not numbers", content: `["high", "low"]`, n: 2, wantErr: "invalid ratings array"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ratings, err := parseRatings(tt.content, tt.n)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, ratings)
+		})
+	}
+}
+
+// passageNumber matches the passage markers in a judge prompt
+var passageNumber = regexp.MustCompile(`(?m)^\[(\d+)\] doc(\d+)$`)
+
+// newJudgeServer rates each passage "docN" as N mod 11, wrapping the array in
+// a code fence, and records how many passages each request carried
+func newJudgeServer(t *testing.T) (*httptest.Server, func() []int) {
+	t.Helper()
+	var mu sync.Mutex
+	var sizes []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Messages []struct {
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var ratings []string
+		for _, match := range passageNumber.FindAllStringSubmatch(body.Messages[1].Content, -1) {
+			var n int
+			fmt.Sscan(match[2], &n)
+			ratings = append(ratings, fmt.Sprint(n%11))
+		}
+		mu.Lock()
+		sizes = append(sizes, len(ratings))
+		mu.Unlock()
+
+		content := "```json\n[" + strings.Join(ratings, ", ") + "]\n```"
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": map[string]string{"content": content}}},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []int {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]int(nil), sizes...)
+	}
+}
+
+func TestLLMRerankerScalesRatings(t *testing.T) {
+	server, sizes := newJudgeServer(t)
+	reranker := NewLLMReranker(Config{BaseURL: server.URL}, testLogger())
+
+	scores, err := reranker.Rerank(context.Background(), "query", []string{"doc10", "doc3", "doc0"})
+	require.NoError(t, err)
+	assert.Equal(t, []float64{1, 0.3, 0}, scores)
+	assert.Equal(t, []int{3}, sizes())
+	assert.Equal(t, "llm/gpt-4o-mini", reranker.ModelID())
+}
+
+func TestLLMRerankerBatchesCandidates(t *testing.T) {
+	server, sizes := newJudgeServer(t)
+	reranker := NewLLMReranker(Config{BaseURL: server.URL}, testLogger())
+
+	documents := make([]string, 2*llmBatchSize+5)
+	for i := range documents {
+		documents[i] = fmt.Sprintf("doc%d", i)
+	}
+	scores, err := reranker.Rerank(context.Background(), "query", documents)
+	require.NoError(t, err)
+	require.Len(t, scores, len(documents))
+	for i, score := range scores {
+		assert.InDelta(t, float64(i%11)/10, score, 1e-9, documents[i])
+	}
+	assert.ElementsMatch(t, []int{llmBatchSize, llmBatchSize, 5}, sizes())
+}
+
+func TestLLMRerankerFailures(t *testing.T) {
+	tests := []struct {
+		name    string
+		reply   string
+		wantErr string
+	}{
+		{name: "no choices", reply: `{"choices": []}`, wantErr: "no choices"},
+		{name: "wrong count", reply: `{"choices": [{"message": {"content": "[5]"}}]}`, wantErr: "expected 2 ratings, got 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newRerankServer(t, http.StatusOK, tt.reply)
+			reranker := NewLLMReranker(Config{BaseURL: server.URL}, testLogger())
+
+			_, err := reranker.Rerank(context.Background(), "query", []string{"a", "b"})
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+
+	// One failing batch fails the whole call
+	server := newRerankServer(t, http.StatusTooManyRequests, `slow down`)
+	reranker := NewLLMReranker(Config{BaseURL: server.URL}, testLogger())
+	_, err := reranker.Rerank(context.Background(), "query", make([]string, 3*llmBatchSize))
+	assert.ErrorContains(t, err, "429")
+}
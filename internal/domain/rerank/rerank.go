@@ -0,0 +1,93 @@
+// Package rerank reorders retrieved chunks by their relevance to the query
+package rerank
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Reranker scores candidate texts against a query
+type Reranker interface {
+	// Rerank returns one relevance score in [0, 1] per document, in the same
+	// order, where higher is more relevant
+	Rerank(ctx context.Context, query string, documents []string) ([]float64, error)
+
+	// ModelID identifies the provider and model, e.g. "cohere/rerank-v3.5"
+	ModelID() string
+}
+
+// Providers accepted by Config.Provider
+const (
+	ProviderCohere  = "cohere"
+	ProviderJina    = "jina"
+	ProviderTEI     = "tei"
+	ProviderLLM     = "llm"
+	ProviderLexical = "lexical"
+)
+
+// Config selects and configures a reranker
+type Config struct {
+	Provider string
+	Model    string
+	// BaseURL overrides the provider's default endpoint
+	BaseURL string
+	APIKey  string
+	// Timeout bounds a single HTTP request (default 30s)
+	Timeout time.Duration
+}
+
+func (c Config) timeout() time.Duration {
+	if c.Timeout > 0 {
+		return c.Timeout
+	}
+	return time.Second * 30
+}
+
+// New creates the reranker selected by the configuration
+func New(config Config, logger *slog.Logger) (Reranker, error) {
+	switch config.Provider {
+	case ProviderCohere, ProviderJina, ProviderTEI:
+		return NewCrossEncoderReranker(config, logger)
+	case ProviderLLM:
+		return NewLLMReranker(config, logger), nil
+	case ProviderLexical:
+		return NewLexicalReranker(), nil
+	default:
+		return nil, fmt.Errorf("unknown rerank provider: %s", config.Provider)
+	}
+}
+
+// postJSON sends body as JSON to url and decodes the response into out
+func postJSON(ctx context.Context, client *http.Client, url string, apiKey string, body interface{}, out interface{}) error {
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("rerank request failed: %s: %s", resp.Status, string(respBody))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
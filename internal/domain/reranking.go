@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"context"
+	"sort"
+
+	"github.com/robstave/gorag/internal/domain/types"
+)
+
+// defaultRerankCandidates is how many results are retrieved for the reranker
+// to choose from when the query does not say
+const defaultRerankCandidates = 50
+
+// rerankResults reorders results by the reranker's relevance scores. If the
+// reranker fails the retrieval order is kept, so a reranker outage degrades
+// search rather than breaking it.
+func (s *Service) rerankResults(ctx context.Context, query string, results []types.SearchResult) []types.SearchResult {
+	if len(results) == 0 {
+		return results
+	}
+
+	texts := make([]string, len(results))
+	for i, result := range results {
+		texts[i] = result.Chunk.Text
+	}
+
+	scores, err := s.reranker.Rerank(ctx, query, texts)
+	if err != nil {
+		s.logger.Warn("Reranking failed, keeping retrieval order", "model", s.reranker.ModelID(), "error", err)
+		return results
+	}
+
+	for i := range results {
+		score := scores[i]
+		results[i].RerankScore = &score
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return *results[i].RerankScore > *results[j].RerankScore
+	})
+	return results
+}
@@ -0,0 +1,93 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/robstave/gorag/internal/domain/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubReranker returns fixed scores, or err when it is set. It records the
+// state of the context it was last called with.
+type stubReranker struct {
+	scores []float64
+	err    error
+	calls  int
+	ctxErr error
+}
+
+func (r *stubReranker) Rerank(ctx context.Context, query string, documents []string) ([]float64, error) {
+	r.calls++
+	r.ctxErr = ctx.Err()
+	if r.ctxErr != nil {
+		return nil, r.ctxErr
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return r.scores[:len(documents)], nil
+}
+
+func (r *stubReranker) ModelID() string {
+	return "stub"
+}
+
+func TestRerankResults(t *testing.T) {
+	service, _ := newTestService(t, newMemoryStore(t))
+	reranker := &stubReranker{scores: []float64{0.2, 0.9, 0.5}}
+	service.reranker = reranker
+
+	results := service.rerankResults(context.Background(), "query", ranked("cosine", "a", 0.9, "b", 0.8, "c", 0.7))
+	ids := make([]string, len(results))
+	for i, result := range results {
+		ids[i] = result.ID
+	}
+	assert.Equal(t, []string{"b", "c", "a"}, ids)
+	require.NotNil(t, results[0].RerankScore)
+	assert.Equal(t, 0.9, *results[0].RerankScore)
+	assert.Equal(t, 0.8, results[0].Score, "the retrieval score is kept")
+
+	assert.Empty(t, service.rerankResults(context.Background(), "query", nil))
+	assert.Equal(t, 1, reranker.calls, "nothing to rerank makes no call")
+}
+
+func TestRerankResultsFallsBackToRetrievalOrder(t *testing.T) {
+	service, _ := newTestService(t, newMemoryStore(t))
+	service.reranker = &stubReranker{err: errors.New("rerank API unavailable")}
+
+	results := service.rerankResults(context.Background(), "query", ranked("cosine", "a", 0.9, "b", 0.8, "c", 0.7))
+	require.Len(t, results, 3)
+	for i, id := range []string{"a", "b", "c"} {
+		assert.Equal(t, id, results[i].ID)
+		assert.Nil(t, results[i].RerankScore)
+	}
+}
+
+func TestSearchWithFailingReranker(t *testing.T) {
+	store := newMemoryStore(t)
+	service, _ := newTestService(t, store)
+
+	texts := []string{"reset the router password", "router firmware updates", "banana bread recipe"}
+	embeddings, err := service.embedService.Embed(context.Background(), texts)
+	require.NoError(t, err)
+	require.NoError(t, store.AddChunks([]types.Chunk{
+		{ID: "a:0", DocumentID: "a", Text: texts[0]},
+		{ID: "b:0", DocumentID: "b", Text: texts[1]},
+		{ID: "c:0", DocumentID: "c", Text: texts[2]},
+	}, embeddings))
+	reranker := &stubReranker{err: errors.New("timeout")}
+	service.reranker = reranker
+
+	results, err := service.SearchDocuments(context.Background(), types.SearchQuery{Query: texts[0], Limit: 2})
+	require.NoError(t, err, "a reranker outage degrades search rather than failing it")
+	require.Len(t, results, 2)
+	assert.Equal(t, "a:0", results[0].ID)
+	assert.Equal(t, 1, reranker.calls)
+
+	_, err = service.SearchDocuments(context.Background(), types.SearchQuery{Query: texts[0], SkipRerank: true})
+	require.NoError(t, err)
+	assert.Equal(t, 1, reranker.calls, "skip_rerank bypasses the reranker")
+}
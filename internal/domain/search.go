@@ -1,19 +1,24 @@
 package domain
 
 import (
+	"context"
+	"errors"
+	"fmt"
+
 	"github.com/robstave/gorag/internal/domain/types"
 )
 
-// SearchDocuments searches for documents using vector similarity
-func (s *Service) SearchDocuments(query types.SearchQuery) ([]types.SearchResult, error) {
-	s.logger.Info("Searching documents", "query", query.Query)
+// hybridOverfetch is how many candidates per result hybrid search takes from
+// each ranking before fusing them
+const hybridOverfetch = 4
 
-	// Generate embedding for the query
-	embedding, err := s.embedService.CreateEmbedding(query.Query)
-	if err != nil {
-		s.logger.Error("Failed to create embedding", "error", err)
-		return nil, err
-	}
+// SearchDocuments searches for document chunks by vector similarity, keyword
+// match or both, as selected by query.Mode. When a reranker is configured or
+// the results are diversified, a larger candidate set is retrieved and
+// narrowed down to the limit. Each result carries the matched chunk and its
+// parent document.
+func (s *Service) SearchDocuments(ctx context.Context, query types.SearchQuery) ([]types.SearchResult, error) {
+	s.logger.Info("Searching documents", "query", query.Query, "mode", query.Mode)
 
 	// Use a default limit if not specified
 	limit := query.Limit
@@ -21,27 +26,149 @@ func (s *Service) SearchDocuments(query types.SearchQuery) ([]types.SearchResult
 		limit = 5
 	}
 
-	// Query the vector store
-	results, err := s.vectorStore.QueryDocuments(query.Query, embedding, limit)
+	// Over-fetch candidates for the reranker and diversification to choose from
+	rerank := s.reranker != nil && !query.SkipRerank
+	diversify := query.MMRLambda != nil || query.MaxPerDocument > 0
+	retrieve := limit
+	if rerank {
+		retrieve = query.RerankCandidates
+		if retrieve <= 0 {
+			retrieve = defaultRerankCandidates
+		}
+		retrieve = max(retrieve, limit)
+	}
+	if diversify {
+		retrieve = max(retrieve, limit*diversifyOverfetch)
+	}
+
+	var results []types.SearchResult
+	var err error
+	switch query.Mode {
+	case "", types.SearchModeVector:
+		results, err = s.vectorSearch(ctx, query, retrieve)
+	case types.SearchModeKeyword:
+		results, err = s.keywordSearch(query, retrieve)
+	case types.SearchModeHybrid:
+		results, err = s.hybridSearch(ctx, query, retrieve)
+	default:
+		err = fmt.Errorf("unknown search mode: %s", query.Mode)
+	}
 	if err != nil {
-		s.logger.Error("Failed to query vector store", "error", err)
 		return nil, err
 	}
 
-	// Ensure we have complete document information
+	// Drop results below the requested similarity. MinScore only applies to
+	// vector similarity: hybrid search applies it to the vector results
+	// before fusion, and keyword results are BM25 scores, not similarities.
+	if query.Mode == "" || query.Mode == types.SearchModeVector {
+		results = dropBelow(results, query.MinScore)
+	}
+
+	if rerank {
+		results = s.rerankResults(ctx, query.Query, results)
+	}
+	if diversify {
+		results = s.diversify(ctx, results, query.MMRLambda, query.MaxPerDocument, limit)
+	}
+	// Reranking and MMR keep the retrieval order when their calls fail, so
+	// check for a cancelled request before hydrating the results
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	// Hydrate the parent documents from the SQL database
 	for i, result := range results {
-		// If the document content is empty, fetch it from the SQL database
-		if result.Document.Value == "" {
-			doc, err := s.repo.GetdocumentById(result.ID)
-			if err != nil {
-				s.logger.Error("Failed to get document from DB", "id", result.ID, "error", err)
-				continue
-			}
-			if doc != nil {
-				results[i].Document = *doc
-			}
+		doc, err := s.repo.GetdocumentById(result.Chunk.DocumentID)
+		if err != nil {
+			s.logger.Error("Failed to get document from DB", "id", result.Chunk.DocumentID, "error", err)
+			continue
 		}
+		if doc != nil {
+			results[i].Document = *doc
+		}
+	}
+
+	return results, nil
+}
+
+// vectorSearch embeds the query and returns the limit closest chunks
+func (s *Service) vectorSearch(ctx context.Context, query types.SearchQuery, limit int) ([]types.SearchResult, error) {
+	if s.vectorStore == nil {
+		s.logger.Error("Search requested but no vector store is configured")
+		return nil, errors.New("vector store not configured")
+	}
+
+	// Generate embedding for the query
+	embeddings, err := s.embedService.Embed(ctx, []string{query.Query})
+	if err != nil {
+		s.logger.Error("Failed to create embedding", "model", s.embedService.ModelID(), "error", err)
+		return nil, err
 	}
 
+	// Query the vector store
+	results, err := s.vectorStore.QueryDocuments(query.Query, embeddings[0], limit, query.Filter)
+	if err != nil {
+		s.logger.Error("Failed to query vector store", "error", err)
+		return nil, err
+	}
+	return results, nil
+}
+
+// keywordSearch returns the limit chunks that best match the query terms
+func (s *Service) keywordSearch(query types.SearchQuery, limit int) ([]types.SearchResult, error) {
+	if s.keywordIndex == nil {
+		s.logger.Error("Keyword search requested but no keyword index is configured")
+		return nil, types.ErrKeywordSearchUnavailable
+	}
+
+	results, err := s.keywordIndex.Search(query.Query, limit, query.Filter)
+	if err != nil {
+		s.logger.Error("Failed to query keyword index", "error", err)
+		return nil, err
+	}
 	return results, nil
 }
+
+// hybridSearch runs vector and keyword search over an over-fetched candidate
+// set and fuses the two rankings
+func (s *Service) hybridSearch(ctx context.Context, query types.SearchQuery, limit int) ([]types.SearchResult, error) {
+	if s.keywordIndex == nil {
+		s.logger.Error("Hybrid search requested but no keyword index is configured")
+		return nil, types.ErrKeywordSearchUnavailable
+	}
+
+	candidates := limit * hybridOverfetch
+	vectorResults, err := s.vectorSearch(ctx, query, candidates)
+	if err != nil {
+		return nil, err
+	}
+	vectorResults = dropBelow(vectorResults, query.MinScore)
+	keywordResults, err := s.keywordSearch(query, candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	vectorWeight := 0.5
+	if query.VectorWeight != nil {
+		vectorWeight = *query.VectorWeight
+	}
+	return fuseResults(vectorResults, keywordResults, query.Fusion, vectorWeight, limit), nil
+}
+
+// dropBelow removes the results scoring below minScore, keeping their order
+func dropBelow(results []types.SearchResult, minScore float64) []types.SearchResult {
+	if minScore <= 0 {
+		return results
+	}
+
+	kept := results[:0]
+	for _, result := range results {
+		if result.Score >= minScore {
+			kept = append(kept, result)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"context"
+	"testing"
+
+	"github.com/robstave/gorag/internal/domain/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchWithoutKeywordIndex(t *testing.T) {
+	service, _ := newTestService(t, newMemoryStore(t))
+
+	for _, mode := range []string{types.SearchModeKeyword, types.SearchModeHybrid} {
+		_, err := service.SearchDocuments(context.Background(), types.SearchQuery{Query: "anything", Mode: mode})
+		assert.ErrorIs(t, err, types.ErrKeywordSearchUnavailable, mode)
+	}
+
+	results, err := service.SearchDocuments(context.Background(), types.SearchQuery{Query: "anything"})
+	require.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func TestMinScoreOnlyAppliesToVectorSimilarity(t *testing.T) {
+	store := newMemoryStore(t)
+	service, _ := newTestService(t, store)
+
+	texts := []string{"reset the router password", "banana bread recipe with walnuts"}
+	embeddings, err := service.embedService.Embed(context.Background(), texts)
+	require.NoError(t, err)
+	require.NoError(t, store.AddChunks([]types.Chunk{
+		{ID: "close:0", DocumentID: "close", Text: texts[0]},
+		{ID: "far:0", DocumentID: "far", Text: texts[1]},
+	}, embeddings))
+	service.keywordIndex = &stubKeywordIndex{results: ranked("bm25", "keyword:0", 0.2, "keyword:1", 0.1)}
+
+	results, err := service.SearchDocuments(context.Background(), types.SearchQuery{Query: texts[0], MinScore: 0.9})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "close:0", results[0].ID)
+
+	// BM25 scores are not on the similarity scale, so keyword mode keeps them
+	results, err = service.SearchDocuments(context.Background(), types.SearchQuery{Query: texts[0], Mode: types.SearchModeKeyword, MinScore: 0.9})
+	require.NoError(t, err)
+	assert.Len(t, results, 2)
+}
+
+func TestSearchStopsWhenCancelled(t *testing.T) {
+	store := newMemoryStore(t)
+	service, _ := newTestService(t, store)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// The request context reaches the query embedding
+	_, err := service.SearchDocuments(ctx, types.SearchQuery{Query: "router password"})
+	assert.ErrorIs(t, err, context.Canceled)
+
+	// and the reranker, whose failure would otherwise keep the retrieval order
+	reranker := &stubReranker{scores: []float64{0.5, 0.4}}
+	service.reranker = reranker
+	service.keywordIndex = &stubKeywordIndex{results: ranked("bm25", "keyword:0", 0.2, "keyword:1", 0.1)}
+	_, err = service.SearchDocuments(ctx, types.SearchQuery{Query: "router password", Mode: types.SearchModeKeyword})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, reranker.calls)
+	assert.ErrorIs(t, reranker.ctxErr, context.Canceled)
+}
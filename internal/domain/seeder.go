@@ -34,9 +34,9 @@ func (s *Service) Seeddocument() error {
 		return nil
 	}
 
-	// Create the sample documents
+	// Create the sample documents through the service so they are indexed too
 	for _, document := range defaultdocuments {
-		if err := s.repo.Createdocument(document); err != nil {
+		if _, err := s.Createdocument(document); err != nil {
 			s.logger.Error("Failed to seed document", "name", document.Name, "error", err)
 			return err
 		}
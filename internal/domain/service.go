@@ -1,11 +1,18 @@
 package domain
 
 import (
+	"context"
 	"log/slog"
+	"time"
 
 	"github.com/robstave/gorag/internal/adapters/repositories"
+	"github.com/robstave/gorag/internal/adapters/repositories/keyword"
 	"github.com/robstave/gorag/internal/adapters/repositories/vectorstore"
+	"github.com/robstave/gorag/internal/domain/chunking"
 	"github.com/robstave/gorag/internal/domain/embedding"
+	"github.com/robstave/gorag/internal/domain/llm"
+	"github.com/robstave/gorag/internal/domain/prompt"
+	"github.com/robstave/gorag/internal/domain/rerank"
 	"github.com/robstave/gorag/internal/domain/types"
 )
 
@@ -13,7 +20,12 @@ type Service struct {
 	logger       *slog.Logger
 	repo         repositories.Repository
 	vectorStore  vectorstore.VectorStore
-	embedService embedding.EmbeddingService
+	keywordIndex keyword.Index
+	embedService embedding.Embedder
+	reranker     rerank.Reranker
+	chatModel    llm.LLM
+	prompts      *prompt.Registry
+	chunker      *chunking.Chunker
 }
 
 type Domain interface {
@@ -23,16 +35,37 @@ type Domain interface {
 	Updatedocument(document types.Document) (*types.Document, error)
 	Deletedocument(documentID string) error
 	Seeddocument() error
-	SearchDocuments(query types.SearchQuery) ([]types.SearchResult, error)
+	SearchDocuments(ctx context.Context, query types.SearchQuery) ([]types.SearchResult, error)
+	Ask(ctx context.Context, request types.AskRequest) (*types.AskResponse, error)
+	AskStream(ctx context.Context, request types.AskRequest, emit func(types.AskEvent) error) error
+	CreateConversation(title string) (*types.Conversation, error)
+	GetConversations() ([]types.Conversation, error)
+	GetConversationByID(conversationID string) (*types.Conversation, error)
+	DeleteConversation(conversationID string) error
+	SendMessage(ctx context.Context, conversationID string, request types.AskRequest) (*types.ConversationTurn, error)
+	StartOutboxDispatcher(ctx context.Context, interval time.Duration)
+	DispatchOutbox() error
+	Reconcile(repair bool) (*types.ReconcileReport, error)
+	EmbeddingCacheStats() (*types.EmbeddingCacheStats, error)
+	PruneEmbeddingCache(keepModels []string) (int64, error)
 }
 
-// NewService creates a new instance of the domain service
-func NewService(logger *slog.Logger, repo repositories.Repository, vectorStore vectorstore.VectorStore, embedService embedding.EmbeddingService) Domain {
+// NewService creates a new instance of the domain service. vectorStore and
+// keywordIndex may be nil to disable vector and keyword search, reranker may
+// be nil to return results in retrieval order and chatModel may be nil to
+// disable question answering and conversations. prompts renders the prompts
+// sent to the chat model.
+func NewService(logger *slog.Logger, repo repositories.Repository, vectorStore vectorstore.VectorStore, keywordIndex keyword.Index, embedService embedding.Embedder, reranker rerank.Reranker, chatModel llm.LLM, prompts *prompt.Registry, chunker *chunking.Chunker) Domain {
 	service := &Service{
 		logger:       logger,
 		repo:         repo,
 		vectorStore:  vectorStore,
+		keywordIndex: keywordIndex,
 		embedService: embedService,
+		reranker:     reranker,
+		chatModel:    chatModel,
+		prompts:      prompts,
+		chunker:      chunker,
 	}
 
 	// Seed the initial documents. This is called on every startup but will only create documents if they don't already exist
@@ -41,5 +74,10 @@ func NewService(logger *slog.Logger, repo repositories.Repository, vectorStore v
 		logger.Error("Failed to seed initial documents", "error", err)
 	}
 
+	// Fill a newly created keyword index with the existing documents
+	if err := service.backfillKeywordIndex(); err != nil {
+		logger.Error("Failed to backfill keyword index", "error", err)
+	}
+
 	return service
 }
@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"io"
+	"log/slog"
+	"path/filepath"
+	"testing"
+
+	"github.com/robstave/gorag/internal/adapters/repositories"
+	"github.com/robstave/gorag/internal/adapters/repositories/vectorstore"
+	"github.com/robstave/gorag/internal/domain/chunking"
+	"github.com/robstave/gorag/internal/domain/embedding"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestService returns a service backed by a fresh SQLite database, the
+// local embedder and the given vector store, without seeding
+func newTestService(t *testing.T, store vectorstore.VectorStore) (*Service, repositories.Repository) {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, repositories.Migrate(db))
+
+	chunkConfig := chunking.DefaultConfig()
+	chunkConfig.Size = 100
+	chunkConfig.Overlap = 10
+	chunker, err := chunking.NewChunker(chunkConfig)
+	require.NoError(t, err)
+
+	repo := repositories.NewRepository(db)
+	service := &Service{
+		logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+		repo:         repo,
+		vectorStore:  store,
+		embedService: embedding.NewLocalEmbedder(0),
+		chunker:      chunker,
+	}
+	return service, repo
+}
+
+// newMemoryStore returns an in-memory vector store without a snapshot
+func newMemoryStore(t *testing.T) *vectorstore.MemoryStore {
+	t.Helper()
+
+	store, err := vectorstore.NewMemoryStore(vectorstore.MetricCosine, "", slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	return store
+}
@@ -0,0 +1,102 @@
+package types
+
+import "errors"
+
+// ErrLLMUnavailable is returned when a question is asked but no chat model is configured
+var ErrLLMUnavailable = errors.New("no chat model is configured")
+
+// ErrUnknownPromptTemplate is returned when a request names a prompt set that is not loaded
+var ErrUnknownPromptTemplate = errors.New("unknown prompt template")
+
+// AskRequest is a question to answer from the indexed documents. Limit, Mode,
+// MinScore and Filter control retrieval as they do for search.
+// MaxContextTokens is the budget for the sources included in the prompt.
+// Template names the prompt set to use (default "default").
+type AskRequest struct {
+	Question         string  `json:"question"`
+	Limit            int     `json:"limit,omitempty"`
+	Mode             string  `json:"mode,omitempty"`
+	MinScore         float64 `json:"min_score,omitempty"`
+	Filter           *Filter `json:"filter,omitempty"`
+	MaxContextTokens int     `json:"max_context_tokens,omitempty"`
+	Template         string  `json:"template,omitempty"`
+}
+
+// AskSource is a retrieved chunk given to the model, numbered as it was in the prompt
+type AskSource struct {
+	Number       int     `json:"number"`
+	DocumentID   string  `json:"document_id"`
+	DocumentName string  `json:"document_name"`
+	ChunkID      string  `json:"chunk_id"`
+	Start        int     `json:"start"`
+	End          int     `json:"end"`
+	Score        float64 `json:"score"`
+	Text         string  `json:"text"`
+}
+
+// Citation maps a [n] marker in the answer to the document span it refers to.
+// Claims are the sentences of the answer that carry the marker.
+type Citation struct {
+	Number     int      `json:"number"`
+	DocumentID string   `json:"document_id"`
+	ChunkID    string   `json:"chunk_id"`
+	Start      int      `json:"start"`
+	End        int      `json:"end"`
+	Claims     []string `json:"claims"`
+}
+
+// TokenUsage counts the tokens a chat model consumed
+type TokenUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// Add returns the sum of two usages
+func (u TokenUsage) Add(other TokenUsage) TokenUsage {
+	return TokenUsage{
+		PromptTokens:     u.PromptTokens + other.PromptTokens,
+		CompletionTokens: u.CompletionTokens + other.CompletionTokens,
+		TotalTokens:      u.TotalTokens + other.TotalTokens,
+	}
+}
+
+// AskResponse is a generated answer with the sources it was given and the
+// citations it made
+type AskResponse struct {
+	Question  string      `json:"question"`
+	Answer    string      `json:"answer"`
+	Citations []Citation  `json:"citations"`
+	Sources   []AskSource `json:"sources"`
+	Model     string      `json:"model"`
+	Usage     TokenUsage  `json:"usage"`
+}
+
+// Event types sent by a streamed answer, in the order they occur. An error
+// event replaces the rest of the stream when generation fails part way.
+const (
+	AskEventSources   = "sources"
+	AskEventDelta     = "delta"
+	AskEventCitations = "citations"
+	AskEventDone      = "done"
+	AskEventError     = "error"
+)
+
+// AskEvent is one step of a streamed answer. Data is []AskSource for sources,
+// AskDelta for delta, []Citation for citations and AskDone for done.
+type AskEvent struct {
+	Type string
+	Data interface{}
+}
+
+// AskDelta is a piece of the answer as the model generates it
+type AskDelta struct {
+	Content string `json:"content"`
+}
+
+// AskDone ends a streamed answer with the complete text and token usage
+type AskDone struct {
+	Answer string     `json:"answer"`
+	Model  string     `json:"model"`
+	Usage  TokenUsage `json:"usage"`
+}
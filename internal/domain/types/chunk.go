@@ -0,0 +1,16 @@
+package types
+
+// Chunk is a span of a document that is embedded and stored in the vector store
+// as its own entry. Start and End are byte offsets into the parent document value.
+type Chunk struct {
+	ID          string `json:"id"`
+	DocumentID  string `json:"document_id"`
+	Index       int    `json:"index"`
+	Start       int    `json:"start"`
+	End         int    `json:"end"`
+	Text        string `json:"text"`
+	ContentHash string `json:"content_hash,omitempty"`
+	// Metadata is the parent document's search metadata, stored with the chunk
+	// so searches can be filtered on it
+	Metadata map[string]interface{} `json:"-"`
+}
@@ -0,0 +1,43 @@
+package types
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrConversationNotFound is returned for an unknown conversation ID
+var ErrConversationNotFound = errors.New("conversation not found")
+
+// Conversation is a persisted multi-turn chat. Messages are only loaded when
+// a single conversation is fetched.
+type Conversation struct {
+	ID        string                `gorm:"primaryKey" json:"id"`
+	Title     string                `gorm:"size:255" json:"title"`
+	Messages  []ConversationMessage `gorm:"foreignKey:ConversationID" json:"messages,omitempty"`
+	CreatedAt time.Time             `json:"created_at"`
+	UpdatedAt time.Time             `json:"updated_at"`
+}
+
+// ConversationMessage is one message of a conversation. Assistant messages
+// also record the standalone query used for retrieval, the sources the model
+// was given, the citations it made and the tokens it used.
+type ConversationMessage struct {
+	ID             uint        `gorm:"primaryKey;autoIncrement" json:"id"`
+	ConversationID string      `gorm:"index;not null" json:"conversation_id"`
+	Role           string      `gorm:"size:16;not null" json:"role"`
+	Content        string      `gorm:"type:text;not null" json:"content"`
+	Query          string      `gorm:"type:text" json:"query,omitempty"`
+	Sources        []AskSource `gorm:"type:text;serializer:json" json:"sources,omitempty"`
+	Citations      []Citation  `gorm:"type:text;serializer:json" json:"citations,omitempty"`
+	Model          string      `gorm:"size:200" json:"model,omitempty"`
+	Usage          *TokenUsage `gorm:"type:text;serializer:json" json:"usage,omitempty"`
+	CreatedAt      time.Time   `json:"created_at"`
+}
+
+// ConversationTurn is the result of posting a message: the stored question
+// and the stored answer
+type ConversationTurn struct {
+	ConversationID string              `json:"conversation_id"`
+	Question       ConversationMessage `json:"question"`
+	Answer         ConversationMessage `json:"answer"`
+}
@@ -2,13 +2,99 @@
 package types
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"time"
 )
 
+// DefaultCollection is the collection documents are placed in when none is given
+const DefaultCollection = "default"
+
+// Document is a named body of text. Names are unique within a collection.
+// Metadata holds arbitrary string, number and boolean values and Tags are
+// labels; both are pushed into the vector store so searches can filter on them.
 type Document struct {
-	ID        string    `gorm:"primaryKey" json:"id"`
-	Name      string    `gorm:"uniqueIndex;size:100;not null" json:"name"`
-	Value     string    `gorm:"size:255;not null" json:"value"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID         string                 `gorm:"primaryKey" json:"id"`
+	Collection string                 `gorm:"uniqueIndex:idx_documents_collection_name;size:100;not null;default:default" json:"collection"`
+	Name       string                 `gorm:"uniqueIndex:idx_documents_collection_name;size:255;not null" json:"name"`
+	Value      string                 `gorm:"type:text;not null" json:"value"`
+	Metadata   map[string]interface{} `gorm:"type:text;serializer:json" json:"metadata,omitempty"`
+	Tags       []string               `gorm:"type:text;serializer:json" json:"tags,omitempty"`
+	CreatedAt  time.Time              `json:"created_at"`
+	UpdatedAt  time.Time              `json:"updated_at"`
+}
+
+// ErrInvalidMetadata is returned when a document's metadata or tags are rejected
+var ErrInvalidMetadata = errors.New("invalid metadata")
+
+// ReservedMetadataKeys are set by gorag on every vector store entry and cannot
+// be used as document metadata keys
+var ReservedMetadataKeys = map[string]bool{
+	"document_id":  true,
+	"chunk_id":     true,
+	"chunk_index":  true,
+	"start":        true,
+	"end":          true,
+	"content_hash": true,
+	"text":         true,
+	"collection":   true,
+	"name":         true,
+	"created_at":   true,
+	"updated_at":   true,
+	TagsField:      true,
+}
+
+// ValidateMetadata checks that metadata keys are usable by every vector store
+// and values are scalars, and that tags are non-empty
+func (d Document) ValidateMetadata() error {
+	for key, value := range d.Metadata {
+		if !ValidMetadataKey(key) {
+			return fmt.Errorf("%w: invalid key %q, keys must be letters, digits and underscores", ErrInvalidMetadata, key)
+		}
+		if ReservedMetadataKeys[key] {
+			return fmt.Errorf("%w: key %q is reserved", ErrInvalidMetadata, key)
+		}
+		switch value.(type) {
+		case string, float64, bool:
+		default:
+			return fmt.Errorf("%w: value of %q must be a string, number or boolean", ErrInvalidMetadata, key)
+		}
+	}
+	for _, tag := range d.Tags {
+		if tag == "" {
+			return fmt.Errorf("%w: tags must not be empty", ErrInvalidMetadata)
+		}
+	}
+	return nil
+}
+
+// SearchMetadata returns the metadata stored with every chunk of the document:
+// its collection, name, tags, timestamps as Unix seconds and its own metadata
+// with dates normalized by NormalizeMetadataValue
+func (d Document) SearchMetadata() map[string]interface{} {
+	metadata := make(map[string]interface{}, len(d.Metadata)+5)
+	for key, value := range d.Metadata {
+		metadata[key] = NormalizeMetadataValue(value)
+	}
+	metadata["collection"] = d.Collection
+	metadata["name"] = d.Name
+	if len(d.Tags) > 0 {
+		metadata[TagsField] = d.Tags
+	}
+	if !d.CreatedAt.IsZero() {
+		metadata["created_at"] = float64(d.CreatedAt.Unix())
+	}
+	if !d.UpdatedAt.IsZero() {
+		metadata["updated_at"] = float64(d.UpdatedAt.Unix())
+	}
+	return metadata
+}
+
+// ContentHash returns the hex-encoded SHA-256 of the document value. It is
+// stored alongside the vector entry so stale embeddings can be detected.
+func (d Document) ContentHash() string {
+	sum := sha256.Sum256([]byte(d.Value))
+	return hex.EncodeToString(sum[:])
 }
@@ -0,0 +1,33 @@
+package types
+
+import (
+	"time"
+)
+
+// EmbeddingCacheEntry is a cached embedding keyed by model, dimension and the
+// SHA-256 of the embedded text
+type EmbeddingCacheEntry struct {
+	Model     string    `gorm:"primaryKey;size:200" json:"model"`
+	Dimension int       `gorm:"primaryKey;autoIncrement:false" json:"dimension"`
+	Hash      string    `gorm:"primaryKey;size:64" json:"hash"`
+	Vector    []byte    `gorm:"not null" json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// EmbeddingCacheModelStats counts the cached embeddings for one model and dimension
+type EmbeddingCacheModelStats struct {
+	Model     string `json:"model"`
+	Dimension int    `json:"dimension"`
+	Entries   int64  `json:"entries"`
+}
+
+// EmbeddingCacheStats reports cache effectiveness since startup and the
+// entries stored per model
+type EmbeddingCacheStats struct {
+	Enabled bool                       `json:"enabled"`
+	Model   string                     `json:"model"`
+	Hits    int64                      `json:"hits"`
+	Misses  int64                      `json:"misses"`
+	HitRate float64                    `json:"hit_rate"`
+	Models  []EmbeddingCacheModelStats `json:"models"`
+}
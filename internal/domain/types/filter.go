@@ -0,0 +1,364 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Filter operators
+const (
+	FilterAnd      = "and"
+	FilterOr       = "or"
+	FilterEq       = "eq"
+	FilterNe       = "ne"
+	FilterIn       = "in"
+	FilterNin      = "nin"
+	FilterGt       = "gt"
+	FilterGte      = "gte"
+	FilterLt       = "lt"
+	FilterLte      = "lte"
+	FilterContains = "contains"
+)
+
+// TagsField is the metadata field holding a document's tags. It is the only
+// list-valued field and the only one that supports contains.
+const TagsField = "tags"
+
+// ErrInvalidFilter is returned for malformed filter expressions
+var ErrInvalidFilter = errors.New("invalid filter")
+
+// metadataKeyPattern restricts metadata keys so every backend can address them
+var metadataKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ValidMetadataKey reports whether key may be used as a metadata key or filter field
+func ValidMetadataKey(key string) bool {
+	return len(key) <= 64 && metadataKeyPattern.MatchString(key)
+}
+
+// Filter is a parsed metadata filter. And and Or combine Filters; every other
+// operator compares Field against Value, a string, float64 or bool, or
+// against Values for In and Nin. Dates are converted to Unix seconds when
+// parsed, matching how they are stored in the vector store. Every comparison,
+// including Ne and Nin, only matches entries that have the field.
+type Filter struct {
+	Op      string        `json:"op"`
+	Field   string        `json:"field,omitempty"`
+	Value   interface{}   `json:"value"`
+	Values  []interface{} `json:"values,omitempty"`
+	Filters []Filter      `json:"filters,omitempty"`
+}
+
+// ParseFilter parses a filter expression written in the Chroma/MongoDB style:
+//
+//	{"lang": "go"}
+//	{"year": {"$gte": 2020, "$lt": 2024}}
+//	{"$or": [{"lang": {"$in": ["go", "rust"]}}, {"tags": {"$contains": "cli"}}]}
+//
+// An object with several keys is an implicit $and. Supported operators are
+// $eq, $ne, $in, $nin, $gt, $gte, $lt, $lte, $contains, $and and $or.
+func ParseFilter(data []byte) (*Filter, error) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFilter, err)
+	}
+	filter, err := parseFilterObject(raw)
+	if err != nil {
+		return nil, err
+	}
+	return &filter, nil
+}
+
+func parseFilterObject(raw map[string]interface{}) (Filter, error) {
+	if len(raw) == 0 {
+		return Filter{}, fmt.Errorf("%w: empty expression", ErrInvalidFilter)
+	}
+
+	// Sort keys so the parsed filter, and the queries built from it, are stable
+	keys := make([]string, 0, len(raw))
+	for key := range raw {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var parts []Filter
+	for _, key := range keys {
+		value := raw[key]
+		switch key {
+		case "$and", "$or":
+			children, ok := value.([]interface{})
+			if !ok || len(children) == 0 {
+				return Filter{}, fmt.Errorf("%w: %s needs a non-empty list", ErrInvalidFilter, key)
+			}
+			group := Filter{Op: strings.TrimPrefix(key, "$")}
+			for _, child := range children {
+				object, ok := child.(map[string]interface{})
+				if !ok {
+					return Filter{}, fmt.Errorf("%w: %s items must be objects", ErrInvalidFilter, key)
+				}
+				parsed, err := parseFilterObject(object)
+				if err != nil {
+					return Filter{}, err
+				}
+				group.Filters = append(group.Filters, parsed)
+			}
+			parts = append(parts, group.simplify())
+		default:
+			if strings.HasPrefix(key, "$") {
+				return Filter{}, fmt.Errorf("%w: unknown operator %s", ErrInvalidFilter, key)
+			}
+			conditions, err := parseFieldConditions(key, value)
+			if err != nil {
+				return Filter{}, err
+			}
+			parts = append(parts, conditions...)
+		}
+	}
+
+	return Filter{Op: FilterAnd, Filters: parts}.simplify(), nil
+}
+
+// parseFieldConditions parses the conditions on one field: either a bare value
+// for equality or an object of operators
+func parseFieldConditions(field string, value interface{}) ([]Filter, error) {
+	if !ValidMetadataKey(field) {
+		return nil, fmt.Errorf("%w: invalid field name %q", ErrInvalidFilter, field)
+	}
+
+	operators, ok := value.(map[string]interface{})
+	if !ok {
+		condition, err := newCondition(field, FilterEq, value)
+		if err != nil {
+			return nil, err
+		}
+		return []Filter{condition}, nil
+	}
+	if len(operators) == 0 {
+		return nil, fmt.Errorf("%w: no operators for %s", ErrInvalidFilter, field)
+	}
+
+	names := make([]string, 0, len(operators))
+	for name := range operators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	conditions := make([]Filter, 0, len(names))
+	for _, name := range names {
+		op := strings.TrimPrefix(name, "$")
+		if op == name {
+			return nil, fmt.Errorf("%w: operator %q must start with $", ErrInvalidFilter, name)
+		}
+		condition, err := newCondition(field, op, operators[name])
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, condition)
+	}
+	return conditions, nil
+}
+
+// newCondition validates and normalizes a single field comparison
+func newCondition(field, op string, value interface{}) (Filter, error) {
+	condition := Filter{Op: op, Field: field}
+
+	switch op {
+	case FilterEq, FilterNe:
+		scalar, err := filterScalar(value)
+		if err != nil {
+			return Filter{}, fmt.Errorf("%w: %s on %s: %v", ErrInvalidFilter, op, field, err)
+		}
+		condition.Value = scalar
+	case FilterIn, FilterNin:
+		list, ok := value.([]interface{})
+		if !ok || len(list) == 0 {
+			return Filter{}, fmt.Errorf("%w: %s on %s needs a non-empty list", ErrInvalidFilter, op, field)
+		}
+		for _, item := range list {
+			scalar, err := filterScalar(item)
+			if err != nil {
+				return Filter{}, fmt.Errorf("%w: %s on %s: %v", ErrInvalidFilter, op, field, err)
+			}
+			condition.Values = append(condition.Values, scalar)
+		}
+	case FilterGt, FilterGte, FilterLt, FilterLte:
+		scalar, err := filterScalar(value)
+		if err != nil {
+			return Filter{}, fmt.Errorf("%w: %s on %s: %v", ErrInvalidFilter, op, field, err)
+		}
+		if _, ok := scalar.(float64); !ok {
+			return Filter{}, fmt.Errorf("%w: %s on %s needs a number or date", ErrInvalidFilter, op, field)
+		}
+		condition.Value = scalar
+	case FilterContains:
+		if field != TagsField {
+			return Filter{}, fmt.Errorf("%w: contains is only supported on %s", ErrInvalidFilter, TagsField)
+		}
+		tag, ok := value.(string)
+		if !ok || tag == "" {
+			return Filter{}, fmt.Errorf("%w: contains needs a non-empty string", ErrInvalidFilter)
+		}
+		condition.Value = tag
+	default:
+		return Filter{}, fmt.Errorf("%w: unknown operator $%s", ErrInvalidFilter, op)
+	}
+
+	return condition, nil
+}
+
+// filterScalar normalizes a filter value the same way metadata values are
+// normalized before they are stored
+func filterScalar(value interface{}) (interface{}, error) {
+	switch value.(type) {
+	case string, float64, bool:
+		return NormalizeMetadataValue(value), nil
+	default:
+		return nil, fmt.Errorf("unsupported value %v", value)
+	}
+}
+
+// simplify collapses groups with a single child
+func (f Filter) simplify() Filter {
+	if (f.Op == FilterAnd || f.Op == FilterOr) && len(f.Filters) == 1 {
+		return f.Filters[0]
+	}
+	return f
+}
+
+// Match reports whether metadata satisfies the filter. It is used by the
+// stores that evaluate filters in process.
+func (f Filter) Match(metadata map[string]interface{}) bool {
+	switch f.Op {
+	case FilterAnd:
+		for _, child := range f.Filters {
+			if !child.Match(metadata) {
+				return false
+			}
+		}
+		return true
+	case FilterOr:
+		for _, child := range f.Filters {
+			if child.Match(metadata) {
+				return true
+			}
+		}
+		return false
+	}
+
+	actual, present := metadata[f.Field]
+	switch f.Op {
+	case FilterEq:
+		return present && metadataEqual(actual, f.Value)
+	case FilterNe:
+		return present && !metadataEqual(actual, f.Value)
+	case FilterIn:
+		if !present {
+			return false
+		}
+		for _, value := range f.Values {
+			if metadataEqual(actual, value) {
+				return true
+			}
+		}
+		return false
+	case FilterNin:
+		if !present {
+			return false
+		}
+		for _, value := range f.Values {
+			if metadataEqual(actual, value) {
+				return false
+			}
+		}
+		return true
+	case FilterGt, FilterGte, FilterLt, FilterLte:
+		number, ok := metadataNumber(actual)
+		if !ok {
+			return false
+		}
+		bound := f.Value.(float64)
+		switch f.Op {
+		case FilterGt:
+			return number > bound
+		case FilterGte:
+			return number >= bound
+		case FilterLt:
+			return number < bound
+		default:
+			return number <= bound
+		}
+	case FilterContains:
+		for _, tag := range metadataStrings(actual) {
+			if tag == f.Value {
+				return true
+			}
+		}
+		return false
+	}
+	return false
+}
+
+// NormalizeMetadataValue converts dates given as RFC 3339 timestamps or
+// YYYY-MM-DD strings to Unix seconds so they can be compared as numbers.
+// Other values are returned unchanged.
+func NormalizeMetadataValue(value interface{}) interface{} {
+	s, ok := value.(string)
+	if !ok {
+		return value
+	}
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return float64(t.Unix())
+		}
+	}
+	return value
+}
+
+func metadataEqual(actual, expected interface{}) bool {
+	if a, ok := metadataNumber(actual); ok {
+		e, ok := expected.(float64)
+		return ok && a == e
+	}
+	return actual == expected
+}
+
+// metadataNumber returns a numeric metadata value as float64
+func metadataNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// metadataStrings returns a list-valued metadata value as strings
+func metadataStrings(value interface{}) []string {
+	switch v := value.(type) {
+	case []string:
+		return v
+	case []interface{}:
+		strs := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				strs = append(strs, s)
+			}
+		}
+		return strs
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,133 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  string
+		want    Filter
+		wantErr bool
+	}{
+		{
+			name:   "implicit equality",
+			filter: `{"lang": "go"}`,
+			want:   Filter{Op: FilterEq, Field: "lang", Value: "go"},
+		},
+		{
+			name:   "several keys are an and in key order",
+			filter: `{"year": 2020, "draft": false}`,
+			want: Filter{Op: FilterAnd, Filters: []Filter{
+				{Op: FilterEq, Field: "draft", Value: false},
+				{Op: FilterEq, Field: "year", Value: float64(2020)},
+			}},
+		},
+		{
+			name:   "range operators",
+			filter: `{"year": {"$lt": 2024, "$gte": 2020}}`,
+			want: Filter{Op: FilterAnd, Filters: []Filter{
+				{Op: FilterGte, Field: "year", Value: float64(2020)},
+				{Op: FilterLt, Field: "year", Value: float64(2024)},
+			}},
+		},
+		{
+			name:   "dates become unix seconds",
+			filter: `{"published": {"$gt": "2024-01-02"}}`,
+			want:   Filter{Op: FilterGt, Field: "published", Value: float64(1704153600)},
+		},
+		{
+			name:   "or of in and contains",
+			filter: `{"$or": [{"lang": {"$in": ["go", "rust"]}}, {"tags": {"$contains": "cli"}}]}`,
+			want: Filter{Op: FilterOr, Filters: []Filter{
+				{Op: FilterIn, Field: "lang", Values: []interface{}{"go", "rust"}},
+				{Op: FilterContains, Field: "tags", Value: "cli"},
+			}},
+		},
+		{
+			name:   "single child groups collapse",
+			filter: `{"$and": [{"lang": {"$ne": "go"}}]}`,
+			want:   Filter{Op: FilterNe, Field: "lang", Value: "go"},
+		},
+		{name: "not an object", filter: `["lang"]`, wantErr: true},
+		{name: "empty object", filter: `{}`, wantErr: true},
+		{name: "unknown operator", filter: `{"lang": {"$regex": "g.*"}}`, wantErr: true},
+		{name: "operator without dollar", filter: `{"lang": {"eq": "go"}}`, wantErr: true},
+		{name: "unknown top-level operator", filter: `{"$not": [{"lang": "go"}]}`, wantErr: true},
+		{name: "invalid field name", filter: `{"lang-name": "go"}`, wantErr: true},
+		{name: "empty or", filter: `{"$or": []}`, wantErr: true},
+		{name: "or of non-objects", filter: `{"$or": ["go"]}`, wantErr: true},
+		{name: "object value", filter: `{"lang": {"$eq": {"name": "go"}}}`, wantErr: true},
+		{name: "null value", filter: `{"lang": null}`, wantErr: true},
+		{name: "empty in", filter: `{"lang": {"$in": []}}`, wantErr: true},
+		{name: "range on a string", filter: `{"lang": {"$gt": "go"}}`, wantErr: true},
+		{name: "contains on another field", filter: `{"lang": {"$contains": "go"}}`, wantErr: true},
+		{name: "contains with an empty tag", filter: `{"tags": {"$contains": ""}}`, wantErr: true},
+		{name: "no operators", filter: `{"year": {}}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := ParseFilter([]byte(tt.filter))
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidFilter)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, *filter)
+		})
+	}
+}
+
+func TestFilterMatch(t *testing.T) {
+	var metadata map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"lang": "go",
+		"year": 2021,
+		"draft": false,
+		"published": 1704153600,
+		"tags": ["cli", "tools"]
+	}`), &metadata))
+
+	tests := []struct {
+		filter string
+		want   bool
+	}{
+		{filter: `{"lang": "go"}`, want: true},
+		{filter: `{"lang": "rust"}`, want: false},
+		{filter: `{"year": 2021}`, want: true},
+		{filter: `{"year": "2021"}`, want: false},
+		{filter: `{"draft": false}`, want: true},
+		{filter: `{"draft": 0}`, want: false},
+		{filter: `{"lang": {"$ne": "rust"}}`, want: true},
+		{filter: `{"lang": {"$ne": "go"}}`, want: false},
+		{filter: `{"missing": {"$ne": "go"}}`, want: false},
+		{filter: `{"lang": {"$in": ["go", "rust"]}}`, want: true},
+		{filter: `{"lang": {"$nin": ["go", "rust"]}}`, want: false},
+		{filter: `{"missing": {"$nin": ["go"]}}`, want: false},
+		{filter: `{"year": {"$gte": 2021, "$lt": 2022}}`, want: true},
+		{filter: `{"year": {"$gt": 2021}}`, want: false},
+		{filter: `{"year": {"$lte": 2021}}`, want: true},
+		{filter: `{"lang": {"$lt": 5}}`, want: false},
+		{filter: `{"published": {"$gte": "2024-01-02"}}`, want: true},
+		{filter: `{"published": {"$gt": "2024-01-02T00:00:00Z"}}`, want: false},
+		{filter: `{"tags": {"$contains": "cli"}}`, want: true},
+		{filter: `{"tags": {"$contains": "web"}}`, want: false},
+		{filter: `{"$or": [{"lang": "rust"}, {"tags": {"$contains": "tools"}}]}`, want: true},
+		{filter: `{"$or": [{"lang": "rust"}, {"year": 2020}]}`, want: false},
+		{filter: `{"$and": [{"lang": "go"}, {"year": 2020}]}`, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			filter, err := ParseFilter([]byte(tt.filter))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, filter.Match(metadata))
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package types
+
+import (
+	"time"
+)
+
+// Outbox operations
+const (
+	OutboxUpsert = "upsert"
+	OutboxDelete = "delete"
+)
+
+// Outbox statuses
+const (
+	OutboxPending = "pending"
+	OutboxDead    = "dead"
+)
+
+// OutboxEntry records a pending vector store change. Entries are written in the
+// same transaction as the document change and drained by the outbox dispatcher.
+type OutboxEntry struct {
+	ID            uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	DocumentID    string    `gorm:"index;not null" json:"document_id"`
+	Operation     string    `gorm:"size:16;not null" json:"operation"`
+	Status        string    `gorm:"size:16;not null;default:pending;index" json:"status"`
+	Attempts      int       `json:"attempts"`
+	LastError     string    `json:"last_error,omitempty"`
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
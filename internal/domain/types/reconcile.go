@@ -0,0 +1,22 @@
+package types
+
+// IndexedDocument is the minimal view of an entry stored in the vector store.
+// ID is the vector store entry (chunk) ID and DocumentID its parent document.
+type IndexedDocument struct {
+	ID          string `json:"id"`
+	DocumentID  string `json:"document_id"`
+	ContentHash string `json:"content_hash"`
+}
+
+// ReconcileReport describes the differences between SQLite and the vector store
+type ReconcileReport struct {
+	Documents int      `json:"documents"`
+	Indexed   int      `json:"indexed"`
+	Chunks    int      `json:"chunks"`
+	Missing   []string `json:"missing"`
+	Orphaned  []string `json:"orphaned"`
+	Stale     []string `json:"stale"`
+	Pending   []string `json:"pending"`
+	Repaired  bool     `json:"repaired"`
+	Errors    []string `json:"errors,omitempty"`
+}
@@ -1,16 +1,65 @@
 package types
 
-// SearchQuery represents a search request
+import "errors"
+
+// Search modes
+const (
+	SearchModeVector  = "vector"
+	SearchModeKeyword = "keyword"
+	SearchModeHybrid  = "hybrid"
+)
+
+// Fusion methods used to combine rankings in hybrid mode
+const (
+	FusionRRF      = "rrf"
+	FusionWeighted = "weighted"
+)
+
+// ErrKeywordSearchUnavailable is returned for keyword and hybrid searches when
+// no keyword index is configured, which is always the case with Postgres
+var ErrKeywordSearchUnavailable = errors.New("keyword search is not available: keyword and hybrid modes need SQLite built with FTS5")
+
+// SearchQuery represents a search request. Mode selects vector (default),
+// keyword or hybrid search. In hybrid mode Fusion selects how the two
+// rankings are combined and VectorWeight, between 0 and 1, how much the vector
+// ranking counts; nil means 0.5.
 type SearchQuery struct {
-	Query string `json:"query"`
-	Limit int    `json:"limit,omitempty"`
+	Query        string   `json:"query"`
+	Limit        int      `json:"limit,omitempty"`
+	Mode         string   `json:"mode,omitempty"`
+	Fusion       string   `json:"fusion,omitempty"`
+	VectorWeight *float64 `json:"vector_weight,omitempty"`
+	// MinScore drops results whose vector similarity is below it. In hybrid
+	// mode it applies before fusion and keyword matches are kept; keyword mode
+	// ignores it.
+	MinScore float64 `json:"min_score,omitempty"`
+	// Filter restricts results to chunks whose document metadata matches it
+	Filter *Filter `json:"filter,omitempty"`
+	// RerankCandidates is how many results are retrieved for the reranker to
+	// choose the top Limit from (default 50). SkipRerank disables reranking.
+	RerankCandidates int  `json:"rerank_candidates,omitempty"`
+	SkipRerank       bool `json:"skip_rerank,omitempty"`
+	// MMRLambda enables Maximal Marginal Relevance, trading relevance (1)
+	// against diversity (0). MaxPerDocument caps the chunks returned from any
+	// one document. Both are off when unset.
+	MMRLambda      *float64 `json:"mmr_lambda,omitempty"`
+	MaxPerDocument int      `json:"max_per_document,omitempty"`
 }
 
-// SearchResult represents a single document result with its similarity score
+// SearchResult represents a single matched chunk. Score is a similarity in
+// [0, 1] where higher is better, comparable across vector stores; Distance is
+// the store's raw distance under Metric, where lower is better. Keyword
+// results report the BM25 rank as their distance. RerankScore is the
+// reranker's relevance in [0, 1] when results were reranked. Document is
+// the parent document and Chunk holds the matched span.
 type SearchResult struct {
-	ID       string   `json:"id"`
-	Score    float64  `json:"score"`
-	Document Document `json:"document"`
+	ID          string   `json:"id"`
+	Score       float64  `json:"score"`
+	Distance    float64  `json:"distance"`
+	Metric      string   `json:"metric"`
+	RerankScore *float64 `json:"rerank_score,omitempty"`
+	Document    Document `json:"document"`
+	Chunk       Chunk    `json:"chunk"`
 }
 
 // SearchResponse represents the response to a search query
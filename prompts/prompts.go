@@ -0,0 +1,10 @@
+// Package prompts holds the default prompt templates. They are embedded in
+// the binary so that gorag runs without the prompts directory on disk.
+package prompts
+
+import "embed"
+
+// Defaults holds the default prompt set in default/
+//
+//go:embed default/*.tmpl
+var Defaults embed.FS